@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"tlgbot/internal/config"
+	"tlgbot/internal/services"
+	"tlgbot/internal/storage"
+)
+
+// runAnalytics writes drop-off funnels computed from persisted user states:
+// analytics [-state-dir dir] [-survey id] [-format json|csv] [-o file]
+func runAnalytics(args []string, out, errOut io.Writer) error {
+	flags := flag.NewFlagSet("analytics", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: telegram-bot analytics [-state-dir dir] [-survey id] [-format json|csv] [-o file]")
+		flags.PrintDefaults()
+	}
+	stateDir := flags.String("state-dir", os.Getenv(config.EnvStateDir), "directory with persisted user states, $"+config.EnvStateDir+" by default")
+	surveyID := flags.String("survey", "", "report a single survey")
+	format := flags.String("format", services.ExportFormatJSON, "output format (json or csv)")
+	output := flags.String("o", "", "output file instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("unexpected arguments")
+	}
+	if *stateDir == "" {
+		return errors.New("state directory is required, set -state-dir or " + config.EnvStateDir)
+	}
+
+	dir, err := storage.Open(*stateDir)
+	if err != nil {
+		return err
+	}
+	states, err := dir.LoadAll()
+	if err != nil {
+		return err
+	}
+	data, err := services.ExportFunnels(services.BuildFunnels(states, *surveyID), *format)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = out.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil { //nolint:gosec // G306: Funnels hold aggregated counts only
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+	"tlgbot/internal/storage"
+)
+
+func TestRunAnalytics(t *testing.T) {
+	stateDir := filepath.Join(t.TempDir(), "state")
+	dir, err := storage.NewDir(stateDir)
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+	startedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for userID, surveyID := range map[int64]string{1: "quiz", 2: "feedback"} {
+		state := models.NewUserState("User")
+		state.SurveyID = surveyID
+		state.StartedAt = startedAt
+		state.Reach("start", startedAt)
+		state.Reach("q1", startedAt.Add(time.Minute))
+		if err := dir.Save(userID, state); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "funnel.csv")
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expectedOut []string
+	}{
+		{name: "json", args: []string{"-state-dir", stateDir}, expectedOut: []string{`"survey_id": "feedback"`, `"survey_id": "quiz"`, `"median_time": "1m"`}},
+		{name: "csv of a survey", args: []string{"-state-dir", stateDir, "-survey", "quiz", "-format", "csv"}, expectedOut: []string{"quiz,start,1,0,0,1.000,60\n"}},
+		{name: "to file", args: []string{"-state-dir", stateDir, "-format", "csv", "-o", output}},
+		{name: "unsupported format", args: []string{"-state-dir", stateDir, "-format", "xml"}, expectError: true},
+		{name: "missing state directory", args: []string{"-state-dir", filepath.Join(stateDir, "missing")}, expectError: true},
+		{name: "no state directory", args: []string{"-state-dir", ""}, expectError: true},
+		{name: "unexpected argument", args: []string{"-state-dir", stateDir, "extra"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := runAnalytics(tt.args, &out, &errOut)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for _, want := range tt.expectedOut {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Expected funnel file: %v", err)
+	}
+	if !strings.Contains(string(data), "feedback,q1,1,1,0,0.000,0") {
+		t.Errorf("Expected feedback funnel in file, got:\n%s", data)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"tlgbot/internal/config"
+)
+
+// runConvert translates a questions or survey file between formats: convert [-to format] <input> [output]
+// Formats are taken from file extensions; without output the result is written to out.
+func runConvert(args []string, out, errOut io.Writer) error {
+	flags := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: telegram-bot convert [-to json|yaml|toml] <input> [output]")
+		flags.PrintDefaults()
+	}
+	to := flags.String("to", "", "output format when writing to standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return errors.New("expected input file and optional output file")
+	}
+
+	input, output := flags.Arg(0), flags.Arg(1)
+	from, err := config.FormatFromPath(input)
+	if err != nil {
+		return err
+	}
+
+	format := *to
+	if output != "" {
+		if format, err = config.FormatFromPath(output); err != nil {
+			return err
+		}
+		if filepath.Clean(output) == filepath.Clean(input) {
+			return errors.New("output file must differ from input file")
+		}
+	}
+	if format == "" {
+		return errors.New("output format is required, set -to or give output file")
+	}
+
+	data, err := os.ReadFile(input) //nolint:gosec // G304: Input path is given by the user
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", input, err)
+	}
+
+	converted, err := config.Convert(data, from, format)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s: %w", input, err)
+	}
+
+	if output == "" {
+		_, err = out.Write(converted)
+		return err
+	}
+	if err := os.WriteFile(output, converted, 0o644); err != nil { //nolint:gosec // G306: Question files are not secret
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunConvert(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "questions.json")
+	if err := os.WriteFile(input, []byte(`[{"id": "start", "text": "Hi"}]`), 0o600); err != nil {
+		t.Fatalf("Failed to write questions: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expectedOut string
+	}{
+		{name: "to standard output", args: []string{"-to", "yaml", input}, expectedOut: "- id: start\n  text: Hi\n"},
+		{name: "to file", args: []string{input, filepath.Join(dir, "questions.toml")}},
+		{name: "missing output format", args: []string{input}, expectError: true},
+		{name: "unsupported output extension", args: []string{input, filepath.Join(dir, "questions.xml")}, expectError: true},
+		{name: "same file", args: []string{input, input}, expectError: true},
+		{name: "missing input", args: []string{filepath.Join(dir, "missing.json"), filepath.Join(dir, "out.yaml")}, expectError: true},
+		{name: "no arguments", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := runConvert(tt.args, &out, &errOut)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if out.String() != tt.expectedOut {
+				t.Errorf("Expected output %q, got %q", tt.expectedOut, out.String())
+			}
+		})
+	}
+
+	converted, err := os.ReadFile(filepath.Join(dir, "questions.toml"))
+	if err != nil {
+		t.Fatalf("Expected converted file: %v", err)
+	}
+	if !strings.Contains(string(converted), "[[questions]]") {
+		t.Errorf("Expected TOML questions table, got:\n%s", converted)
+	}
+}
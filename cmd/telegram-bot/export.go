@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"tlgbot/internal/config"
+	"tlgbot/internal/services"
+	"tlgbot/internal/storage"
+)
+
+// runExport writes survey submissions read from persisted user states, one row per submission:
+// export [-state-dir dir] [-format csv|jsonl|xlsx] [-since time] [-survey id] [-o file]
+func runExport(args []string, out, errOut io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: telegram-bot export [-state-dir dir] [-format csv|jsonl|xlsx] [-since time] [-survey id] [-o file]")
+		flags.PrintDefaults()
+	}
+	stateDir := flags.String("state-dir", os.Getenv(config.EnvStateDir), "directory with persisted user states, $"+config.EnvStateDir+" by default")
+	format := flags.String("format", services.ExportFormatCSV, "output format (csv, jsonl or xlsx)")
+	sinceText := flags.String("since", "", "only submissions completed since a date (2024-05-01), time (RFC 3339) or duration ago (72h)")
+	surveyID := flags.String("survey", "", "export a single survey")
+	output := flags.String("o", "", "output file instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("unexpected arguments")
+	}
+	if *stateDir == "" {
+		return errors.New("state directory is required, set -state-dir or " + config.EnvStateDir)
+	}
+	since, err := parseSince(*sinceText, time.Now())
+	if err != nil {
+		return err
+	}
+
+	dir, err := storage.Open(*stateDir)
+	if err != nil {
+		return err
+	}
+	states, err := dir.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	records := services.CollectSubmissions(states, *surveyID)
+	columns := services.SubmissionColumns(records)
+	data, err := services.ExportSubmissions(services.SubmissionsSince(records, since), columns, *format)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = out.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+	return nil
+}
+
+// parseSince parses start of the export period: a date, an RFC 3339 time or a duration before now.
+// Empty text means no limit.
+func parseSince(text string, now time.Time) (time.Time, error) {
+	if text == "" {
+		return time.Time{}, nil
+	}
+	if since, err := time.Parse(time.RFC3339, text); err == nil {
+		return since, nil
+	}
+	if since, err := time.Parse(time.DateOnly, text); err == nil {
+		return since, nil
+	}
+	if ago, err := time.ParseDuration(text); err == nil && ago >= 0 {
+		return now.Add(-ago), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -since %q, expected a date such as 2024-05-01, an RFC 3339 time or a duration such as 72h", text)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+	"tlgbot/internal/storage"
+)
+
+func TestRunExport(t *testing.T) {
+	stateDir := filepath.Join(t.TempDir(), "state")
+	dir, err := storage.NewDir(stateDir)
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+	for userID, completedAt := range map[int64]time.Time{
+		1: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		2: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	} {
+		state := models.NewUserState("User")
+		state.SurveyID = "quiz"
+		state.RecordAnswer("q1", "Question?", "Yes")
+		state.Complete(userID, "end", completedAt)
+		if err := dir.Save(userID, state); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "responses.xlsx")
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expectedOut string
+	}{
+		{name: "csv", args: []string{"-state-dir", stateDir}, expectedOut: "quiz,2,,User,"},
+		{name: "jsonl since date", args: []string{"-state-dir", stateDir, "-format", "jsonl", "-since", "2024-05-15"}, expectedOut: `"user_id":2`},
+		{name: "other survey", args: []string{"-state-dir", stateDir, "-survey", "feedback"}, expectedOut: "survey_id,user_id"},
+		{name: "to file", args: []string{"-state-dir", stateDir, "-format", "xlsx", "-o", output}},
+		{name: "invalid since", args: []string{"-state-dir", stateDir, "-since", "yesterday"}, expectError: true},
+		{name: "unsupported format", args: []string{"-state-dir", stateDir, "-format", "xml"}, expectError: true},
+		{name: "no state directory", args: []string{"-state-dir", ""}, expectError: true},
+		{name: "unexpected argument", args: []string{"-state-dir", stateDir, "extra"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := runExport(tt.args, &out, &errOut)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !strings.Contains(out.String(), tt.expectedOut) {
+				t.Errorf("Expected output to contain %q, got:\n%s", tt.expectedOut, out.String())
+			}
+		})
+	}
+
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatalf("Expected export file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected export readable by owner only, got %v", info.Mode().Perm())
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text        string
+		expected    time.Time
+		expectError bool
+	}{
+		{text: "", expected: time.Time{}},
+		{text: "2024-05-01", expected: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{text: "2024-05-01T10:00:00+02:00", expected: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)},
+		{text: "72h", expected: time.Date(2024, 5, 7, 12, 0, 0, 0, time.UTC)},
+		{text: "-1h", expectError: true},
+		{text: "last week", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := parseSince(tt.text, now)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -2,87 +2,391 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
 
 	"tlgbot/internal/bot"
 	"tlgbot/internal/config"
 	"tlgbot/internal/handlers"
+	"tlgbot/internal/health"
+	"tlgbot/internal/logging"
+	"tlgbot/internal/messenger"
+	"tlgbot/internal/metrics"
 	"tlgbot/internal/models"
+	"tlgbot/internal/outbox"
+	"tlgbot/internal/scheduler"
+	"tlgbot/internal/secrets"
 	"tlgbot/internal/services"
+	"tlgbot/internal/storage"
+	"tlgbot/internal/webhook"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// initializeBot initializes all bot components and returns them or an error
-func initializeBot() (*tgbotapi.BotAPI, *handlers.TelegramHandler, *models.Config, error) {
-	// Load configuration
-	cfg, err := config.LoadFromEnv()
+// Update polling and health probing settings
+const (
+	pollTimeoutSeconds = 60
+	pollRetryDelay     = 3 * time.Second
+	pollingMaxAge      = 2*pollTimeoutSeconds*time.Second + 30*time.Second
+	getMeInterval      = 30 * time.Second
+	getMeMaxAge        = 2 * time.Minute
+)
+
+// app holds initialized bot components
+type app struct {
+	botAPI  *tgbotapi.BotAPI
+	handler *handlers.TelegramHandler
+	config  *models.Config
+	// polling beats after every successful getUpdates call
+	polling *health.Heartbeat
+}
+
+// initializeBot initializes all bot components from command-line arguments and returns them or an error
+func initializeBot(args []string) (*app, error) {
+	// Load and validate configuration
+	cfg, err := config.Load(newFlagSet(), args)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to load configuration: %w", err)
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Validate configuration
-	if err := cfg.Validate(); err != nil {
-		return nil, nil, nil, fmt.Errorf("configuration validation error: %w", err)
+	// Configure structured logging with the token removed from all output
+	redactor := secrets.NewRedactor(cfg.TelegramToken, cfg.WebhookSecret)
+	if err := setupLogging(cfg, redactor); err != nil {
+		return nil, fmt.Errorf("failed to configure logging: %w", err)
 	}
 
 	// Create bot API
 	botAPI, err := tgbotapi.NewBotAPI(cfg.TelegramToken)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to create bot: %w", err)
+		return nil, fmt.Errorf("failed to create bot: %w", redactor.Error(err))
 	}
 
 	// Load questions
-	questionsMap, err := config.LoadQuestions(cfg.QuestionsFilePath)
+	questionManager, err := loadQuestionManager(cfg)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to load questions: %w", err)
+		return nil, fmt.Errorf("failed to load questions: %w", err)
 	}
 
 	// Create services
-	userStateManager := services.NewUserStateManager()
-	questionManager := services.NewQuestionManager(questionsMap)
+	userStateManager, stateDir, err := newUserStateManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user states: %w", err)
+	}
+
+	// Metrics are collected only when they can be scraped
+	var m *metrics.Metrics
+	if cfg.HTTPAddr != "" {
+		m = metrics.New()
+	}
 
 	// Create bot
-	telegramBot := bot.NewTelegramBot(botAPI, cfg, userStateManager, questionManager)
+	telegramBot := bot.NewTelegramBot(messenger.NewTelegram(botAPI, m), cfg, userStateManager, questionManager)
+
+	// Inactivity reminders and session timeouts are planned on a single scheduler
+	timeouts := scheduler.New()
+	go timeouts.Run(context.Background())
+	telegramBot.SetScheduler(timeouts)
+	// The scheduler is not persisted, so restored attempts get their timeouts again
+	if resumed := telegramBot.ResumeTimeouts(); resumed > 0 {
+		slog.Info("Timeouts resumed", "count", resumed)
+	}
+
+	// Completed submissions are sent to the webhook from a background worker
+	var webhookSink *webhook.Sink
+	if cfg.WebhookURL != "" {
+		webhookSink = webhook.New(webhook.Options{
+			URL:            cfg.WebhookURL,
+			Secret:         cfg.WebhookSecret,
+			MaxAttempts:    cfg.WebhookMaxAttempts,
+			DeadLetterPath: cfg.WebhookDeadLetter,
+			Answers:        cfg.WebhookAnswers,
+		})
+		go webhookSink.Run(context.Background())
+		telegramBot.AddSink(webhookSink)
+	}
+
+	// Submissions wait in the outbox until the admin chat and the webhook accept them
+	var box *outbox.Outbox
+	if cfg.OutboxDir != "" {
+		if box, err = newOutbox(cfg, telegramBot, webhookSink); err != nil {
+			return nil, fmt.Errorf("failed to open outbox: %w", err)
+		}
+		telegramBot.SetOutbox(box)
+	}
 
 	// Create handler
 	handler := handlers.NewTelegramHandler(telegramBot, cfg, userStateManager, questionManager)
+	if box != nil {
+		handler.SetOutbox(box)
+	}
+
+	a := &app{
+		botAPI:  botAPI,
+		handler: handler,
+		config:  cfg,
+		polling: health.NewHeartbeat(),
+	}
+
+	// Expose metrics and health endpoints when HTTP server is configured
+	if cfg.HTTPAddr != "" {
+		mux := http.NewServeMux()
+
+		m.RegisterActiveUsers(func() int {
+			stats := services.CollectStats(userStateManager.GetAllUserStates(), "")
+			return stats.Started - stats.Completed - stats.Expired - stats.Abandoned
+		})
+		telegramBot.SetMetrics(m)
+		if webhookSink != nil {
+			webhookSink.SetMetrics(m)
+			m.RegisterWebhookQueued(webhookSink.Len)
+		}
+		if box != nil {
+			box.SetMetrics(m)
+			m.RegisterOutboxPending(box.Len)
+		}
+		handler.SetMetrics(m)
+		mux.Handle("/metrics", m.Handler())
+
+		telegram := health.NewHeartbeat()
+		// Request errors hold the API URL with the token and are shown by the readiness endpoint
+		go telegram.Watch(context.Background(), getMeInterval, func() error {
+			_, err := botAPI.GetMe()
+			return redactor.Error(err)
+		})
+
+		checker := health.NewChecker()
+		checker.Add("questions", questionsCheck(questionManager, cfg))
+		checker.Add("telegram", telegram.Check(getMeMaxAge))
+		checker.Add("updates", a.polling.Check(pollingMaxAge))
+		if stateDir != nil {
+			checker.Add("storage", stateDir.Check)
+		}
+		if box != nil {
+			checker.Add("outbox", box.Check)
+		}
+		mux.Handle("/healthz", health.LivenessHandler())
+		mux.Handle("/readyz", checker.ReadinessHandler())
+
+		startHTTPServer(cfg.HTTPAddr, mux)
+	}
+
+	// The worker starts once metrics are set, delivering items left from previous runs first
+	if box != nil {
+		go box.Run(context.Background())
+	}
+
+	return a, nil
+}
+
+// newOutbox opens the outbox and registers the sinks it delivers to.
+// Sink names are stored with pending items, so they must not change between releases.
+func newOutbox(cfg *models.Config, telegramBot *bot.TelegramBot, webhookSink *webhook.Sink) (*outbox.Outbox, error) {
+	box, err := outbox.Open(cfg.OutboxDir)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AdminChatID != 0 {
+		box.Register("admin_chat", bot.NewAdminChatSink(telegramBot, cfg.AdminChatID))
+	}
+	if webhookSink != nil {
+		// The outbox retries on its own, so each attempt is a single request
+		box.Register("webhook", webhookSink.Sender())
+	}
+	slog.Info("Outbox loaded", "pending", box.Len(), "dir", cfg.OutboxDir)
+	return box, nil
+}
+
+// newUserStateManager restores user states from the state directory if configured, otherwise keeps them in memory
+func newUserStateManager(cfg *models.Config) (*services.UserStateManager, *storage.Dir, error) {
+	if cfg.StateDir == "" {
+		return services.NewUserStateManager(), nil, nil
+	}
+
+	dir, err := storage.NewDir(cfg.StateDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	userStateManager, err := services.NewPersistentUserStateManager(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	slog.Info("User states loaded", "count", len(userStateManager.GetAllUserStates()), "dir", cfg.StateDir)
+	return userStateManager, dir, nil
+}
+
+// questionsCheck returns readiness check verifying that every survey has its start question
+func questionsCheck(questionManager models.QuestionService, cfg *models.Config) health.CheckFunc {
+	return func(_ context.Context) error {
+		surveys := questionManager.ListSurveys()
+		if len(surveys) == 0 {
+			return errors.New("no surveys loaded")
+		}
 
-	return botAPI, handler, cfg, nil
+		for _, survey := range surveys {
+			startQuestionID := survey.StartQuestionID
+			if startQuestionID == "" {
+				startQuestionID = cfg.StartQuestionID
+			}
+			if _, err := questionManager.GetSurveyQuestion(survey.ID, startQuestionID); err != nil {
+				return fmt.Errorf("survey %q: start question %q: %w", survey.ID, startQuestionID, err)
+			}
+		}
+		return nil
+	}
+}
+
+// loadQuestionManager loads surveys from directory if configured, otherwise the single questions file
+func loadQuestionManager(cfg *models.Config) (*services.QuestionManager, error) {
+	if cfg.SurveysDir != "" {
+		surveys, err := config.LoadSurveys(cfg.SurveysDir, cfg.StartQuestionID)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Surveys loaded", "count", len(surveys), "dir", cfg.SurveysDir)
+		return services.NewSurveyQuestionManager(surveys)
+	}
+
+	questionsMap, err := config.LoadQuestions(cfg.QuestionsFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return services.NewQuestionManager(questionsMap), nil
+}
+
+// setupLogging installs configured logger as default for slog, log and Telegram API client.
+// Secrets known to the redactor are removed from every record, including Telegram API client logs.
+func setupLogging(cfg *models.Config, redactor *secrets.Redactor) error {
+	logger, err := logging.New(redactor.Writer(os.Stderr), cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	// Telegram API client only logs failures such as polling errors
+	return tgbotapi.SetLogger(slog.NewLogLogger(logger.Handler(), slog.LevelWarn))
+}
+
+// startHTTPServer serves HTTP endpoints in background
+func startHTTPServer(addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		slog.Info("HTTP server listening", "addr", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server stopped", "addr", addr, "error", err)
+		}
+	}()
+}
+
+// subcommands maps CLI subcommand names to their entry points; without a subcommand the bot is started
+var subcommands = map[string]func(args []string) error{
+	"analytics": func(args []string) error { return runAnalytics(args, os.Stdout, os.Stderr) },
+	"convert":   func(args []string) error { return runConvert(args, os.Stdout, os.Stderr) },
+	"export":    func(args []string) error { return runExport(args, os.Stdout, os.Stderr) },
+	"schema":    func(args []string) error { return runSchema(args, os.Stdout, os.Stderr) },
+	"simulate":  func(args []string) error { return runSimulate(args, os.Stdin, os.Stdout, os.Stderr) },
+	"test":      func(args []string) error { return runScenarios(args, os.Stdout, os.Stderr) },
+}
+
+// newFlagSet creates flag set of the bot command with help listing subcommands
+func newFlagSet() *flag.FlagSet {
+	flags := flag.NewFlagSet("telegram-bot", flag.ContinueOnError)
+	flags.Usage = func() {
+		out := flags.Output()
+		_, _ = fmt.Fprintln(out, "Usage: telegram-bot [flags] [config.json]")
+		_, _ = fmt.Fprintln(out, "       telegram-bot <analytics|convert|export|schema|simulate|test> [args]")
+		_, _ = fmt.Fprintln(out)
+		_, _ = fmt.Fprintln(out, "Settings are taken from flags, then environment variables, then the config file, then defaults.")
+		_, _ = fmt.Fprintln(out)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// runSubcommand runs the subcommand and exits, keeping bot logs to warnings and errors
+func runSubcommand(name string, run func(args []string) error, args []string) {
+	logger, err := logging.New(os.Stderr, "warn", logging.FormatText)
+	if err == nil {
+		slog.SetDefault(logger)
+	}
+
+	if err := run(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "telegram-bot %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
 }
 
 func main() {
-	botAPI, handler, _, err := initializeBot()
+	if len(os.Args) > 1 {
+		if run, exists := subcommands[os.Args[1]]; exists {
+			runSubcommand(os.Args[1], run, os.Args[2:])
+		}
+	}
+
+	a, err := initializeBot(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		log.Fatalf("Bot initialization failed: %v", err)
+		slog.Error("Bot initialization failed", "error", err)
+		os.Exit(1)
 	}
 
-	log.Printf("Authorized as %s", botAPI.Self.UserName)
+	slog.Info("Authorized", "bot_username", a.botAPI.Self.UserName)
+
+	// Publish command list to Telegram
+	if err := a.handler.Commands().Sync(a.botAPI, a.config.AdminUserIDs); err != nil {
+		slog.Warn("Failed to sync bot commands", "error", err)
+	}
 
 	// Start processing updates
-	startBot(botAPI, handler)
+	slog.Info("Bot started. Waiting for messages...")
+	pollUpdates(context.Background(), a.botAPI, a.polling, a.handler.HandleUpdate)
 }
 
-// startBot starts processing updates from Telegram
-func startBot(botAPI *tgbotapi.BotAPI, handler *handlers.TelegramHandler) {
-	// Settings for receiving updates
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 60
-
-	updates := botAPI.GetUpdatesChan(updateConfig)
+// updatesGetter fetches updates from Telegram
+type updatesGetter interface {
+	GetUpdates(config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error)
+}
 
-	log.Println("Bot started. Waiting for messages...")
+// pollUpdates long-polls Telegram for updates until ctx is done, handling each update in its own goroutine.
+// The heartbeat beats after every successful poll so a stalled loop shows up in readiness.
+func pollUpdates(ctx context.Context, api updatesGetter, heartbeat *health.Heartbeat, handle func(tgbotapi.Update)) {
+	updateConfig := tgbotapi.NewUpdate(0)
+	updateConfig.Timeout = pollTimeoutSeconds
 
-	for update := range updates {
-		// Process messages
-		if update.Message != nil {
-			go handler.HandleMessage(update.Message)
+	for ctx.Err() == nil {
+		updates, err := api.GetUpdates(updateConfig)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to get updates, retrying", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollRetryDelay):
+			}
+			continue
 		}
+		heartbeat.Beat()
 
-		// Process callback queries
-		if update.CallbackQuery != nil {
-			go handler.HandleCallbackQuery(update.CallbackQuery)
+		for _, update := range updates {
+			if update.UpdateID < updateConfig.Offset {
+				continue
+			}
+			updateConfig.Offset = update.UpdateID + 1
+			go handle(update)
 		}
 	}
 }
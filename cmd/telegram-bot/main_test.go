@@ -2,13 +2,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
+	"tlgbot/internal/health"
 	"tlgbot/internal/models"
+	"tlgbot/internal/services"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -382,3 +388,79 @@ func TestMainErrorHandling(t *testing.T) {
 		}
 	})
 }
+
+// mockUpdatesGetter returns queued batches of updates, cancelling polling when exhausted
+type mockUpdatesGetter struct {
+	batches [][]tgbotapi.Update
+	offsets []int
+	cancel  context.CancelFunc
+}
+
+func (m *mockUpdatesGetter) GetUpdates(config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error) {
+	m.offsets = append(m.offsets, config.Offset)
+	if len(m.batches) == 0 {
+		m.cancel()
+		return nil, nil
+	}
+	batch := m.batches[0]
+	m.batches = m.batches[1:]
+	return batch, nil
+}
+
+func TestPollUpdates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	getter := &mockUpdatesGetter{
+		batches: [][]tgbotapi.Update{
+			{{UpdateID: 5}, {UpdateID: 6}},
+			{{UpdateID: 6}, {UpdateID: 7}},
+		},
+		cancel: cancel,
+	}
+	heartbeat := health.NewHeartbeat()
+
+	var mu sync.Mutex
+	var handled []int
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	pollUpdates(ctx, getter, heartbeat, func(update tgbotapi.Update) {
+		mu.Lock()
+		handled = append(handled, update.UpdateID)
+		mu.Unlock()
+		wg.Done()
+	})
+	wg.Wait()
+
+	sort.Ints(handled)
+	if fmt.Sprint(handled) != "[5 6 7]" {
+		t.Errorf("Expected each update handled once, got %v", handled)
+	}
+	if fmt.Sprint(getter.offsets) != "[0 7 8]" {
+		t.Errorf("Unexpected polling offsets: %v", getter.offsets)
+	}
+	if heartbeat.Last().IsZero() {
+		t.Error("Expected successful polls to beat")
+	}
+}
+
+func TestQuestionsCheck(t *testing.T) {
+	cfg := &models.Config{StartQuestionID: "start"}
+
+	tests := []struct {
+		name      string
+		questions map[string]models.Question
+		wantErr   bool
+	}{
+		{"start question present", map[string]models.Question{"start": {ID: "start"}}, false},
+		{"start question missing", map[string]models.Question{"other": {ID: "other"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := questionsCheck(services.NewQuestionManager(tt.questions), cfg)
+			if err := check(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
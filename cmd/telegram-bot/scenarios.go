@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"tlgbot/internal/scenario"
+)
+
+// runScenarios runs scripted flow tests: test <scenario.yaml>...
+// Patterns are expanded, so quoted globs work the same as ones expanded by the shell.
+func runScenarios(args []string, out, errOut io.Writer) error {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: telegram-bot test <scenario.yaml>...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	paths, err := expandPatterns(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		flags.Usage()
+		return errors.New("no scenario files given")
+	}
+
+	failed := 0
+	for _, path := range paths {
+		result := scenario.RunFile(path)
+		if result.Passed() {
+			_, _ = fmt.Fprintf(out, "PASS  %s (%s)\n", result.Name, path)
+			continue
+		}
+
+		failed++
+		_, _ = fmt.Fprintf(out, "FAIL  %s (%s)\n", result.Name, path)
+		for _, failure := range result.Failures {
+			_, _ = fmt.Fprintf(out, "      %s\n", failure)
+		}
+	}
+
+	_, _ = fmt.Fprintf(out, "\n%d scenarios, %d failed\n", len(paths), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scenarios failed", failed, len(paths))
+	}
+	return nil
+}
+
+// expandPatterns expands glob patterns, keeping arguments without matches so missing files are reported
+func expandPatterns(patterns []string) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			matches = []string{pattern}
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunScenarios(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"questions.json": `[
+			{"id": "start", "text": "Ready?", "options": [{"text": "Go", "next_id": "end"}]},
+			{"id": "end", "text": "Done"}
+		]`,
+		"pass.yaml": "questions: questions.json\nsteps:\n  - send: /start\n  - press: Go\nexpect:\n  question: end\n",
+		"fail.yaml": "name: wrong outcome\nquestions: questions.json\nsteps:\n  - send: /start\nexpect:\n  question: end\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectError   bool
+		expectedParts []string
+	}{
+		{
+			name:          "passing scenario",
+			args:          []string{filepath.Join(dir, "pass.yaml")},
+			expectedParts: []string{"PASS  pass", "1 scenarios, 0 failed"},
+		},
+		{
+			name:        "glob with failing scenario",
+			args:        []string{filepath.Join(dir, "*.yaml")},
+			expectError: true,
+			expectedParts: []string{
+				"FAIL  wrong outcome",
+				`final state: question: expected "end", got "start"`,
+				"PASS  pass",
+				"2 scenarios, 1 failed",
+			},
+		},
+		{
+			name:          "missing file",
+			args:          []string{filepath.Join(dir, "missing.yaml")},
+			expectError:   true,
+			expectedParts: []string{"failed to read scenario"},
+		},
+		{name: "no arguments", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := runScenarios(tt.args, &out, &errOut)
+
+			if tt.expectError && err == nil {
+				t.Error("Expected error, got nil")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			for _, part := range tt.expectedParts {
+				if !strings.Contains(out.String(), part) {
+					t.Errorf("Expected output to contain %q, got:\n%s", part, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"tlgbot/internal/config"
+)
+
+// runSchema prints JSON Schema of question files or the config file: schema [-o file] questions|config
+func runSchema(args []string, out, errOut io.Writer) error {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: telegram-bot schema [-o file] questions|config")
+		flags.PrintDefaults()
+	}
+	output := flags.String("o", "", "write schema to file instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected schema name")
+	}
+
+	schema, err := config.Schema(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = out.Write(schema)
+		return err
+	}
+	if err := os.WriteFile(*output, schema, 0o644); err != nil { //nolint:gosec // G306: Schemas are not secret
+		return fmt.Errorf("failed to write %s: %w", *output, err)
+	}
+	return nil
+}
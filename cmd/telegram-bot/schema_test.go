@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectError    bool
+		expectedOutput string
+	}{
+		{name: "questions", args: []string{"questions"}, expectedOutput: `"title": "Survey questions"`},
+		{name: "config", args: []string{"config"}, expectedOutput: `"telegram_token"`},
+		{name: "to file", args: []string{"-o", filepath.Join(dir, "config.schema.json"), "config"}},
+		{name: "unknown schema", args: []string{"scenario"}, expectError: true},
+		{name: "no arguments", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := runSchema(tt.args, &out, &errOut)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !strings.Contains(out.String(), tt.expectedOutput) {
+				t.Errorf("Expected output containing %q, got:\n%s", tt.expectedOutput, out.String())
+			}
+		})
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, "config.schema.json"))
+	if err != nil {
+		t.Fatalf("Expected schema file: %v", err)
+	}
+	if !strings.Contains(string(written), `"additionalProperties": false`) {
+		t.Errorf("Expected strict config schema, got:\n%s", written)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"tlgbot/internal/config"
+	"tlgbot/internal/simulator"
+)
+
+// runSimulate runs survey from a questions file in the terminal: simulate [flags] <questions.json>
+func runSimulate(args []string, in io.Reader, out, errOut io.Writer) error {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(errOut, "Usage: telegram-bot simulate [flags] <questions.json>")
+		flags.PrintDefaults()
+	}
+
+	opts := simulator.Options{}
+	flags.StringVar(&opts.StartQuestionID, "start", config.DefaultStartQuestionID, "ID of the first question")
+	flags.StringVar(&opts.UserName, "name", "Tester", "name substituted for {name}")
+	flags.BoolVar(&opts.Delays, "delays", false, "pause between messages like the live bot")
+	flags.IntVar(&opts.DelayMs, "delay-ms", config.DefaultDelayMs, "default pause in milliseconds when -delays is set")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("questions file is required")
+	}
+
+	questions, err := config.LoadQuestions(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if _, exists := questions[opts.StartQuestionID]; !exists {
+		return fmt.Errorf("start question %q not found in %s", opts.StartQuestionID, flags.Arg(0))
+	}
+
+	return simulator.New(questions, opts, out).Run(in)
+}
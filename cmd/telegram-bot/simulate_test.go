@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSimulate(t *testing.T) {
+	questionsPath := filepath.Join(t.TempDir(), "questions.json")
+	questions := `[
+		{"id": "start", "text": "Ready?", "options": [{"text": "Go", "next_id": "end"}]},
+		{"id": "end", "text": "Done"}
+	]`
+	if err := os.WriteFile(questionsPath, []byte(questions), 0o600); err != nil {
+		t.Fatalf("Failed to write questions: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectError   bool
+		expectedParts []string
+	}{
+		{
+			name:          "completes survey",
+			args:          []string{questionsPath},
+			expectedParts: []string{"bot: Ready?\n  1) Go\n", "bot: Done", "Recorded answers:\n  Ready?: Go\n"},
+		},
+		{name: "missing file argument", args: nil, expectError: true},
+		{name: "unknown start question", args: []string{"-start", "intro", questionsPath}, expectError: true},
+		{name: "missing questions file", args: []string{filepath.Join(t.TempDir(), "none.json")}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			err := runSimulate(tt.args, strings.NewReader("1\n"), &out, &errOut)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for _, part := range tt.expectedParts {
+				if !strings.Contains(out.String(), part) {
+					t.Errorf("Expected output to contain %q, got:\n%s", part, out.String())
+				}
+			}
+		})
+	}
+}
@@ -5,3 +5,22 @@ go 1.23.0
 require github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
 
 require github.com/joho/godotenv v1.5.1
+
+require github.com/prometheus/client_golang v1.22.0
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/pelletier/go-toml/v2 v2.2.3
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/kylelemons/godebug v1.1.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.62.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	google.golang.org/protobuf v1.36.5 // indirect
+)
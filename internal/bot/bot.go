@@ -2,40 +2,74 @@
 package bot
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"sort"
 	"strings"
 	"time"
 
+	"tlgbot/internal/logging"
+	"tlgbot/internal/metrics"
 	"tlgbot/internal/models"
+	"tlgbot/internal/outbox"
+	"tlgbot/internal/scheduler"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
 )
 
 // TelegramBot represents a Telegram bot
 type TelegramBot struct {
-	api              *tgbotapi.BotAPI
+	messenger        models.Messenger
 	config           *models.Config
 	userStateManager models.UserStateService
 	questionManager  models.QuestionService
+	sinks            []models.ResultSink
+	metrics          *metrics.Metrics
+	scheduler        *scheduler.Scheduler
+	outbox           *outbox.Outbox
 }
 
 // NewTelegramBot creates a new bot instance
 func NewTelegramBot(
-	api *tgbotapi.BotAPI,
+	messenger models.Messenger,
 	config *models.Config,
 	userStateManager models.UserStateService,
 	questionManager models.QuestionService,
 ) *TelegramBot {
-	return &TelegramBot{
-		api:              api,
+	bot := &TelegramBot{
+		messenger:        messenger,
 		config:           config,
 		userStateManager: userStateManager,
 		questionManager:  questionManager,
 	}
+
+	// Report completed surveys to admins
+	if config.AdminChatID != 0 {
+		bot.AddSink(NewAdminChatSink(bot, config.AdminChatID))
+	}
+
+	return bot
+}
+
+// AddSink registers a sink receiving completed survey submissions
+func (bot *TelegramBot) AddSink(sink models.ResultSink) {
+	bot.sinks = append(bot.sinks, sink)
+}
+
+// SetOutbox stores completed submissions in the outbox, which delivers them to the sinks registered on it.
+// Sinks added to the bot then receive submissions only when the outbox fails to store them.
+func (bot *TelegramBot) SetOutbox(box *outbox.Outbox) {
+	bot.outbox = box
+}
+
+// SetMetrics enables recording of bot metrics
+func (bot *TelegramBot) SetMetrics(m *metrics.Metrics) {
+	bot.metrics = m
 }
 
 // SendImages sends images to user
-func (bot *TelegramBot) SendImages(userID int64, images []string, delay int) error {
+func (bot *TelegramBot) SendImages(ctx context.Context, userID int64, images []string, delay int) error {
 	if len(images) == 0 {
 		return nil
 	}
@@ -49,21 +83,12 @@ func (bot *TelegramBot) SendImages(userID int64, images []string, delay int) err
 
 // sendMultipleImages sends multiple images as media group
 func (bot *TelegramBot) sendMultipleImages(userID int64, images []string, delay int) error {
-	mediaPhotos := bot.buildMediaGroup(images)
-	if len(mediaPhotos) == 0 {
+	paths := nonEmptyPaths(images)
+	if len(paths) == 0 {
 		return nil
 	}
 
-	// Convert to []interface{} for compatibility with NewMediaGroup
-	media := make([]interface{}, len(mediaPhotos))
-	for i, photo := range mediaPhotos {
-		media[i] = photo
-	}
-
-	mediaGroup := tgbotapi.NewMediaGroup(userID, media)
-	// Send media group returns []tgbotapi.Message, not tgbotapi.Message
-	_, err := bot.api.SendMediaGroup(mediaGroup)
-	if err != nil {
+	if err := bot.messenger.SendMediaGroup(userID, paths); err != nil {
 		return fmt.Errorf("failed to send media group: %w", err)
 	}
 
@@ -77,9 +102,7 @@ func (bot *TelegramBot) sendSingleImage(userID int64, imagePath string, delay in
 		return nil
 	}
 
-	photoMsg := tgbotapi.NewPhoto(userID, tgbotapi.FilePath(imagePath))
-	_, err := bot.api.Send(photoMsg)
-	if err != nil {
+	if err := bot.messenger.SendPhoto(userID, imagePath); err != nil {
 		return fmt.Errorf("failed to send photo: %w", err)
 	}
 
@@ -87,43 +110,47 @@ func (bot *TelegramBot) sendSingleImage(userID int64, imagePath string, delay in
 	return nil
 }
 
-// buildMediaGroup creates media group from image paths
-func (bot *TelegramBot) buildMediaGroup(images []string) []tgbotapi.InputMediaPhoto {
-	media := make([]tgbotapi.InputMediaPhoto, 0, len(images))
+// nonEmptyPaths returns image paths without empty entries
+func nonEmptyPaths(images []string) []string {
+	paths := make([]string, 0, len(images))
 	for _, imgPath := range images {
 		if imgPath != "" {
-			photo := tgbotapi.NewInputMediaPhoto(tgbotapi.FilePath(imgPath))
-			media = append(media, photo)
+			paths = append(paths, imgPath)
 		}
 	}
-	return media
+	return paths
 }
 
 // SendMessage sends a single message with keyboard
-func (bot *TelegramBot) SendMessage(userID int64, text string, keyboard interface{}) error {
-	msg := tgbotapi.NewMessage(userID, text)
-	if keyboard != nil {
-		msg.ReplyMarkup = keyboard
-	}
-	_, err := bot.api.Send(msg)
+func (bot *TelegramBot) SendMessage(_ context.Context, userID int64, text string, keyboard interface{}) error {
+	_, err := bot.messenger.SendText(userID, text, keyboard)
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 	return nil
 }
 
+// SendDocument sends a file to user
+func (bot *TelegramBot) SendDocument(_ context.Context, userID int64, fileName string, data []byte) error {
+	err := bot.messenger.SendDocument(userID, fileName, data)
+	if err != nil {
+		return fmt.Errorf("failed to send document: %w", err)
+	}
+	return nil
+}
+
 // SendMessages sends multiple messages with keyboard on the last one
-func (bot *TelegramBot) SendMessages(userID int64, messages []string, userName string, keyboard interface{}) error {
+func (bot *TelegramBot) SendMessages(_ context.Context, userID int64, messages []string, userName string, keyboard interface{}) error {
 	for i, msgTmpl := range messages {
 		msgText := bot.replaceNamePlaceholder(msgTmpl, userName)
-		msg := tgbotapi.NewMessage(userID, msgText)
 
 		// Add keyboard to the last message
-		if i == len(messages)-1 && keyboard != nil {
-			msg.ReplyMarkup = keyboard
+		var markup interface{}
+		if i == len(messages)-1 {
+			markup = keyboard
 		}
 
-		_, err := bot.api.Send(msg)
+		_, err := bot.messenger.SendText(userID, msgText, markup)
 		if err != nil {
 			return fmt.Errorf("failed to send message %d: %w", i, err)
 		}
@@ -144,8 +171,11 @@ func (bot *TelegramBot) BuildKeyboard(q *models.Question) *tgbotapi.InlineKeyboa
 
 	rows := make([][]tgbotapi.InlineKeyboardButton, 0)
 
-	// Add option buttons
+	// Add option buttons; options without text only point to the next question
 	for _, opt := range q.Options {
+		if opt.Text == "" {
+			continue
+		}
 		btn := tgbotapi.NewInlineKeyboardButtonData(opt.Text, opt.Text)
 		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
 	}
@@ -156,12 +186,16 @@ func (bot *TelegramBot) BuildKeyboard(q *models.Question) *tgbotapi.InlineKeyboa
 		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
 	}
 
+	if len(rows) == 0 {
+		return nil
+	}
+
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
 	return &keyboard
 }
 
 // ProcessQuestion processes sending a question to user
-func (bot *TelegramBot) ProcessQuestion(userID int64, question *models.Question) error {
+func (bot *TelegramBot) ProcessQuestion(ctx context.Context, userID int64, question *models.Question) error {
 	userState := bot.userStateManager.GetUserState(userID)
 	if userState == nil {
 		return fmt.Errorf("user state not found for user %d", userID)
@@ -170,37 +204,75 @@ func (bot *TelegramBot) ProcessQuestion(userID int64, question *models.Question)
 	// Send images
 	if len(question.Images) > 0 {
 		delay := question.GetDelayMs(bot.config.DelayMs)
-		if err := bot.SendImages(userID, question.Images, delay); err != nil {
+		if err := bot.SendImages(ctx, userID, question.Images, delay); err != nil {
 			return fmt.Errorf("failed to send images: %w", err)
 		}
 	}
 
-	// Build keyboard
-	keyboard := bot.BuildKeyboard(question)
+	// Build keyboard, keeping interface nil when there is none
+	var keyboard interface{}
+	if markup := bot.BuildKeyboard(question); markup != nil {
+		keyboard = markup
+	}
+
+	// Answers summary is appended to the last message
+	summary := ""
+	if question.ShouldShowSummary() {
+		summary = bot.generateAnswersSummary(userState.Answers) + generateScoreSummary(userState)
+	}
 
 	// Send messages
 	if len(question.Messages) > 0 {
-		if err := bot.SendMessages(userID, question.Messages, userState.Name, keyboard); err != nil {
-			return fmt.Errorf("failed to send messages: %w", err)
+		messages := make([]string, len(question.Messages))
+		for i, message := range question.Messages {
+			messages[i] = bot.renderTemplate(message, userState)
 		}
-	} else if question.Text != "" {
-		text := bot.replaceNamePlaceholder(question.Text, userState.Name)
+		messages[len(messages)-1] += summary
 
-		// For final question add answers summary
-		if question.ID == "end" {
-			text += bot.generateAnswersSummary(userState.Answers)
+		if err := bot.SendMessages(ctx, userID, messages, userState.Name, keyboard); err != nil {
+			return fmt.Errorf("failed to send messages: %w", err)
 		}
+	} else if question.Text != "" {
+		text := bot.renderTemplate(question.Text, userState) + summary
 
-		if err := bot.SendMessage(userID, text, keyboard); err != nil {
+		if err := bot.SendMessage(ctx, userID, text, keyboard); err != nil {
 			return fmt.Errorf("failed to send text message: %w", err)
 		}
 	}
 
+	bot.scheduleTimeouts(userID, userState, question)
+	if question.IsTerminal() {
+		bot.completeSurvey(ctx, userID, userState, question)
+	}
+
 	return nil
 }
 
+// completeSurvey marks survey as completed and delivers submission to sinks
+func (bot *TelegramBot) completeSurvey(ctx context.Context, userID int64, userState *models.UserState, question *models.Question) {
+	submission := userState.Complete(userID, question.ID, time.Now())
+	bot.metrics.SurveyCompleted(submission.SurveyID, submission.TerminalQuestionID)
+
+	logCtx := userContext(ctx, userID, userState)
+	slog.InfoContext(logCtx, "Survey completed")
+
+	if bot.outbox != nil {
+		err := bot.outbox.Deliver(submission)
+		if err == nil {
+			return
+		}
+		slog.ErrorContext(logCtx, "Failed to store submission in outbox, delivering directly", "error", err)
+	}
+
+	for _, sink := range bot.sinks {
+		if err := sink.Deliver(submission); err != nil {
+			slog.ErrorContext(logCtx, "Failed to deliver submission", "sink", fmt.Sprintf("%T", sink), "error", err)
+		}
+	}
+}
+
 // HandleAutoAdvance handles automatic transition to next question
-func (bot *TelegramBot) HandleAutoAdvance(userID int64, question *models.Question) error {
+func (bot *TelegramBot) HandleAutoAdvance(ctx context.Context, userID int64, question *models.Question) error {
 	if !question.AutoAdvance {
 		return nil
 	}
@@ -213,51 +285,52 @@ func (bot *TelegramBot) HandleAutoAdvance(userID int64, question *models.Questio
 	time.Sleep(time.Duration(delayMs) * time.Millisecond)
 
 	if len(question.Options) > 0 {
-		nextQuestionID := question.Options[0].NextID
-		nextQuestion, err := bot.questionManager.GetQuestion(nextQuestionID)
-		if err != nil {
-			return fmt.Errorf("failed to get next question: %w", err)
-		}
-
-		bot.userStateManager.UpdateCurrentQuestion(userID, nextQuestionID)
-
-		if err := bot.ProcessQuestion(userID, nextQuestion); err != nil {
-			return fmt.Errorf("failed to process next question: %w", err)
-		}
-
-		return bot.HandleAutoAdvance(userID, nextQuestion)
+		return bot.moveToNextQuestion(ctx, userID, question.Options[0].NextID)
 	}
 
 	return nil
 }
 
 // ProcessAnswer processes user's answer
-func (bot *TelegramBot) ProcessAnswer(userID int64, answer string) error {
+func (bot *TelegramBot) ProcessAnswer(ctx context.Context, userID int64, answer string) error {
+	return bot.processAnswer(ctx, userID, answer, nil)
+}
+
+// processAnswer saves and scores the answer; option is the chosen option or nil for text answers
+func (bot *TelegramBot) processAnswer(ctx context.Context, userID int64, answer string, option *models.Option) error {
 	userState := bot.userStateManager.GetUserState(userID)
 	if userState == nil {
 		return fmt.Errorf("user state not found")
 	}
 
-	currentQuestion, err := bot.questionManager.GetQuestion(userState.CurrentQuestionID)
+	currentQuestion, err := bot.questionManager.GetSurveyQuestion(userState.SurveyID, userState.CurrentQuestionID)
 	if err != nil {
 		return fmt.Errorf("failed to get current question: %w", err)
 	}
 
 	// Save user's answer
 	questionText := currentQuestion.GetDisplayText()
-	userState.AddAnswer(questionText, answer)
+	userState.RecordAnswer(currentQuestion.ID, questionText, answer)
+
+	// Forward answer to admins if requested
+	bot.notifyAnswer(ctx, userID, userState, currentQuestion, answer)
+	bot.deliverAnswer(ctx, userID, userState, currentQuestion, answer)
+
+	if err := bot.scoreAnswer(ctx, userID, userState, currentQuestion, answer, option); err != nil {
+		return fmt.Errorf("failed to send answer feedback: %w", err)
+	}
 
 	return nil
 }
 
 // ProcessOptionAnswer handles user option selection
-func (bot *TelegramBot) ProcessOptionAnswer(userID int64, optionText string) error {
+func (bot *TelegramBot) ProcessOptionAnswer(ctx context.Context, userID int64, optionText string) error {
 	userState := bot.userStateManager.GetUserState(userID)
 	if userState == nil {
 		return fmt.Errorf("user state not found")
 	}
 
-	currentQuestion, err := bot.questionManager.GetQuestion(userState.CurrentQuestionID)
+	currentQuestion, err := bot.questionManager.GetSurveyQuestion(userState.SurveyID, userState.CurrentQuestionID)
 	if err != nil {
 		return fmt.Errorf("failed to get current question: %w", err)
 	}
@@ -275,8 +348,8 @@ func (bot *TelegramBot) ProcessOptionAnswer(userID int64, optionText string) err
 		return fmt.Errorf("option not found: %s", optionText)
 	}
 
-	// Save answer
-	if err := bot.ProcessAnswer(userID, optionText); err != nil {
+	// Save and score answer
+	if err := bot.processAnswer(ctx, userID, optionText, selectedOption); err != nil {
 		return fmt.Errorf("failed to process answer: %w", err)
 	}
 
@@ -286,34 +359,41 @@ func (bot *TelegramBot) ProcessOptionAnswer(userID int64, optionText string) err
 	}
 
 	// Move to next question
-	return bot.moveToNextQuestion(userID, selectedOption.NextID)
+	return bot.moveToNextQuestion(ctx, userID, selectedOption.NextID)
 }
 
 // moveToNextQuestion moves to next question
-func (bot *TelegramBot) moveToNextQuestion(userID int64, nextQuestionID string) error {
-	nextQuestion, err := bot.questionManager.GetQuestion(nextQuestionID)
+func (bot *TelegramBot) moveToNextQuestion(ctx context.Context, userID int64, nextQuestionID string) error {
+	userState := bot.userStateManager.GetUserState(userID)
+	if userState == nil {
+		return fmt.Errorf("user state not found")
+	}
+
+	nextQuestion, err := bot.questionManager.GetSurveyQuestion(userState.SurveyID, nextQuestionID)
 	if err != nil {
 		return fmt.Errorf("failed to get next question: %w", err)
 	}
+	if nextQuestion, err = FollowScoreBranches(bot.questionManager, userState.SurveyID, nextQuestion, userState.Score()); err != nil {
+		return err
+	}
 
-	bot.userStateManager.UpdateCurrentQuestion(userID, nextQuestionID)
+	bot.userStateManager.UpdateCurrentQuestion(userID, nextQuestion.ID)
+	bot.metrics.QuestionReached(userState.SurveyID, nextQuestion.ID)
 
-	if err := bot.ProcessQuestion(userID, nextQuestion); err != nil {
+	if err := bot.ProcessQuestion(ctx, userID, nextQuestion); err != nil {
 		return fmt.Errorf("failed to process next question: %w", err)
 	}
 
-	return bot.HandleAutoAdvance(userID, nextQuestion)
+	return bot.HandleAutoAdvance(ctx, userID, nextQuestion)
 }
 
 // requestLocation requests user's location
 func (bot *TelegramBot) requestLocation(userID int64) error {
-	msg := tgbotapi.NewMessage(userID, "Please share your location by clicking the button below:")
 	locationBtn := tgbotapi.NewKeyboardButtonLocation("📍 Share Location")
 	keyboard := tgbotapi.NewReplyKeyboard([]tgbotapi.KeyboardButton{locationBtn})
 	keyboard.OneTimeKeyboard = true
-	msg.ReplyMarkup = keyboard
 
-	_, err := bot.api.Send(msg)
+	_, err := bot.messenger.SendText(userID, "Please share your location by clicking the button below:", keyboard)
 	if err != nil {
 		return fmt.Errorf("failed to send location request: %w", err)
 	}
@@ -331,16 +411,34 @@ func (bot *TelegramBot) generateAnswersSummary(answers map[string]string) string
 		return ""
 	}
 
+	// Sort questions to keep summary stable
+	questions := make([]string, 0, len(answers))
+	for question := range answers {
+		questions = append(questions, question)
+	}
+	sort.Strings(questions)
+
 	summary := "\n\n📋 Your answers:\n"
-	for question, answer := range answers {
-		summary += fmt.Sprintf("• %s: %s\n", question, answer)
+	for _, question := range questions {
+		summary += fmt.Sprintf("• %s: %s\n", question, answers[question])
 	}
 	return summary
 }
 
-// GetAPI returns the Telegram bot API instance
-func (bot *TelegramBot) GetAPI() *tgbotapi.BotAPI {
-	return bot.api
+// userContext returns ctx annotated with user, chat, survey and current question IDs.
+// It is called for every record, so the question ID stays current as the user moves on.
+// Users chat with the bot privately, so chat ID equals user ID.
+func userContext(ctx context.Context, userID int64, userState *models.UserState) context.Context {
+	ctx = logging.With(ctx, logging.KeyUserID, userID, logging.KeyChatID, userID)
+	return logging.WithUserState(ctx, userState)
+}
+
+// AnswerCallback acknowledges a callback query
+func (bot *TelegramBot) AnswerCallback(_ context.Context, callbackID string) error {
+	if err := bot.messenger.AnswerCallback(callbackID, ""); err != nil {
+		return fmt.Errorf("failed to answer callback: %w", err)
+	}
+	return nil
 }
 
 // GetTelegramName gets user name from User object
@@ -1,47 +1,31 @@
 package bot
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
 	"testing"
+	"tlgbot/internal/logging"
+	"tlgbot/internal/messenger"
 	"tlgbot/internal/models"
+	"tlgbot/internal/outbox"
 	"tlgbot/internal/services"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// mockBotAPI is a mock implementation of the Telegram Bot API
-type mockBotAPI struct {
-	sentMessages []tgbotapi.Chattable
-	sendError    error
-}
-
-func (m *mockBotAPI) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
-	if m.sendError != nil {
-		return tgbotapi.Message{}, m.sendError
-	}
-	m.sentMessages = append(m.sentMessages, c)
-	return tgbotapi.Message{}, nil
-}
-
-func (m *mockBotAPI) SendMediaGroup(config tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error) {
-	if m.sendError != nil {
-		return nil, m.sendError
-	}
-	m.sentMessages = append(m.sentMessages, config)
-	return []tgbotapi.Message{}, nil
-}
-
-func (m *mockBotAPI) Request(_ tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
-	return &tgbotapi.APIResponse{}, nil
-}
-
-func createTestBot(_ *testing.T) (*TelegramBot, *mockBotAPI, *services.UserStateManager, *services.QuestionManager) {
-	// Create mock API
-	mockAPI := &mockBotAPI{}
+func createTestBot(_ *testing.T) (*TelegramBot, *messenger.Recorder, *services.UserStateManager, *services.QuestionManager) {
+	// Create recording messenger
+	recorder := messenger.NewRecorder()
 
 	// Create test config
 	config := &models.Config{
 		TelegramToken:     "test_token",
-		DelayMs:           100,
+		DelayMs:           0,
 		StartQuestionID:   "start",
 		QuestionsFilePath: "test.json",
 	}
@@ -103,30 +87,24 @@ func createTestBot(_ *testing.T) (*TelegramBot, *mockBotAPI, *services.UserState
 
 	questionManager := services.NewQuestionManager(questions)
 
-	// Create bot with mock API (we can't use real tgbotapi.BotAPI easily)
-	bot := &TelegramBot{
-		api:              nil, // We'll mock API calls directly
-		config:           config,
-		userStateManager: userStateManager,
-		questionManager:  questionManager,
-	}
+	bot := NewTelegramBot(recorder, config, userStateManager, questionManager)
 
-	return bot, mockAPI, userStateManager, questionManager
+	return bot, recorder, userStateManager, questionManager
 }
 
 func TestNewTelegramBot(t *testing.T) {
-	api := &tgbotapi.BotAPI{}
+	recorder := messenger.NewRecorder()
 	config := &models.Config{}
 	userStateManager := services.NewUserStateManager()
 	questionManager := services.NewQuestionManager(map[string]models.Question{})
 
-	bot := NewTelegramBot(api, config, userStateManager, questionManager)
+	bot := NewTelegramBot(recorder, config, userStateManager, questionManager)
 
 	if bot == nil {
 		t.Fatal("Expected bot to be created")
 	}
-	if bot.api != api {
-		t.Error("Expected API to be set correctly")
+	if bot.messenger != recorder {
+		t.Error("Expected messenger to be set correctly")
 	}
 	if bot.config != config {
 		t.Error("Expected config to be set correctly")
@@ -191,6 +169,17 @@ func TestBuildKeyboard(t *testing.T) {
 			},
 			wantRows: 0,
 		},
+		{
+			name: "text input with next question pointer only",
+			question: models.Question{
+				ID:        "test",
+				InputType: "text",
+				Options: []models.Option{
+					{NextID: "next1"},
+				},
+			},
+			wantRows: 0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -308,9 +297,7 @@ func TestGenerateAnswersSummary(t *testing.T) {
 	}
 }
 
-func TestBuildMediaGroup(t *testing.T) {
-	bot, _, _, _ := createTestBot(t)
-
+func TestNonEmptyPaths(t *testing.T) {
 	tests := []struct {
 		name     string
 		images   []string
@@ -340,9 +327,9 @@ func TestBuildMediaGroup(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := bot.buildMediaGroup(tt.images)
+			result := nonEmptyPaths(tt.images)
 			if len(result) != tt.expected {
-				t.Errorf("Expected %d media items, got %d", tt.expected, len(result))
+				t.Errorf("Expected %d paths, got %d", tt.expected, len(result))
 			}
 		})
 	}
@@ -438,3 +425,202 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// mockResultSink records delivered submissions
+type mockResultSink struct {
+	submissions []*models.Submission
+}
+
+func (m *mockResultSink) Deliver(submission *models.Submission) error {
+	m.submissions = append(m.submissions, submission)
+	return nil
+}
+
+func TestCompleteSurvey(t *testing.T) {
+	bot, _, userStateManager, _ := createTestBot(t)
+	sink := &mockResultSink{}
+	bot.AddSink(sink)
+
+	userState := userStateManager.GetOrCreateUserState(123, "John")
+	userState.AddAnswer("Choose an option:", "Option 1")
+
+	bot.completeSurvey(context.Background(), 123, userState, &models.Question{ID: "disqualified", Terminal: true})
+
+	if !userState.IsCompleted() || userState.Outcome != "disqualified" {
+		t.Errorf("Expected completed state with outcome, got %+v", userState)
+	}
+	if len(sink.submissions) != 1 {
+		t.Fatalf("Expected 1 submission, got %d", len(sink.submissions))
+	}
+	submission := sink.submissions[0]
+	if submission.UserID != 123 || submission.TerminalQuestionID != "disqualified" {
+		t.Errorf("Unexpected submission: %+v", submission)
+	}
+	if submission.Answers["Choose an option:"] != "Option 1" {
+		t.Errorf("Expected answers in submission, got %v", submission.Answers)
+	}
+}
+
+func TestCompleteSurveyStoresSubmissionInOutbox(t *testing.T) {
+	bot, _, userStateManager, _ := createTestBot(t)
+	direct := &mockResultSink{}
+	bot.AddSink(direct)
+
+	box, err := outbox.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	queued := &mockResultSink{}
+	box.Register("mock", queued)
+	bot.SetOutbox(box)
+
+	userState := userStateManager.GetOrCreateUserState(123, "John")
+	bot.completeSurvey(context.Background(), 123, userState, &models.Question{ID: "end", Terminal: true})
+
+	if len(direct.submissions) != 0 || len(queued.submissions) != 0 {
+		t.Errorf("Expected no delivery before the outbox runs, got %d direct and %d queued",
+			len(direct.submissions), len(queued.submissions))
+	}
+	pending := box.Pending()
+	if len(pending) != 1 || pending[0].Submission.ID != userState.Submissions[0].ID {
+		t.Fatalf("Expected the submission in the outbox, got %+v", pending)
+	}
+
+	box.RunDue(context.Background())
+	if len(queued.submissions) != 1 || box.Len() != 0 {
+		t.Errorf("Expected the outbox to deliver the submission, got %d delivered and %d pending",
+			len(queued.submissions), box.Len())
+	}
+}
+
+func TestProcessQuestionSendsMessages(t *testing.T) {
+	tests := []struct {
+		name          string
+		questionID    string
+		expectedCalls []string
+		expectedText  string
+		expectMarkup  bool
+	}{
+		{
+			name:          "text with keyboard",
+			questionID:    "start",
+			expectedCalls: []string{messenger.MethodSendMessage},
+			expectedText:  "Welcome John! Choose an option:",
+			expectMarkup:  true,
+		},
+		{
+			name:          "album before text",
+			questionID:    "with_images",
+			expectedCalls: []string{messenger.MethodSendMediaGroup, messenger.MethodSendMessage},
+			expectedText:  "Question with images",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, recorder, userStateManager, questionManager := createTestBot(t)
+			userStateManager.GetOrCreateUserState(123, "John")
+			question, err := questionManager.GetQuestion(tt.questionID)
+			if err != nil {
+				t.Fatalf("Failed to get question: %v", err)
+			}
+
+			if err := bot.ProcessQuestion(context.Background(), 123, question); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			calls := recorder.Calls()
+			if len(calls) != len(tt.expectedCalls) {
+				t.Fatalf("Expected %d calls, got %+v", len(tt.expectedCalls), calls)
+			}
+			for i, method := range tt.expectedCalls {
+				if calls[i].Method != method || calls[i].ChatID != 123 {
+					t.Errorf("Call %d: expected %s to chat 123, got %+v", i, method, calls[i])
+				}
+			}
+
+			last := calls[len(calls)-1]
+			if last.Text != tt.expectedText {
+				t.Errorf("Expected text %q, got %q", tt.expectedText, last.Text)
+			}
+			if (last.Markup != nil) != tt.expectMarkup {
+				t.Errorf("Expected markup %v, got %#v", tt.expectMarkup, last.Markup)
+			}
+		})
+	}
+}
+
+func TestProcessOptionAnswerAdvances(t *testing.T) {
+	bot, recorder, userStateManager, _ := createTestBot(t)
+	userState := userStateManager.GetOrCreateUserState(123, "John")
+	userState.CurrentQuestionID = "start"
+
+	if err := bot.ProcessOptionAnswer(context.Background(), 123, "Option 1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if userState.CurrentQuestionID != "question1" {
+		t.Errorf("Expected current question question1, got %s", userState.CurrentQuestionID)
+	}
+	if answer, _ := userState.GetAnswer("Welcome {name}! Choose an option:"); answer != "Option 1" {
+		t.Errorf("Expected answer to be saved, got %q", answer)
+	}
+	if values := userState.Responses["start"]; !reflect.DeepEqual(values, []string{"Option 1"}) {
+		t.Errorf("Expected answer to be saved by question ID, got %q", values)
+	}
+	if texts := recorder.Texts(123); len(texts) != 1 || texts[0] != "You chose option 1" {
+		t.Errorf("Expected next question to be sent, got %v", texts)
+	}
+}
+
+func TestSendFailures(t *testing.T) {
+	bot, recorder, _, _ := createTestBot(t)
+	recorder.FailWith(messenger.MethodSendMessage, errors.New("forbidden"))
+	recorder.FailWith(messenger.MethodAnswerCallbackQuery, errors.New("query is too old"))
+
+	if err := bot.SendMessage(context.Background(), 123, "Hello", nil); err == nil {
+		t.Error("Expected send error")
+	}
+	if err := bot.AnswerCallback(context.Background(), "cb-1"); err == nil {
+		t.Error("Expected callback error")
+	}
+	if len(recorder.Calls()) != 0 {
+		t.Errorf("Expected failed calls not to be recorded, got %+v", recorder.Calls())
+	}
+}
+
+func TestLogsCarryUpdateAndCurrentQuestion(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", logging.FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	bot, _, userStateManager, _ := createTestBot(t)
+	userState := userStateManager.GetOrCreateUserState(123, "John")
+	userState.CurrentQuestionID = "input_question"
+
+	// The handler annotates the update with the question answered, the bot moves past it
+	ctx := logging.With(context.Background(), logging.KeyUpdateID, 42)
+	ctx = logging.WithUserState(ctx, userState)
+	if err := bot.moveToNextQuestion(ctx, 123, "end"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var record map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if err := json.Unmarshal([]byte(line), &record); err == nil && record["msg"] == "Survey completed" {
+			break
+		}
+		record = nil
+	}
+	if record == nil {
+		t.Fatalf("Expected completion to be logged, got:\n%s", buf.String())
+	}
+	if record[logging.KeyUpdateID] != float64(42) || record[logging.KeyUserID] != float64(123) || record[logging.KeyQuestionID] != "end" {
+		t.Errorf("Expected update 42, user 123 and question end, got %v", record)
+	}
+}
@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"tlgbot/internal/models"
+)
+
+// reportTimeLayout is the timestamp format used in admin reports
+const reportTimeLayout = "2006-01-02 15:04:05 MST"
+
+// messageSender sends plain text messages
+type messageSender interface {
+	SendMessage(ctx context.Context, userID int64, text string, keyboard interface{}) error
+}
+
+// AdminChatSink sends completed survey reports to the admin chat
+type AdminChatSink struct {
+	sender messageSender
+	chatID int64
+}
+
+// NewAdminChatSink creates a sink reporting submissions to the given chat
+func NewAdminChatSink(sender messageSender, chatID int64) *AdminChatSink {
+	return &AdminChatSink{
+		sender: sender,
+		chatID: chatID,
+	}
+}
+
+// Deliver sends submission report to the admin chat
+func (s *AdminChatSink) Deliver(submission *models.Submission) error {
+	if err := s.sender.SendMessage(context.Background(), s.chatID, formatSubmissionReport(submission), nil); err != nil {
+		return fmt.Errorf("failed to send completion report for user %d: %w", submission.UserID, err)
+	}
+	return nil
+}
+
+// notifyAnswer forwards a single answer to the admin chat
+func (bot *TelegramBot) notifyAnswer(ctx context.Context, userID int64, userState *models.UserState, question *models.Question, answer string) {
+	if bot.config.AdminChatID == 0 || !question.Notify {
+		return
+	}
+
+	report := formatAnswerReport(userID, userState, question, answer)
+	if err := bot.SendMessage(ctx, bot.config.AdminChatID, report, nil); err != nil {
+		slog.ErrorContext(userContext(ctx, userID, userState), "Failed to forward answer to admin chat", "error", err)
+	}
+}
+
+// deliverAnswer passes the answer to sinks receiving single answers
+func (bot *TelegramBot) deliverAnswer(ctx context.Context, userID int64, userState *models.UserState, question *models.Question, answer string) {
+	var event *models.Answer
+	for _, sink := range bot.sinks {
+		answerSink, ok := sink.(models.AnswerSink)
+		if !ok {
+			continue
+		}
+		if event == nil {
+			event = &models.Answer{
+				SurveyID:   userState.SurveyID,
+				UserID:     userID,
+				Name:       userState.Name,
+				UserName:   userState.UserName,
+				QuestionID: question.ID,
+				Question:   question.GetDisplayText(),
+				Answer:     answer,
+				AnsweredAt: userState.UpdatedAt,
+			}
+		}
+		if err := answerSink.DeliverAnswer(event); err != nil {
+			slog.ErrorContext(userContext(ctx, userID, userState), "Failed to deliver answer", "sink", fmt.Sprintf("%T", sink), "error", err)
+		}
+	}
+}
+
+// formatSubmissionReport builds admin report for a completed survey
+func formatSubmissionReport(submission *models.Submission) string {
+	var sb strings.Builder
+
+	sb.WriteString("✅ Survey completed\n\n")
+	sb.WriteString(formatUserLine(submission.UserID, submission.Name, submission.UserName))
+	fmt.Fprintf(&sb, "Outcome: %s\n", submission.TerminalQuestionID)
+	if submission.Score != nil {
+		fmt.Fprintf(&sb, "Score: %d\n", *submission.Score)
+	}
+	if !submission.StartedAt.IsZero() {
+		fmt.Fprintf(&sb, "Started: %s\n", submission.StartedAt.Format(reportTimeLayout))
+	}
+	fmt.Fprintf(&sb, "Completed: %s\n", submission.CompletedAt.Format(reportTimeLayout))
+
+	if len(submission.Answers) == 0 {
+		sb.WriteString("\nNo answers recorded.")
+		return sb.String()
+	}
+
+	// Sort questions to keep reports stable
+	questions := make([]string, 0, len(submission.Answers))
+	for question := range submission.Answers {
+		questions = append(questions, question)
+	}
+	sort.Strings(questions)
+
+	sb.WriteString("\n📋 Answers:\n")
+	for _, question := range questions {
+		fmt.Fprintf(&sb, "• %s: %s\n", question, submission.Answers[question])
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatAnswerReport builds admin report for a single answer
+func formatAnswerReport(userID int64, userState *models.UserState, question *models.Question, answer string) string {
+	var sb strings.Builder
+
+	sb.WriteString("📨 New answer\n\n")
+	sb.WriteString(formatUserLine(userID, userState.Name, userState.UserName))
+	fmt.Fprintf(&sb, "Question: %s\n", question.GetDisplayText())
+	fmt.Fprintf(&sb, "Answer: %s", answer)
+
+	return sb.String()
+}
+
+// formatUserLine formats user name, handle and ID for reports
+func formatUserLine(userID int64, name, userName string) string {
+	user := name
+	if userName != "" {
+		user += " (@" + userName + ")"
+	}
+	return fmt.Sprintf("User: %s\nID: %d\n", user, userID)
+}
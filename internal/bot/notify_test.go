@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+// mockMessageSender records sent messages
+type mockMessageSender struct {
+	chatID int64
+	text   string
+	err    error
+}
+
+func (m *mockMessageSender) SendMessage(_ context.Context, userID int64, text string, _ interface{}) error {
+	m.chatID = userID
+	m.text = text
+	return m.err
+}
+
+func TestFormatSubmissionReport(t *testing.T) {
+	completedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		submission *models.Submission
+		contains   []string
+		excludes   []string
+	}{
+		{
+			name: "user with handle and answers",
+			submission: &models.Submission{
+				UserID:             123,
+				Name:               "John",
+				UserName:           "johndoe",
+				TerminalQuestionID: "qualified",
+				StartedAt:          completedAt.Add(-5 * time.Minute),
+				CompletedAt:        completedAt,
+				Answers: map[string]string{
+					"How are you?":  "Good",
+					"Any feedback?": "Great demo!",
+				},
+			},
+			contains: []string{
+				"User: John (@johndoe)",
+				"ID: 123",
+				"Outcome: qualified",
+				"Started: 2024-05-01 12:25:00 UTC",
+				"Completed: 2024-05-01 12:30:00 UTC",
+				"• Any feedback?: Great demo!\n• How are you?: Good",
+			},
+		},
+		{
+			name: "user without handle and answers",
+			submission: &models.Submission{
+				UserID:      123,
+				Name:        "John",
+				CompletedAt: completedAt,
+				Answers:     map[string]string{},
+			},
+			contains: []string{"User: John\n", "No answers recorded."},
+			excludes: []string{"@", "Started:", "Score:"},
+		},
+		{
+			name: "scored attempt",
+			submission: &models.Submission{
+				UserID:             123,
+				Name:               "John",
+				TerminalQuestionID: "excellent",
+				CompletedAt:        completedAt,
+				Score:              intPtr(7),
+			},
+			contains: []string{"Outcome: excellent\nScore: 7\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := formatSubmissionReport(tt.submission)
+			for _, want := range tt.contains {
+				if !strings.Contains(report, want) {
+					t.Errorf("Expected report to contain %q, got %q", want, report)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(report, unwanted) {
+					t.Errorf("Expected report not to contain %q, got %q", unwanted, report)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminChatSinkDeliver(t *testing.T) {
+	sender := &mockMessageSender{}
+	sink := NewAdminChatSink(sender, -100123)
+	submission := &models.Submission{UserID: 123, Name: "John", TerminalQuestionID: "end"}
+
+	if err := sink.Deliver(submission); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sender.chatID != -100123 {
+		t.Errorf("Expected report to admin chat, got %d", sender.chatID)
+	}
+	if !strings.HasPrefix(sender.text, "✅ Survey completed") {
+		t.Errorf("Unexpected report: %q", sender.text)
+	}
+
+	sender.err = errors.New("chat not found")
+	if err := sink.Deliver(submission); err == nil {
+		t.Error("Expected error when sending fails")
+	}
+}
+
+func TestFormatAnswerReport(t *testing.T) {
+	state := &models.UserState{Name: "John", UserName: "johndoe"}
+	question := &models.Question{ID: "phone", Text: "Your phone number?", Notify: true}
+
+	report := formatAnswerReport(123, state, question, "+123456")
+
+	expected := "📨 New answer\n\nUser: John (@johndoe)\nID: 123\nQuestion: Your phone number?\nAnswer: +123456"
+	if report != expected {
+		t.Errorf("Expected %q, got %q", expected, report)
+	}
+}
+
+func TestNotifyAnswerWithoutAdminChat(t *testing.T) {
+	bot, _, _, _ := createTestBot(t)
+	state := models.NewUserState("John")
+	question := &models.Question{ID: "phone", Text: "Your phone number?", Notify: true}
+
+	// Bot API is nil in tests, so any send attempt would panic
+	bot.notifyAnswer(context.Background(), 123, state, question, "+123456")
+}
+
+// mockAnswerSink records submissions and single answers
+type mockAnswerSink struct {
+	mockResultSink
+	answers []*models.Answer
+}
+
+func (m *mockAnswerSink) DeliverAnswer(answer *models.Answer) error {
+	m.answers = append(m.answers, answer)
+	return nil
+}
+
+func TestProcessAnswerDeliversToAnswerSinks(t *testing.T) {
+	bot, _, userStateManager, _ := createTestBot(t)
+	answerSink := &mockAnswerSink{}
+	bot.AddSink(&mockResultSink{})
+	bot.AddSink(answerSink)
+
+	userState := userStateManager.GetOrCreateUserState(123, "John")
+	userState.UserName = "johndoe"
+	userState.CurrentQuestionID = "start"
+	if err := bot.ProcessAnswer(context.Background(), 123, "Option 1"); err != nil {
+		t.Fatalf("ProcessAnswer failed: %v", err)
+	}
+
+	if len(answerSink.answers) != 1 {
+		t.Fatalf("Expected 1 answer delivered, got %d", len(answerSink.answers))
+	}
+	answer := answerSink.answers[0]
+	if answer.UserID != 123 || answer.UserName != "johndoe" || answer.QuestionID != "start" || answer.Answer != "Option 1" || answer.AnsweredAt.IsZero() {
+		t.Errorf("Unexpected answer: %+v", answer)
+	}
+}
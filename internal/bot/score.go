@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tlgbot/internal/models"
+)
+
+// Default feedback on answers to graded questions; {answer} is the correct answer
+const (
+	DefaultCorrectFeedback = "✅ Correct!"
+	DefaultWrongFeedback   = "❌ Wrong, the answer was: {answer}"
+)
+
+// maxScoreRedirects limits chained score branches so a cycle between questions ends with an error
+const maxScoreRedirects = 10
+
+// FollowScoreBranches returns the question a user with the score ends up at.
+// A question with score branches sends the user on to the first matching branch; without a match it is shown itself.
+func FollowScoreBranches(questions models.QuestionService, surveyID string, question *models.Question, score int) (*models.Question, error) {
+	for redirects := 0; ; redirects++ {
+		nextID, ok := question.ScoreBranch(score)
+		if !ok {
+			return question, nil
+		}
+		if redirects == maxScoreRedirects {
+			return nil, fmt.Errorf("too many score branch redirects from question %s", question.ID)
+		}
+
+		next, err := questions.GetSurveyQuestion(surveyID, nextID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get score branch question: %w", err)
+		}
+		question = next
+	}
+}
+
+// scoreAnswer records points of the answer and sends feedback for graded questions.
+// Option is nil for text answers.
+func (bot *TelegramBot) scoreAnswer(ctx context.Context, userID int64, userState *models.UserState, question *models.Question, answer string, option *models.Option) error {
+	score := models.QuestionScore{Graded: question.IsGraded()}
+	if option != nil {
+		score.Points += option.Points
+	}
+	if question.IsCorrect(answer) {
+		score.Correct = true
+		score.Points += question.GetPoints()
+	}
+
+	if !score.Graded && score.Points == 0 {
+		// Drop points of an earlier answer to the question
+		delete(userState.Scores, question.ID)
+		return nil
+	}
+	userState.SetScore(question.ID, score)
+
+	if !score.Graded {
+		return nil
+	}
+	return bot.SendMessage(ctx, userID, bot.renderTemplate(answerFeedback(question, score.Correct), userState), nil)
+}
+
+// answerFeedback returns feedback on an answer to a graded question
+func answerFeedback(question *models.Question, correct bool) string {
+	if correct {
+		if question.CorrectFeedback != "" {
+			return question.CorrectFeedback
+		}
+		return DefaultCorrectFeedback
+	}
+
+	feedback := question.WrongFeedback
+	if feedback == "" {
+		feedback = DefaultWrongFeedback
+	}
+	return strings.ReplaceAll(feedback, "{answer}", question.CorrectAnswer)
+}
+
+// renderTemplate replaces {name}, {score}, {correct} and {total} placeholders.
+// Total is the number of graded questions answered.
+func (bot *TelegramBot) renderTemplate(text string, userState *models.UserState) string {
+	correct, graded := userState.CorrectCount()
+	return strings.NewReplacer(
+		"{name}", userState.Name,
+		"{score}", strconv.Itoa(userState.Score()),
+		"{correct}", strconv.Itoa(correct),
+		"{total}", strconv.Itoa(graded),
+	).Replace(text)
+}
+
+// generateScoreSummary returns score lines of the answers summary, empty when nothing was scored
+func generateScoreSummary(userState *models.UserState) string {
+	if !userState.IsScored() {
+		return ""
+	}
+
+	summary := fmt.Sprintf("\n🏆 Score: %d\n", userState.Score())
+	if correct, graded := userState.CorrectCount(); graded > 0 {
+		summary += fmt.Sprintf("✅ Correct answers: %d of %d\n", correct, graded)
+	}
+	return summary
+}
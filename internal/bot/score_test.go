@@ -0,0 +1,218 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"tlgbot/internal/messenger"
+	"tlgbot/internal/models"
+	"tlgbot/internal/services"
+)
+
+func createQuizBot(t *testing.T) (*TelegramBot, *messenger.Recorder, *models.UserState) {
+	t.Helper()
+	questions := map[string]models.Question{
+		"q1": {
+			ID:            "q1",
+			Text:          "2 + 2?",
+			CorrectAnswer: "4",
+			Options: []models.Option{
+				{Text: "4", NextID: "q2"},
+				{Text: "5", NextID: "q2"},
+			},
+		},
+		"q2": {
+			ID:              "q2",
+			Text:            "Capital of France?",
+			InputType:       "text",
+			CorrectAnswer:   "Paris",
+			Points:          intPtr(3),
+			CorrectFeedback: "Right, {name}! Score: {score}",
+			WrongFeedback:   "No, it is {answer}.",
+			Options:         []models.Option{{NextID: "bonus"}},
+		},
+		"bonus": {
+			ID:   "bonus",
+			Text: "Score so far: {score}. Confident?",
+			Options: []models.Option{
+				{Text: "Yes", NextID: "result", Points: 1},
+				{Text: "No", NextID: "result", Points: -1},
+			},
+		},
+		"result": {
+			ID: "result",
+			ScoreBranches: []models.ScoreBranch{
+				{MinScore: intPtr(4), NextID: "excellent"},
+				{NextID: "keep_learning"},
+			},
+		},
+		"excellent":     {ID: "excellent", Terminal: true, Text: "Excellent: {correct} of {total}, {score} points"},
+		"keep_learning": {ID: "keep_learning", Terminal: true, Text: "Keep learning: {correct} of {total}, {score} points"},
+	}
+
+	recorder := messenger.NewRecorder()
+	userStateManager := services.NewUserStateManager()
+	bot := NewTelegramBot(recorder, &models.Config{StartQuestionID: "q1"}, userStateManager, services.NewQuestionManager(questions))
+
+	userState := userStateManager.GetOrCreateUserState(123, "Ann")
+	userState.CurrentQuestionID = "q1"
+	return bot, recorder, userState
+}
+
+func TestQuizFlow(t *testing.T) {
+	tests := []struct {
+		name            string
+		q1, q2, bonus   string
+		expectedOutcome string
+		expectedScore   int
+		expectedTexts   []string
+	}{
+		{
+			name: "all correct", q1: "4", q2: "paris", bonus: "Yes",
+			expectedOutcome: "excellent",
+			expectedScore:   5,
+			expectedTexts: []string{
+				DefaultCorrectFeedback,
+				"Capital of France?",
+				"Right, Ann! Score: 4",
+				"Score so far: 4. Confident?",
+				"Excellent: 2 of 2, 5 points",
+			},
+		},
+		{
+			name: "wrong answers", q1: "5", q2: "Lyon", bonus: "No",
+			expectedOutcome: "keep_learning",
+			expectedScore:   -1,
+			expectedTexts: []string{
+				"❌ Wrong, the answer was: 4",
+				"Capital of France?",
+				"No, it is Paris.",
+				"Score so far: 0. Confident?",
+				"Keep learning: 0 of 2, -1 points",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, recorder, userState := createQuizBot(t)
+
+			if err := bot.ProcessOptionAnswer(context.Background(), 123, tt.q1); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := bot.ProcessAnswer(context.Background(), 123, tt.q2); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := bot.moveToNextQuestion(context.Background(), 123, "bonus"); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := bot.ProcessOptionAnswer(context.Background(), 123, tt.bonus); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if userState.Outcome != tt.expectedOutcome || userState.CurrentQuestionID != tt.expectedOutcome {
+				t.Errorf("Expected outcome %s, got %s at %s", tt.expectedOutcome, userState.Outcome, userState.CurrentQuestionID)
+			}
+			if userState.Score() != tt.expectedScore {
+				t.Errorf("Expected score %d, got %d", tt.expectedScore, userState.Score())
+			}
+
+			texts := recorder.Texts(123)
+			if len(texts) != len(tt.expectedTexts) {
+				t.Fatalf("Expected %d messages, got %q", len(tt.expectedTexts), texts)
+			}
+			for i, expected := range tt.expectedTexts {
+				if !strings.HasPrefix(texts[i], expected) {
+					t.Errorf("Message %d: expected prefix %q, got %q", i, expected, texts[i])
+				}
+			}
+
+			last := texts[len(texts)-1]
+			if !strings.Contains(last, "🏆 Score: ") || !strings.Contains(last, "✅ Correct answers: ") {
+				t.Errorf("Expected score in the summary, got %q", last)
+			}
+		})
+	}
+}
+
+func TestFollowScoreBranches(t *testing.T) {
+	questions := services.NewQuestionManager(map[string]models.Question{
+		"router": {ID: "router", ScoreBranches: []models.ScoreBranch{{MaxScore: intPtr(0), NextID: "low"}}},
+		"low":    {ID: "low", Terminal: true},
+		"loop":   {ID: "loop", ScoreBranches: []models.ScoreBranch{{NextID: "loop"}}},
+		"broken": {ID: "broken", ScoreBranches: []models.ScoreBranch{{NextID: "missing"}}},
+	})
+
+	tests := []struct {
+		name        string
+		start       string
+		score       int
+		expectedID  string
+		expectError bool
+	}{
+		{name: "matching branch", start: "router", score: 0, expectedID: "low"},
+		{name: "no matching branch", start: "router", score: 5, expectedID: "router"},
+		{name: "question without branches", start: "low", expectedID: "low"},
+		{name: "cycle", start: "loop", expectError: true},
+		{name: "unknown question", start: "broken", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, err := questions.GetQuestion(tt.start)
+			if err != nil {
+				t.Fatalf("Expected question %s: %v", tt.start, err)
+			}
+
+			question, err := FollowScoreBranches(questions, "", start, tt.score)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got question %s", question.ID)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if question.ID != tt.expectedID {
+				t.Errorf("Expected question %s, got %s", tt.expectedID, question.ID)
+			}
+		})
+	}
+}
+
+func TestUngradedAnswerNotScored(t *testing.T) {
+	bot, recorder, userStateManager, _ := createTestBot(t)
+	userState := userStateManager.GetOrCreateUserState(123, "John")
+	userState.CurrentQuestionID = "start"
+
+	if err := bot.ProcessOptionAnswer(context.Background(), 123, "Option 1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if userState.IsScored() {
+		t.Error("Expected survey without points not to be scored")
+	}
+	if texts := recorder.Texts(123); len(texts) != 1 {
+		t.Errorf("Expected no feedback message, got %v", texts)
+	}
+}
+
+func TestReansweredWithoutPointsDropsScore(t *testing.T) {
+	bot, _, userState := createQuizBot(t)
+	question := &models.Question{ID: "bonus", Options: []models.Option{{Text: "Yes", Points: 1}, {Text: "Maybe"}}}
+
+	if err := bot.scoreAnswer(context.Background(), 123, userState, question, "Yes", &question.Options[0]); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if userState.Score() != 1 {
+		t.Fatalf("Expected 1 point, got %d", userState.Score())
+	}
+
+	if err := bot.scoreAnswer(context.Background(), 123, userState, question, "Maybe", &question.Options[1]); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, exists := userState.Scores["bonus"]; exists || userState.Score() != 0 {
+		t.Errorf("Expected points of the earlier answer to be dropped, got %+v", userState.Scores)
+	}
+}
@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"tlgbot/internal/models"
+	"tlgbot/internal/scheduler"
+)
+
+// DefaultReminderText is sent when neither the question nor the config sets a reminder message
+const DefaultReminderText = "⏰ {name}, your survey is waiting for you. Just answer the question above to continue."
+
+// attempt identifies the question of a survey attempt a reminder or timeout was planned for
+type attempt struct {
+	surveyID   string
+	questionID string
+	startedAt  time.Time
+}
+
+// attemptOf returns the attempt the user is currently at
+func attemptOf(userState *models.UserState) attempt {
+	return attempt{surveyID: userState.SurveyID, questionID: userState.CurrentQuestionID, startedAt: userState.StartedAt}
+}
+
+// isCurrent checks if the user is still in progress at the planned question of the same attempt
+func (a attempt) isCurrent(userState *models.UserState) bool {
+	return userState != nil && userState.IsInProgress() &&
+		userState.SurveyID == a.surveyID && userState.CurrentQuestionID == a.questionID && userState.StartedAt.Equal(a.startedAt)
+}
+
+// SetScheduler enables inactivity reminders and session timeouts planned on the scheduler
+func (bot *TelegramBot) SetScheduler(s *scheduler.Scheduler) {
+	bot.scheduler = s
+}
+
+// reminderKey names the reminder job of a user
+func reminderKey(userID int64) string {
+	return fmt.Sprintf("reminder:%d", userID)
+}
+
+// expiryKey names the session timeout job of a user
+func expiryKey(userID int64) string {
+	return fmt.Sprintf("expiry:%d", userID)
+}
+
+// scheduleTimeouts plans reminder and session timeout for the question just sent,
+// replacing those of the previous question. Terminal questions only cancel them.
+func (bot *TelegramBot) scheduleTimeouts(userID int64, userState *models.UserState, question *models.Question) {
+	if bot.scheduler == nil {
+		return
+	}
+	bot.scheduler.Cancel(reminderKey(userID))
+	bot.scheduler.Cancel(expiryKey(userID))
+	if question.IsTerminal() {
+		return
+	}
+
+	bot.planTimeouts(userID, attemptOf(userState), question, bot.scheduler.Now())
+}
+
+// ResumeTimeouts plans reminders and session timeouts of attempts in progress, such as those restored
+// from the state directory after a restart, and returns their number. Times count from when the current
+// question was reached: reminders due while the bot was down are skipped, overdue attempts expire right away.
+func (bot *TelegramBot) ResumeTimeouts() int {
+	if bot.scheduler == nil {
+		return 0
+	}
+
+	count := 0
+	for userID, userState := range bot.userStateManager.GetAllUserStates() {
+		if !userState.IsInProgress() {
+			continue
+		}
+		question, err := bot.questionManager.GetSurveyQuestion(userState.SurveyID, userState.CurrentQuestionID)
+		if err != nil || question.IsTerminal() {
+			continue
+		}
+		bot.planTimeouts(userID, attemptOf(userState), question, reachedAt(userState))
+		count++
+	}
+	return count
+}
+
+// planTimeouts plans reminder and session timeout of the question reached at the given time
+func (bot *TelegramBot) planTimeouts(userID int64, current attempt, question *models.Question, since time.Time) {
+	now := bot.scheduler.Now()
+	if after := question.GetReminderAfter(time.Duration(bot.config.ReminderAfter)); after > 0 {
+		// Skip reminders that are already past, as far as repeating allows
+		at, n := since.Add(after), 1
+		interval := time.Duration(bot.config.ReminderInterval)
+		for at.Before(now) && interval > 0 && n < bot.config.ReminderLimit {
+			at, n = at.Add(interval), n+1
+		}
+		if !at.Before(now) {
+			bot.scheduler.At(reminderKey(userID), at, func() { bot.remind(userID, current, n) })
+		}
+	}
+	if timeout := question.GetTimeout(time.Duration(bot.config.SessionTimeout)); timeout > 0 {
+		bot.scheduler.At(expiryKey(userID), since.Add(timeout), func() { bot.expire(userID, current) })
+	}
+}
+
+// reachedAt returns when the user reached the current question, the last activity known for older states
+func reachedAt(userState *models.UserState) time.Time {
+	if n := len(userState.Visits); n > 0 {
+		return userState.Visits[n-1].At
+	}
+	if !userState.UpdatedAt.IsZero() {
+		return userState.UpdatedAt
+	}
+	return userState.StartedAt
+}
+
+// remind sends the n-th reminder and plans the next one when reminders repeat
+func (bot *TelegramBot) remind(userID int64, current attempt, n int) {
+	defer bot.userStateManager.LockUser(userID)()
+	userState := bot.userStateManager.GetUserState(userID)
+	if !current.isCurrent(userState) {
+		return
+	}
+	question, err := bot.questionManager.GetSurveyQuestion(current.surveyID, current.questionID)
+	if err != nil {
+		return
+	}
+
+	ctx := userContext(context.Background(), userID, userState)
+	if err := bot.SendMessage(ctx, userID, bot.renderTemplate(bot.reminderText(question), userState), nil); err != nil {
+		slog.ErrorContext(ctx, "Failed to send reminder", "error", err)
+	} else {
+		bot.metrics.ReminderSent(current.surveyID, current.questionID)
+		slog.InfoContext(ctx, "Reminder sent", "reminder", n)
+	}
+
+	if interval := time.Duration(bot.config.ReminderInterval); interval > 0 && n < bot.config.ReminderLimit {
+		bot.scheduler.After(reminderKey(userID), interval, func() { bot.remind(userID, current, n+1) })
+	}
+}
+
+// reminderText returns reminder message of the question, the configured one or the default
+func (bot *TelegramBot) reminderText(question *models.Question) string {
+	if question.ReminderText != "" {
+		return question.ReminderText
+	}
+	if bot.config.ReminderText != "" {
+		return bot.config.ReminderText
+	}
+	return DefaultReminderText
+}
+
+// expire marks the unfinished attempt abandoned and sends the expired message when configured
+func (bot *TelegramBot) expire(userID int64, current attempt) {
+	defer bot.userStateManager.LockUser(userID)()
+	userState := bot.userStateManager.GetUserState(userID)
+	if !current.isCurrent(userState) {
+		return
+	}
+
+	bot.scheduler.Cancel(reminderKey(userID))
+	userState.Expire(bot.scheduler.Now())
+	bot.metrics.SurveyExpired(current.surveyID)
+	bot.metrics.QuestionDroppedOff(current.surveyID, current.questionID)
+
+	ctx := userContext(context.Background(), userID, userState)
+	slog.InfoContext(ctx, "Survey expired after inactivity")
+	if err := bot.userStateManager.SaveUserState(userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to save user state", "error", err)
+	}
+
+	if bot.config.ExpiredText == "" {
+		return
+	}
+	if err := bot.SendMessage(ctx, userID, bot.renderTemplate(bot.config.ExpiredText, userState), nil); err != nil {
+		slog.ErrorContext(ctx, "Failed to send expired message", "error", err)
+	}
+}
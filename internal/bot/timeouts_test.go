@@ -0,0 +1,302 @@
+package bot
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"tlgbot/internal/messenger"
+	"tlgbot/internal/models"
+	"tlgbot/internal/scheduler"
+	"tlgbot/internal/services"
+)
+
+func durationPtr(d time.Duration) *models.Duration {
+	value := models.Duration(d)
+	return &value
+}
+
+func createTimeoutBot(t *testing.T, cfg *models.Config) (*TelegramBot, *messenger.Recorder, *scheduler.Manual, *models.UserState) {
+	t.Helper()
+	questions := map[string]models.Question{
+		"q1": {
+			ID:      "q1",
+			Text:    "Ready?",
+			Options: []models.Option{{Text: "Yes", NextID: "q2"}},
+		},
+		"q2": {
+			ID:            "q2",
+			Text:          "Your email?",
+			InputType:     "text",
+			ReminderAfter: durationPtr(5 * time.Minute),
+			ReminderText:  "Still waiting for your email, {name}",
+			Timeout:       durationPtr(time.Hour),
+			Options:       []models.Option{{NextID: "end"}},
+		},
+		"quiet": {
+			ID:            "quiet",
+			Text:          "No reminders here",
+			ReminderAfter: durationPtr(0),
+			Options:       []models.Option{{Text: "Done", NextID: "end"}},
+		},
+		"end": {ID: "end", Text: "Thanks!"},
+	}
+
+	recorder := messenger.NewRecorder()
+	userStateManager := services.NewUserStateManager()
+	bot := NewTelegramBot(recorder, cfg, userStateManager, services.NewQuestionManager(questions))
+	clock := scheduler.NewManual(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	bot.SetScheduler(clock.Scheduler)
+
+	userState := userStateManager.GetOrCreateUserState(123, "Ann")
+	userState.CurrentQuestionID = "q1"
+	userState.StartedAt = clock.Now()
+	return bot, recorder, clock, userState
+}
+
+// textsAfter returns texts sent to the user after the first n messages
+func textsAfter(recorder *messenger.Recorder, n int) []string {
+	texts := recorder.Texts(123)
+	if len(texts) <= n {
+		return nil
+	}
+	return texts[n:]
+}
+
+func TestReminders(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      models.Config
+		wait     time.Duration
+		expected []string
+	}{
+		{
+			name:     "disabled",
+			cfg:      models.Config{},
+			wait:     48 * time.Hour,
+			expected: nil,
+		},
+		{
+			name:     "once",
+			cfg:      models.Config{ReminderAfter: models.Duration(30 * time.Minute), ReminderLimit: 3},
+			wait:     48 * time.Hour,
+			expected: []string{"⏰ Ann, your survey is waiting for you. Just answer the question above to continue."},
+		},
+		{
+			name: "not due yet",
+			cfg:  models.Config{ReminderAfter: models.Duration(30 * time.Minute)},
+			wait: 29 * time.Minute,
+		},
+		{
+			name: "repeated up to the limit",
+			cfg: models.Config{
+				ReminderAfter:    models.Duration(30 * time.Minute),
+				ReminderInterval: models.Duration(time.Hour),
+				ReminderLimit:    3,
+				ReminderText:     "Hi {name}?",
+			},
+			wait:     48 * time.Hour,
+			expected: []string{"Hi Ann?", "Hi Ann?", "Hi Ann?"},
+		},
+		{
+			name: "stopped by session timeout",
+			cfg: models.Config{
+				ReminderAfter:    models.Duration(30 * time.Minute),
+				ReminderInterval: models.Duration(time.Hour),
+				ReminderLimit:    10,
+				ReminderText:     "Hi {name}?",
+				SessionTimeout:   models.Duration(2 * time.Hour),
+			},
+			wait:     48 * time.Hour,
+			expected: []string{"Hi Ann?", "Hi Ann?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot, recorder, clock, _ := createTimeoutBot(t, &tt.cfg)
+			question, _ := bot.questionManager.GetQuestion("q1")
+
+			if err := bot.ProcessQuestion(context.Background(), 123, question); err != nil {
+				t.Fatalf("ProcessQuestion failed: %v", err)
+			}
+			clock.Advance(tt.wait)
+
+			if got := textsAfter(recorder, 1); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected reminders %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSessionTimeout(t *testing.T) {
+	cfg := &models.Config{SessionTimeout: models.Duration(24 * time.Hour), ExpiredText: "Expired, {name}"}
+	bot, recorder, clock, userState := createTimeoutBot(t, cfg)
+	question, _ := bot.questionManager.GetQuestion("q1")
+
+	if err := bot.ProcessQuestion(context.Background(), 123, question); err != nil {
+		t.Fatalf("ProcessQuestion failed: %v", err)
+	}
+	clock.Advance(23 * time.Hour)
+	if userState.IsExpired() {
+		t.Fatal("Expected survey not to expire before the timeout")
+	}
+
+	clock.Advance(time.Hour)
+	if !userState.IsExpired() || userState.IsInProgress() {
+		t.Fatal("Expected survey to expire after the timeout")
+	}
+	if userState.CurrentQuestionID != "q1" {
+		t.Errorf("Expected expired survey to keep question q1, got %q", userState.CurrentQuestionID)
+	}
+	if got := textsAfter(recorder, 1); !reflect.DeepEqual(got, []string{"Expired, Ann"}) {
+		t.Errorf("Expected expired message, got %q", got)
+	}
+	if got := clock.Pending(); got != 0 {
+		t.Errorf("Expected no pending jobs after expiry, got %d", got)
+	}
+}
+
+func TestSessionTimeoutWaitsForUpdate(t *testing.T) {
+	cfg := &models.Config{SessionTimeout: models.Duration(time.Hour)}
+	bot, _, clock, userState := createTimeoutBot(t, cfg)
+	question, _ := bot.questionManager.GetQuestion("q1")
+	if err := bot.ProcessQuestion(context.Background(), 123, question); err != nil {
+		t.Fatalf("ProcessQuestion failed: %v", err)
+	}
+
+	// Expiry due while an update of the user is handled waits until the update is done
+	unlock := bot.userStateManager.LockUser(123)
+	expired := make(chan struct{})
+	go func() {
+		clock.Advance(time.Hour)
+		close(expired)
+	}()
+
+	select {
+	case <-expired:
+		t.Fatal("Expected expiry to wait for the update being handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-expired
+	if !userState.IsExpired() {
+		t.Error("Expected survey to expire after the update")
+	}
+}
+
+func TestResumeTimeouts(t *testing.T) {
+	cfg := &models.Config{
+		ReminderAfter:    models.Duration(30 * time.Minute),
+		ReminderInterval: models.Duration(time.Hour),
+		ReminderLimit:    3,
+		ReminderText:     "Hi {name}?",
+		SessionTimeout:   models.Duration(3 * time.Hour),
+	}
+	bot, recorder, clock, userState := createTimeoutBot(t, cfg)
+	// Restored states reached their question before the restart, reminders 1 and 2 were due while down
+	userState.Reach("q1", clock.Now().Add(-100*time.Minute))
+
+	overdue := bot.userStateManager.GetOrCreateUserState(124, "Bob")
+	overdue.CurrentQuestionID = "q1"
+	overdue.Reach("q1", clock.Now().Add(-5*time.Hour))
+
+	finished := bot.userStateManager.GetOrCreateUserState(125, "Eve")
+	finished.Complete(125, "end", clock.Now())
+
+	if resumed := bot.ResumeTimeouts(); resumed != 2 {
+		t.Fatalf("Expected timeouts of 2 attempts in progress, got %d", resumed)
+	}
+
+	clock.Advance(0)
+	if !bot.userStateManager.GetUserState(124).IsExpired() {
+		t.Error("Expected overdue attempt to expire right away")
+	}
+
+	clock.Advance(time.Hour)
+	if got := recorder.Texts(123); !reflect.DeepEqual(got, []string{"Hi Ann?"}) {
+		t.Errorf("Expected only the last reminder, got %q", got)
+	}
+	if userState.IsExpired() {
+		t.Error("Expected attempt not to expire before its timeout")
+	}
+
+	clock.Advance(time.Hour)
+	if !userState.IsExpired() {
+		t.Error("Expected attempt to expire 3 hours after reaching the question")
+	}
+}
+
+func TestQuestionTimeoutOverrides(t *testing.T) {
+	cfg := &models.Config{
+		ReminderAfter:  models.Duration(30 * time.Minute),
+		SessionTimeout: models.Duration(24 * time.Hour),
+		ReminderText:   "Configured reminder",
+	}
+	bot, recorder, clock, userState := createTimeoutBot(t, cfg)
+	question, _ := bot.questionManager.GetQuestion("q1")
+	if err := bot.ProcessQuestion(context.Background(), 123, question); err != nil {
+		t.Fatalf("ProcessQuestion failed: %v", err)
+	}
+
+	// Answering replaces reminder and timeout of q1 with those of q2
+	if err := bot.ProcessOptionAnswer(context.Background(), 123, "Yes"); err != nil {
+		t.Fatalf("ProcessOptionAnswer failed: %v", err)
+	}
+	sent := len(recorder.Texts(123))
+	clock.Advance(time.Hour)
+
+	if got := textsAfter(recorder, sent); !reflect.DeepEqual(got, []string{"Still waiting for your email, Ann"}) {
+		t.Errorf("Expected question reminder only, got %q", got)
+	}
+	if !userState.IsExpired() || userState.CurrentQuestionID != "q2" {
+		t.Errorf("Expected survey to expire at q2 after the question timeout, got question %q expired %v", userState.CurrentQuestionID, userState.IsExpired())
+	}
+}
+
+func TestTimeoutsCancelled(t *testing.T) {
+	cfg := &models.Config{ReminderAfter: models.Duration(30 * time.Minute), SessionTimeout: models.Duration(time.Hour)}
+
+	t.Run("question without reminders", func(t *testing.T) {
+		bot, recorder, clock, userState := createTimeoutBot(t, cfg)
+		userState.CurrentQuestionID = "quiet"
+		question, _ := bot.questionManager.GetQuestion("quiet")
+		if err := bot.ProcessQuestion(context.Background(), 123, question); err != nil {
+			t.Fatalf("ProcessQuestion failed: %v", err)
+		}
+		clock.Advance(59 * time.Minute)
+		if got := textsAfter(recorder, 1); got != nil {
+			t.Errorf("Expected no reminders, got %q", got)
+		}
+	})
+
+	t.Run("terminal question", func(t *testing.T) {
+		bot, _, clock, userState := createTimeoutBot(t, cfg)
+		question, _ := bot.questionManager.GetQuestion("q1")
+		if err := bot.ProcessQuestion(context.Background(), 123, question); err != nil {
+			t.Fatalf("ProcessQuestion failed: %v", err)
+		}
+		userState.CurrentQuestionID = "end"
+		end, _ := bot.questionManager.GetQuestion("end")
+		if err := bot.ProcessQuestion(context.Background(), 123, end); err != nil {
+			t.Fatalf("ProcessQuestion failed: %v", err)
+		}
+		if got := clock.Pending(); got != 0 {
+			t.Errorf("Expected reminders cancelled on completion, got %d pending", got)
+		}
+	})
+
+	t.Run("restarted attempt", func(t *testing.T) {
+		bot, recorder, clock, userState := createTimeoutBot(t, cfg)
+		question, _ := bot.questionManager.GetQuestion("q1")
+		if err := bot.ProcessQuestion(context.Background(), 123, question); err != nil {
+			t.Fatalf("ProcessQuestion failed: %v", err)
+		}
+		userState.StartedAt = userState.StartedAt.Add(time.Minute)
+		clock.Advance(2 * time.Hour)
+		if got := textsAfter(recorder, 1); got != nil || userState.IsExpired() {
+			t.Errorf("Expected jobs of the previous attempt to do nothing, got %q expired %v", got, userState.IsExpired())
+		}
+	})
+}
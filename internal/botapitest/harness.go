@@ -0,0 +1,105 @@
+package botapitest
+
+import (
+	"fmt"
+
+	"tlgbot/internal/bot"
+	"tlgbot/internal/config"
+	"tlgbot/internal/handlers"
+	"tlgbot/internal/messenger"
+	"tlgbot/internal/models"
+	"tlgbot/internal/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// Harness runs the bot against a fake Bot API server.
+// Updates are fetched with getUpdates and handled one at a time, so transcripts are deterministic.
+type Harness struct {
+	Server     *Server
+	Bot        *bot.TelegramBot
+	Handler    *handlers.TelegramHandler
+	UserStates *services.UserStateManager
+
+	api    *tgbotapi.BotAPI
+	offset int
+}
+
+// NewHarness wires the bot with the given configuration and questions to a new fake server
+func NewHarness(cfg *models.Config, questionManager models.QuestionService) (*Harness, error) {
+	server := NewServer()
+
+	api, err := server.NewBotAPI()
+	if err != nil {
+		server.Close()
+		return nil, fmt.Errorf("failed to connect to fake Bot API: %w", err)
+	}
+
+	userStateManager := services.NewUserStateManager()
+	telegramBot := bot.NewTelegramBot(messenger.NewTelegram(api, nil), cfg, userStateManager, questionManager)
+
+	return &Harness{
+		Server:     server,
+		Bot:        telegramBot,
+		Handler:    handlers.NewTelegramHandler(telegramBot, cfg, userStateManager, questionManager),
+		UserStates: userStateManager,
+		api:        api,
+	}, nil
+}
+
+// NewHarnessFromFile creates a harness for questions file without delays between messages
+func NewHarnessFromFile(questionsPath string) (*Harness, error) {
+	questions, err := config.LoadQuestions(questionsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &models.Config{
+		TelegramToken:     Token,
+		StartQuestionID:   config.DefaultStartQuestionID,
+		QuestionsFilePath: questionsPath,
+	}
+	return NewHarness(cfg, services.NewQuestionManager(questions))
+}
+
+// Close shuts the fake server down
+func (h *Harness) Close() {
+	h.Server.Close()
+}
+
+// Send sends text from the user and waits until the bot handles it
+func (h *Harness) Send(userID int64, text string) error {
+	h.Server.SendText(userID, text)
+	return h.Process()
+}
+
+// Press presses inline button on the latest keyboard sent to the user and waits until the bot handles it
+func (h *Harness) Press(userID int64, buttonText string) error {
+	if err := h.Server.PressButton(userID, buttonText); err != nil {
+		return err
+	}
+	return h.Process()
+}
+
+// Process fetches pending updates and handles them in order
+func (h *Harness) Process() error {
+	for {
+		updates, err := h.api.GetUpdates(tgbotapi.UpdateConfig{Offset: h.offset})
+		if err != nil {
+			return fmt.Errorf("failed to get updates: %w", err)
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+
+		for _, update := range updates {
+			h.offset = update.UpdateID + 1
+			h.Handler.HandleUpdate(update)
+		}
+	}
+}
+
+// Transcript returns conversation with the user in readable form
+func (h *Harness) Transcript(userID int64) []string {
+	return h.Server.Transcript(userID)
+}
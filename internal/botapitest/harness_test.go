@@ -0,0 +1,78 @@
+package botapitest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tlgbot/internal/models"
+)
+
+func writeQuestions(t *testing.T, questions []models.Question) string {
+	t.Helper()
+
+	data, err := json.Marshal(questions)
+	if err != nil {
+		t.Fatalf("Failed to marshal questions: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "questions.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write questions: %v", err)
+	}
+	return path
+}
+
+func TestHarnessConversation(t *testing.T) {
+	path := writeQuestions(t, []models.Question{
+		{ID: "start", Text: "Hi {name}! Ready?", Options: []models.Option{{Text: "Yes", NextID: "name"}}},
+		{ID: "name", Text: "Your city?", InputType: "text", Options: []models.Option{{NextID: "end"}}},
+		{ID: "end", Text: "Thanks!", Terminal: true},
+	})
+
+	h, err := NewHarnessFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to create harness: %v", err)
+	}
+	defer h.Close()
+
+	steps := []func() error{
+		func() error { return h.Send(testUserID, "/start") },
+		func() error { return h.Press(testUserID, "Yes") },
+		func() error { return h.Send(testUserID, "Berlin") },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+	}
+
+	expected := []string{
+		"user: /start",
+		"bot: Hi Test! Ready?\n  [Yes]",
+		"user: [Yes]",
+		"bot: Your city?",
+		"user: Berlin",
+		"bot: Thanks!\n\n📋 Your answers:\n• Hi {name}! Ready?: Yes\n• Your city?: Berlin\n",
+	}
+	got := h.Transcript(testUserID)
+	if strings.Join(got, "\n---\n") != strings.Join(expected, "\n---\n") {
+		t.Errorf("Unexpected transcript:\n%s", strings.Join(got, "\n---\n"))
+	}
+
+	if state := h.UserStates.GetUserState(testUserID); state == nil || !state.IsCompleted() {
+		t.Errorf("Expected completed survey, got %+v", state)
+	}
+
+	// Every button press must be acknowledged
+	acknowledged := 0
+	for _, req := range h.Server.Requests() {
+		if req.Method == "answerCallbackQuery" {
+			acknowledged++
+		}
+	}
+	if acknowledged != 1 {
+		t.Errorf("Expected 1 acknowledged callback, got %d", acknowledged)
+	}
+}
@@ -0,0 +1,462 @@
+// Package botapitest provides a fake Telegram Bot API server and a harness
+// running the bot against it, for end-to-end tests of conversations.
+package botapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// Token is the bot token accepted by the fake server
+const Token = "123456:TEST-TOKEN"
+
+// maxUploadMemory limits memory used for parsing uploaded files
+const maxUploadMemory = 32 << 20
+
+// Request is a Bot API call received from the bot
+type Request struct {
+	Method     string
+	ChatID     int64
+	MessageID  int
+	Text       string
+	Buttons    [][]string
+	Files      []string
+	CallbackID string
+	Action     string
+}
+
+// apiError is a scripted failure of a Bot API method
+type apiError struct {
+	code        int
+	description string
+}
+
+// Server is a fake Telegram Bot API server.
+// Users act through SendText and PressButton, requests from the bot are recorded.
+type Server struct {
+	server *httptest.Server
+	bot    tgbotapi.User
+
+	mu            sync.Mutex
+	users         map[int64]tgbotapi.User
+	updates       []tgbotapi.Update
+	updated       chan struct{}
+	nextUpdateID  int
+	nextMessageID int
+	nextCallback  int
+	requests      []Request
+	transcript    []Entry
+	failures      map[string][]apiError
+}
+
+// NewServer starts a fake Bot API server
+func NewServer() *Server {
+	s := &Server{
+		bot:          tgbotapi.User{ID: 123456, IsBot: true, FirstName: "Test Bot", UserName: "test_bot"},
+		users:        make(map[int64]tgbotapi.User),
+		updated:      make(chan struct{}),
+		nextUpdateID: 1,
+		failures:     make(map[string][]apiError),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Endpoint returns API endpoint format accepted by tgbotapi.NewBotAPIWithClient
+func (s *Server) Endpoint() string {
+	return s.server.URL + "/bot%s/%s"
+}
+
+// NewBotAPI creates an API client connected to the server
+func (s *Server) NewBotAPI() (*tgbotapi.BotAPI, error) {
+	return tgbotapi.NewBotAPIWithClient(Token, s.Endpoint(), s.server.Client())
+}
+
+// SetUser registers profile of a user sending updates
+func (s *Server) SetUser(user tgbotapi.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.ID] = user
+}
+
+// FailNext makes the next call of the method fail with the given error code and description
+func (s *Server) FailNext(method string, code int, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], apiError{code: code, description: description})
+}
+
+// SendText queues a text message from the user, commands get a bot_command entity
+func (s *Server) SendText(userID int64, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	message := s.newMessage(s.user(userID), text)
+	if strings.HasPrefix(text, "/") {
+		command := strings.SplitN(text, " ", 2)[0]
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+
+	s.transcript = append(s.transcript, Entry{From: FromUser, ChatID: userID, Text: text})
+	s.pushUpdate(tgbotapi.Update{Message: message})
+}
+
+// PressButton queues a callback query for the inline button with the given text
+// on the latest message with inline keyboard sent to the user
+func (s *Server) PressButton(userID int64, buttonText string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source, data, err := s.findButton(userID, buttonText)
+	if err != nil {
+		return err
+	}
+
+	s.nextCallback++
+	user := s.user(userID)
+	s.transcript = append(s.transcript, Entry{From: FromUser, ChatID: userID, Button: buttonText})
+	s.pushUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:   strconv.Itoa(s.nextCallback),
+		From: &user,
+		Message: &tgbotapi.Message{
+			MessageID: source.MessageID,
+			From:      &s.bot,
+			Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+			Text:      source.Text,
+		},
+		Data: data,
+	}})
+	return nil
+}
+
+// Requests returns all recorded Bot API calls in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Transcript returns conversation with the user in readable form
+func (s *Server) Transcript(userID int64) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([]string, 0, len(s.transcript))
+	for _, entry := range s.transcript {
+		if entry.ChatID == userID {
+			lines = append(lines, entry.String())
+		}
+	}
+	return lines
+}
+
+// Entries returns conversation with the user as structured entries
+func (s *Server) Entries(userID int64) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var entries []Entry
+	for _, entry := range s.transcript {
+		if entry.ChatID == userID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// PendingUpdates returns number of updates not yet fetched by the bot
+func (s *Server) PendingUpdates() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.updates)
+}
+
+// user returns registered profile of the user or a default one
+func (s *Server) user(userID int64) tgbotapi.User {
+	if user, exists := s.users[userID]; exists {
+		return user
+	}
+	return tgbotapi.User{ID: userID, FirstName: "Test"}
+}
+
+// newMessage creates a message from the user in a private chat
+func (s *Server) newMessage(user tgbotapi.User, text string) *tgbotapi.Message {
+	s.nextMessageID++
+	return &tgbotapi.Message{
+		MessageID: s.nextMessageID,
+		From:      &user,
+		Chat:      &tgbotapi.Chat{ID: user.ID, Type: "private", FirstName: user.FirstName, UserName: user.UserName},
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+}
+
+// pushUpdate queues update and wakes up pending getUpdates calls
+func (s *Server) pushUpdate(update tgbotapi.Update) {
+	update.UpdateID = s.nextUpdateID
+	s.nextUpdateID++
+	s.updates = append(s.updates, update)
+
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+// findButton looks up inline button on the latest keyboard sent to the user
+func (s *Server) findButton(userID int64, buttonText string) (Entry, string, error) {
+	for i := len(s.transcript) - 1; i >= 0; i-- {
+		entry := s.transcript[i]
+		if entry.ChatID != userID || entry.From != FromBot || len(entry.Buttons) == 0 {
+			continue
+		}
+
+		for _, row := range entry.Buttons {
+			for _, button := range row {
+				if button.Text == buttonText && button.Data != "" {
+					return entry, button.Data, nil
+				}
+			}
+		}
+		return entry, "", fmt.Errorf("button %q not found on latest keyboard sent to user %d", buttonText, userID)
+	}
+	return Entry{}, "", fmt.Errorf("no inline keyboard was sent to user %d", userID)
+}
+
+// handle serves Bot API requests
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	// Path is /bot<token>/<method>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] != "bot"+Token {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	method := parts[1]
+
+	if err := parseForm(r); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: "+err.Error())
+		return
+	}
+
+	if method == "getUpdates" {
+		s.handleGetUpdates(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if failure, failed := s.takeFailure(method); failed {
+		s.requests = append(s.requests, requestFromForm(method, r))
+		writeError(w, failure.code, failure.description)
+		return
+	}
+
+	switch method {
+	case "getMe":
+		writeResult(w, s.bot)
+	case "sendMessage", "sendPhoto", "sendDocument":
+		req := requestFromForm(method, r)
+		writeResult(w, s.recordMessage(req, r))
+	case "sendMediaGroup":
+		req := requestFromForm(method, r)
+		writeResult(w, []tgbotapi.Message{s.recordMessage(req, r)})
+	case "editMessageText", "editMessageReplyMarkup":
+		req := requestFromForm(method, r)
+		s.requests = append(s.requests, req)
+		s.transcript = append(s.transcript, entryFromRequest(req, r))
+		writeResult(w, tgbotapi.Message{MessageID: req.MessageID, Chat: &tgbotapi.Chat{ID: req.ChatID}, Text: req.Text})
+	case "answerCallbackQuery", "sendChatAction", "setMyCommands", "deleteMyCommands":
+		s.requests = append(s.requests, requestFromForm(method, r))
+		writeResult(w, true)
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method not supported by fake server")
+	}
+}
+
+// handleGetUpdates returns queued updates, waiting up to the requested timeout when there are none
+func (s *Server) handleGetUpdates(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.FormValue("offset"))
+	timeout, _ := strconv.Atoi(r.FormValue("timeout"))
+	deadline := time.After(time.Duration(timeout) * time.Second)
+
+	for {
+		s.mu.Lock()
+		// Confirm updates below offset like Telegram does
+		for len(s.updates) > 0 && s.updates[0].UpdateID < offset {
+			s.updates = s.updates[1:]
+		}
+		updates := append([]tgbotapi.Update(nil), s.updates...)
+		updated := s.updated
+		s.mu.Unlock()
+
+		if len(updates) > 0 || timeout <= 0 {
+			writeResult(w, updates)
+			return
+		}
+
+		select {
+		case <-updated:
+		case <-deadline:
+			timeout = 0
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// takeFailure pops scripted failure of the method
+func (s *Server) takeFailure(method string) (apiError, bool) {
+	failures := s.failures[method]
+	if len(failures) == 0 {
+		return apiError{}, false
+	}
+	s.failures[method] = failures[1:]
+	return failures[0], true
+}
+
+// recordMessage stores request that produces a message and returns that message
+func (s *Server) recordMessage(req Request, r *http.Request) tgbotapi.Message {
+	s.nextMessageID++
+	req.MessageID = s.nextMessageID
+	s.requests = append(s.requests, req)
+
+	entry := entryFromRequest(req, r)
+	entry.MessageID = req.MessageID
+	s.transcript = append(s.transcript, entry)
+
+	return tgbotapi.Message{
+		MessageID: req.MessageID,
+		From:      &s.bot,
+		Chat:      &tgbotapi.Chat{ID: req.ChatID, Type: "private"},
+		Date:      int(time.Now().Unix()),
+		Text:      req.Text,
+	}
+}
+
+// parseForm parses URL-encoded or multipart request body
+func parseForm(r *http.Request) error {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.ParseMultipartForm(maxUploadMemory)
+	}
+	return r.ParseForm()
+}
+
+// requestFromForm extracts recorded fields from request form
+func requestFromForm(method string, r *http.Request) Request {
+	chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	messageID, _ := strconv.Atoi(r.FormValue("message_id"))
+
+	req := Request{
+		Method:     method,
+		ChatID:     chatID,
+		MessageID:  messageID,
+		Text:       r.FormValue("text"),
+		CallbackID: r.FormValue("callback_query_id"),
+		Action:     r.FormValue("action"),
+		Files:      uploadedFiles(r.MultipartForm),
+	}
+	for _, row := range parseButtons(r.FormValue("reply_markup")) {
+		texts := make([]string, len(row))
+		for i, button := range row {
+			texts[i] = button.Text
+		}
+		req.Buttons = append(req.Buttons, texts)
+	}
+	return req
+}
+
+// uploadedFiles returns names of uploaded files in field order
+func uploadedFiles(form *multipart.Form) []string {
+	if form == nil {
+		return nil
+	}
+
+	// tgbotapi names album files file-0, file-1, ...
+	var files []string
+	for _, field := range []string{"photo", "document"} {
+		for _, header := range form.File[field] {
+			files = append(files, header.Filename)
+		}
+	}
+	for i := 0; ; i++ {
+		headers := form.File[fmt.Sprintf("file-%d", i)]
+		if len(headers) == 0 {
+			break
+		}
+		files = append(files, headers[0].Filename)
+	}
+	return files
+}
+
+// parseButtons decodes reply markup, returning inline buttons or plain reply buttons
+func parseButtons(markup string) [][]Button {
+	if markup == "" {
+		return nil
+	}
+
+	var decoded struct {
+		InlineKeyboard [][]tgbotapi.InlineKeyboardButton `json:"inline_keyboard"`
+		Keyboard       [][]tgbotapi.KeyboardButton       `json:"keyboard"`
+	}
+	if err := json.Unmarshal([]byte(markup), &decoded); err != nil {
+		return nil
+	}
+
+	var rows [][]Button
+	for _, row := range decoded.InlineKeyboard {
+		buttons := make([]Button, len(row))
+		for i, button := range row {
+			buttons[i] = Button{Text: button.Text}
+			if button.CallbackData != nil {
+				buttons[i].Data = *button.CallbackData
+			}
+			if button.URL != nil {
+				buttons[i].URL = *button.URL
+			}
+		}
+		rows = append(rows, buttons)
+	}
+	for _, row := range decoded.Keyboard {
+		buttons := make([]Button, len(row))
+		for i, button := range row {
+			buttons[i] = Button{Text: button.Text}
+		}
+		rows = append(rows, buttons)
+	}
+	return rows
+}
+
+// writeResult writes successful API response
+func writeResult(w http.ResponseWriter, result interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, tgbotapi.APIResponse{Ok: true, Result: data})
+}
+
+// writeError writes failed API response
+func writeError(w http.ResponseWriter, code int, description string) {
+	writeJSON(w, code, tgbotapi.APIResponse{Ok: false, ErrorCode: code, Description: description})
+}
+
+// writeJSON writes response body as JSON
+func writeJSON(w http.ResponseWriter, status int, body tgbotapi.APIResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
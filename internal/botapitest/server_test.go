@@ -0,0 +1,177 @@
+package botapitest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tlgbot/internal/messenger"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const testUserID = int64(42)
+
+func newTestMessenger(t *testing.T) (*Server, *messenger.Telegram) {
+	t.Helper()
+
+	server := NewServer()
+	t.Cleanup(server.Close)
+
+	api, err := server.NewBotAPI()
+	if err != nil {
+		t.Fatalf("Failed to create API client: %v", err)
+	}
+	if api.Self.UserName != "test_bot" {
+		t.Errorf("Expected getMe to return test_bot, got %q", api.Self.UserName)
+	}
+	return server, messenger.NewTelegram(api, nil)
+}
+
+func TestServerRecordsRequests(t *testing.T) {
+	server, m := newTestMessenger(t)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Yes", "yes"),
+			tgbotapi.NewInlineKeyboardButtonURL("Site", "https://example.com"),
+		),
+	)
+	messageID, err := m.SendText(testUserID, "Continue?", keyboard)
+	if err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+
+	edited := tgbotapi.NewInlineKeyboardMarkup()
+	if err := m.EditMessageText(testUserID, messageID, "Done", &edited); err != nil {
+		t.Fatalf("Failed to edit message: %v", err)
+	}
+	if err := m.AnswerCallback("7", ""); err != nil {
+		t.Fatalf("Failed to answer callback: %v", err)
+	}
+	if err := m.SendChatAction(testUserID, tgbotapi.ChatTyping); err != nil {
+		t.Fatalf("Failed to send chat action: %v", err)
+	}
+
+	requests := server.Requests()
+	methods := make([]string, len(requests))
+	for i, req := range requests {
+		methods[i] = req.Method
+	}
+	if got := strings.Join(methods, ","); got != "sendMessage,editMessageText,answerCallbackQuery,sendChatAction" {
+		t.Errorf("Unexpected requests: %s", got)
+	}
+	if requests[1].MessageID != messageID {
+		t.Errorf("Expected edit of message %d, got %d", messageID, requests[1].MessageID)
+	}
+
+	expected := []string{
+		"bot: Continue?\n  [Yes] [Site](https://example.com)",
+		"bot: <edit #1> Done",
+	}
+	if got := server.Transcript(testUserID); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected transcript %q, got %q", expected, got)
+	}
+}
+
+func TestServerUploads(t *testing.T) {
+	server, m := newTestMessenger(t)
+
+	dir := t.TempDir()
+	paths := make([]string, 2)
+	for i, name := range []string{"a.jpg", "b.jpg"} {
+		paths[i] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[i], []byte("image"), 0o600); err != nil {
+			t.Fatalf("Failed to write image: %v", err)
+		}
+	}
+
+	if err := m.SendPhoto(testUserID, paths[0]); err != nil {
+		t.Fatalf("Failed to send photo: %v", err)
+	}
+	if err := m.SendMediaGroup(testUserID, paths); err != nil {
+		t.Fatalf("Failed to send album: %v", err)
+	}
+	if err := m.SendDocument(testUserID, "report.csv", []byte("a,b")); err != nil {
+		t.Fatalf("Failed to send document: %v", err)
+	}
+
+	expected := []string{"bot: <photo a.jpg>", "bot: <album a.jpg, b.jpg>", "bot: <document report.csv>"}
+	if got := server.Transcript(testUserID); strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected transcript %q, got %q", expected, got)
+	}
+}
+
+func TestServerFailNext(t *testing.T) {
+	server, m := newTestMessenger(t)
+	server.FailNext("sendMessage", 403, "Forbidden: bot was blocked by the user")
+
+	_, err := m.SendText(testUserID, "Hello", nil)
+	if err == nil || !strings.Contains(err.Error(), "blocked") {
+		t.Fatalf("Expected scripted failure, got %v", err)
+	}
+	if _, err := m.SendText(testUserID, "Hello again", nil); err != nil {
+		t.Errorf("Expected only the next call to fail, got %v", err)
+	}
+	if got := server.Transcript(testUserID); len(got) != 1 || got[0] != "bot: Hello again" {
+		t.Errorf("Expected failed message to be left out of transcript, got %q", got)
+	}
+}
+
+func TestServerUpdates(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	api, err := server.NewBotAPI()
+	if err != nil {
+		t.Fatalf("Failed to create API client: %v", err)
+	}
+
+	server.SetUser(tgbotapi.User{ID: testUserID, FirstName: "Ann", UserName: "ann"})
+	server.SendText(testUserID, "/start promo")
+	server.SendText(testUserID, "hello")
+
+	updates, err := api.GetUpdates(tgbotapi.UpdateConfig{})
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("Expected 2 updates, got %d", len(updates))
+	}
+
+	command := updates[0].Message
+	if !command.IsCommand() || command.Command() != "start" || command.CommandArguments() != "promo" {
+		t.Errorf("Expected /start command with arguments, got %+v", command)
+	}
+	if command.From.FirstName != "Ann" || command.Chat.ID != testUserID {
+		t.Errorf("Expected message from registered user, got %+v", command.From)
+	}
+
+	updates, err = api.GetUpdates(tgbotapi.UpdateConfig{Offset: updates[1].UpdateID + 1})
+	if err != nil {
+		t.Fatalf("Failed to get updates: %v", err)
+	}
+	if len(updates) != 0 || server.PendingUpdates() != 0 {
+		t.Errorf("Expected confirmed updates to be dropped, got %d pending", server.PendingUpdates())
+	}
+}
+
+func TestPressButtonRequiresKeyboard(t *testing.T) {
+	server, m := newTestMessenger(t)
+
+	if err := server.PressButton(testUserID, "Yes"); err == nil {
+		t.Error("Expected error without keyboard")
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Yes", "yes")))
+	if _, err := m.SendText(testUserID, "Sure?", keyboard); err != nil {
+		t.Fatalf("Failed to send message: %v", err)
+	}
+	if err := server.PressButton(testUserID, "No"); err == nil {
+		t.Error("Expected error for missing button")
+	}
+	if err := server.PressButton(testUserID, "Yes"); err != nil {
+		t.Errorf("Expected button press, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package botapitest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sides of a conversation
+const (
+	FromUser = "user"
+	FromBot  = "bot"
+)
+
+// Button is a keyboard button sent by the bot
+type Button struct {
+	Text string
+	Data string
+	URL  string
+}
+
+// String formats button as [text] or [text](url)
+func (b Button) String() string {
+	if b.URL != "" {
+		return fmt.Sprintf("[%s](%s)", b.Text, b.URL)
+	}
+	return "[" + b.Text + "]"
+}
+
+// Entry is a single step of a conversation
+type Entry struct {
+	From      string
+	ChatID    int64
+	MessageID int
+	Method    string
+	Text      string
+	Button    string
+	Buttons   [][]Button
+	Files     []string
+}
+
+// String formats entry as a transcript line.
+// Keyboards follow the text, one row per line.
+func (e Entry) String() string {
+	if e.From == FromUser {
+		if e.Button != "" {
+			return "user: [" + e.Button + "]"
+		}
+		return "user: " + e.Text
+	}
+
+	var sb strings.Builder
+	sb.WriteString("bot: ")
+	switch e.Method {
+	case "sendPhoto":
+		fmt.Fprintf(&sb, "<photo %s>", strings.Join(e.Files, ", "))
+	case "sendMediaGroup":
+		fmt.Fprintf(&sb, "<album %s>", strings.Join(e.Files, ", "))
+	case "sendDocument":
+		fmt.Fprintf(&sb, "<document %s>", strings.Join(e.Files, ", "))
+	case "editMessageText", "editMessageReplyMarkup":
+		fmt.Fprintf(&sb, "<edit #%d> %s", e.MessageID, e.Text)
+	default:
+		sb.WriteString(e.Text)
+	}
+
+	for _, row := range e.Buttons {
+		buttons := make([]string, len(row))
+		for i, button := range row {
+			buttons[i] = button.String()
+		}
+		sb.WriteString("\n  " + strings.Join(buttons, " "))
+	}
+	return sb.String()
+}
+
+// entryFromRequest converts bot request into transcript entry
+func entryFromRequest(req Request, r *http.Request) Entry {
+	return Entry{
+		From:      FromBot,
+		ChatID:    req.ChatID,
+		MessageID: req.MessageID,
+		Method:    req.Method,
+		Text:      req.Text,
+		Buttons:   parseButtons(r.FormValue("reply_markup")),
+		Files:     req.Files,
+	}
+}
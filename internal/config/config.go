@@ -2,118 +2,202 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
 	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
 
 	"tlgbot/internal/models"
-
-	"github.com/joho/godotenv"
 )
 
 // Constants for environment variables
 const (
-	EnvTelegramToken     = "TELEGRAM_TOKEN"
-	EnvGoogleCreds       = "GOOGLE_CREDS"
-	EnvSheetID           = "SHEET_ID"
-	EnvDelayMs           = "DELAY_MS"
-	EnvStartQuestionID   = "START_QUESTION_ID"
-	EnvQuestionsFilePath = "QUESTIONS_FILE_PATH"
+	EnvTelegramToken      = "TELEGRAM_TOKEN"
+	EnvGoogleCreds        = "GOOGLE_CREDS"
+	EnvSheetID            = "SHEET_ID"
+	EnvDelayMs            = "DELAY_MS"
+	EnvStartQuestionID    = "START_QUESTION_ID"
+	EnvQuestionsFilePath  = "QUESTIONS_FILE_PATH"
+	EnvAdminChatID        = "ADMIN_CHAT_ID"
+	EnvAdminUserIDs       = "ADMIN_USER_IDS"
+	EnvSurveysDir         = "SURVEYS_DIR"
+	EnvHTTPAddr           = "HTTP_ADDR"
+	EnvLogLevel           = "LOG_LEVEL"
+	EnvLogFormat          = "LOG_FORMAT"
+	EnvReminderAfter      = "REMINDER_AFTER"
+	EnvReminderInterval   = "REMINDER_INTERVAL"
+	EnvReminderLimit      = "REMINDER_LIMIT"
+	EnvReminderText       = "REMINDER_TEXT"
+	EnvSessionTimeout     = "SESSION_TIMEOUT"
+	EnvExpiredText        = "EXPIRED_TEXT"
+	EnvStateDir           = "STATE_DIR"
+	EnvWebhookURL         = "WEBHOOK_URL"
+	EnvWebhookSecret      = "WEBHOOK_SECRET"
+	EnvWebhookMaxAttempts = "WEBHOOK_MAX_ATTEMPTS"
+	EnvWebhookDeadLetter  = "WEBHOOK_DEAD_LETTER"
+	EnvWebhookAnswers     = "WEBHOOK_ANSWERS"
+	EnvOutboxDir          = "OUTBOX_DIR"
+	EnvConfigFile         = "CONFIG_FILE"
+
+	// FileEnvSuffix marks variables holding path to a file with the setting value, e.g. TELEGRAM_TOKEN_FILE
+	FileEnvSuffix = "_FILE"
 )
 
 // Default values
 const (
-	DefaultGoogleCreds       = "google-credentials.json"
-	DefaultSheetID           = "YOUR_GOOGLE_SHEET_ID"
-	DefaultDelayMs           = 700
-	DefaultStartQuestionID   = "start"
-	DefaultQuestionsFilePath = "configs/questions.json"
+	DefaultGoogleCreds        = "google-credentials.json"
+	DefaultSheetID            = "YOUR_GOOGLE_SHEET_ID"
+	DefaultDelayMs            = 700
+	DefaultStartQuestionID    = "start"
+	DefaultQuestionsFilePath  = "configs/questions.json"
+	DefaultLogLevel           = "info"
+	DefaultLogFormat          = "text"
+	DefaultConfigFile         = "config.json"
+	DefaultReminderLimit      = 3
+	DefaultWebhookMaxAttempts = 5
 )
 
-// LoadFromEnv loads configuration from environment variables
-// First tries to load .env file, then falls back to config.json if required variables are missing
-func LoadFromEnv() (*models.Config, error) {
-	// Try to load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		// It's okay if .env file doesn't exist, just log for debugging
-		log.Printf("No .env file found or error loading it: %v", err)
+// LoadFromFile loads configuration from file only, without defaults
+func LoadFromFile(path string) (*models.Config, error) {
+	config := &models.Config{}
+	if err := readConfigFile(path, config); err != nil {
+		return nil, err
 	}
+	return config, nil
+}
 
-	config := &models.Config{}
-	var err error
+// readConfigFile decodes config file over existing values; fields missing in the file are kept
+func readConfigFile(path string, config *models.Config) error {
+	if path == "" {
+		return fmt.Errorf("config file path cannot be empty")
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: Config file path is controlled by application
+	if err != nil {
+		return fmt.Errorf("failed to open config file %s: %w", path, err)
+	}
+
+	// Unknown fields are rejected so typos do not silently drop settings
+	if err := decodeJSONStrict(data, config); err != nil {
+		return fmt.Errorf("failed to decode config from %s: %w", path, err)
+	}
+
+	return nil
+}
 
-	// Get required Telegram token
-	config.TelegramToken = os.Getenv(EnvTelegramToken)
-	if config.TelegramToken == "" {
-		// Fallback to config.json if TELEGRAM_TOKEN is not set
-		log.Println("TELEGRAM_TOKEN not found in environment variables, trying to load from config.json")
-		return LoadFromFile("config.json")
+// LoadQuestions loads questions from a file or from all question files of a directory.
+// Files may be JSON, YAML or TOML selected by extension, other extensions are read as JSON.
+// A file holds a list of questions or an object with questions, includes of other files
+// and shared fragments and option sets.
+func LoadQuestions(filename string) (map[string]models.Question, error) {
+	if filename == "" {
+		return nil, fmt.Errorf("questions file path cannot be empty")
 	}
 
-	// Get optional parameters with default values
-	config.GoogleCreds = getEnvOrDefault(EnvGoogleCreds, DefaultGoogleCreds)
-	config.SheetID = getEnvOrDefault(EnvSheetID, DefaultSheetID)
-	config.StartQuestionID = getEnvOrDefault(EnvStartQuestionID, DefaultStartQuestionID)
-	config.QuestionsFilePath = getEnvOrDefault(EnvQuestionsFilePath, DefaultQuestionsFilePath)
+	loader := newQuestionLoader()
+	if err := loader.loadPath(filename); err != nil {
+		return nil, err
+	}
 
-	// Get delay with validation
-	config.DelayMs, err = getDelayFromEnv()
+	questions, err := loader.resolve()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse %s: %w", EnvDelayMs, err)
+		return nil, err
 	}
 
-	return config, nil
+	return buildQuestionMap(questions, filename)
 }
 
-// LoadFromFile loads configuration from file (fallback)
-func LoadFromFile(path string) (*models.Config, error) {
-	if path == "" {
-		return nil, fmt.Errorf("config file path cannot be empty")
+// LoadSurveys loads survey definitions from all JSON, YAML and TOML files in a directory.
+// Surveys without their own start question start at startQuestionID, the configured one.
+func LoadSurveys(dir, startQuestionID string) ([]models.Survey, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("surveys directory path cannot be empty")
 	}
 
-	file, err := os.Open(path) //nolint:gosec // G304: Config file path is controlled by application
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open config file %s: %w", path, err)
+		return nil, fmt.Errorf("failed to read surveys directory %s: %w", dir, err)
 	}
-	defer func() {
-		if closeErr := file.Close(); closeErr != nil {
-			log.Printf("Failed to close config file: %v", closeErr)
+
+	surveys := make([]models.Survey, 0, len(entries))
+	seen := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, err := FormatFromPath(entry.Name()); err != nil {
+			continue
 		}
-	}()
 
-	config := &models.Config{}
-	decoder := json.NewDecoder(file)
+		path := filepath.Join(dir, entry.Name())
+		survey, err := loadSurvey(path, startQuestionID)
+		if err != nil {
+			return nil, err
+		}
 
-	if err := decoder.Decode(config); err != nil {
-		return nil, fmt.Errorf("failed to decode config from %s: %w", path, err)
+		if other, exists := seen[survey.ID]; exists {
+			return nil, fmt.Errorf("duplicate survey ID %s in %s and %s", survey.ID, other, path)
+		}
+		seen[survey.ID] = path
+		surveys = append(surveys, survey)
 	}
 
-	return config, nil
+	if len(surveys) == 0 {
+		return nil, fmt.Errorf("no surveys found in %s", dir)
+	}
+
+	return surveys, nil
 }
 
-// LoadQuestions loads questions from JSON file
-func LoadQuestions(filename string) (map[string]models.Question, error) {
-	if filename == "" {
-		return nil, fmt.Errorf("questions file path cannot be empty")
+// loadSurvey loads a single survey definition.
+// The file may contain a survey object or a plain array of questions;
+// missing survey ID is taken from the file name. Questions of included files belong to the survey.
+// Missing start question is left empty, so the survey follows the configured one.
+func loadSurvey(path, defaultStartQuestionID string) (models.Survey, error) {
+	if _, err := FormatFromPath(path); err != nil {
+		return models.Survey{}, err
 	}
 
-	data, err := os.ReadFile(filename) //nolint:gosec // G304: Questions file path is controlled by application
+	loader := newQuestionLoader()
+	loader.allowSurvey = true
+	file, err := loader.loadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read questions file %s: %w", filename, err)
+		return models.Survey{}, err
+	}
+
+	survey := file.survey()
+	if survey.Questions, err = loader.resolve(); err != nil {
+		return survey, err
 	}
 
-	var questions []models.Question
-	if err := json.Unmarshal(data, &questions); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal questions from %s: %w", filename, err)
+	if survey.ID == "" {
+		survey.ID = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	startQuestionID := survey.StartQuestionID
+	if startQuestionID == "" {
+		startQuestionID = defaultStartQuestionID
+	}
+	if startQuestionID == "" {
+		startQuestionID = DefaultStartQuestionID
 	}
 
+	qMap, err := buildQuestionMap(survey.Questions, path)
+	if err != nil {
+		return survey, err
+	}
+	if _, exists := qMap[startQuestionID]; !exists {
+		return survey, fmt.Errorf("start question %s not found in %s", startQuestionID, path)
+	}
+
+	return survey, nil
+}
+
+// buildQuestionMap converts questions to map and validates IDs
+func buildQuestionMap(questions []models.Question, source string) (map[string]models.Question, error) {
 	if len(questions) == 0 {
-		return nil, fmt.Errorf("no questions found in %s", filename)
+		return nil, fmt.Errorf("no questions found in %s", source)
 	}
 
-	// Convert to map and validate
 	qMap := make(map[string]models.Question, len(questions))
 	for i := range questions {
 		q := &questions[i]
@@ -130,30 +214,3 @@ func LoadQuestions(filename string) (map[string]models.Question, error) {
 
 	return qMap, nil
 }
-
-// getEnvOrDefault returns environment variable value or default value
-func getEnvOrDefault(envVar, defaultValue string) string {
-	if value := os.Getenv(envVar); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-// getDelayFromEnv gets and validates delay from environment variable
-func getDelayFromEnv() (int, error) {
-	delayStr := os.Getenv(EnvDelayMs)
-	if delayStr == "" {
-		return DefaultDelayMs, nil
-	}
-
-	delay, err := strconv.Atoi(delayStr)
-	if err != nil {
-		return 0, fmt.Errorf("invalid delay value %s: %w", delayStr, err)
-	}
-
-	if delay < 0 {
-		return 0, fmt.Errorf("delay cannot be negative: %d", delay)
-	}
-
-	return delay, nil
-}
@@ -2,9 +2,9 @@ package config
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"tlgbot/internal/models"
@@ -12,125 +12,6 @@ import (
 
 const expectedNoErrorMsg = "Expected no error, got %v"
 
-func TestLoadFromEnvSuccess(t *testing.T) {
-	// Save original values
-	originalToken := os.Getenv(EnvTelegramToken)
-	originalDelay := os.Getenv(EnvDelayMs)
-
-	// Set test values
-	if err := os.Setenv(EnvTelegramToken, "test_token_123"); err != nil {
-		t.Fatalf("Failed to set environment variable: %v", err)
-	}
-	if err := os.Setenv(EnvDelayMs, "1000"); err != nil {
-		t.Fatalf("Failed to set environment variable: %v", err)
-	}
-
-	// Restore after test
-	defer func() {
-		if originalToken == "" {
-			if err := os.Unsetenv(EnvTelegramToken); err != nil {
-				t.Logf("Failed to unset environment variable: %v", err)
-			}
-		} else {
-			if err := os.Setenv(EnvTelegramToken, originalToken); err != nil {
-				t.Logf("Failed to restore environment variable: %v", err)
-			}
-		}
-		if originalDelay == "" {
-			if err := os.Unsetenv(EnvDelayMs); err != nil {
-				t.Logf("Failed to unset environment variable: %v", err)
-			}
-		} else {
-			if err := os.Setenv(EnvDelayMs, originalDelay); err != nil {
-				t.Logf("Failed to restore environment variable: %v", err)
-			}
-		}
-	}()
-
-	config, err := LoadFromEnv()
-	if err != nil {
-		t.Errorf(expectedNoErrorMsg, err)
-	}
-
-	if config.TelegramToken != "test_token_123" {
-		t.Errorf("Expected token 'test_token_123', got %s", config.TelegramToken)
-	}
-
-	if config.DelayMs != 1000 {
-		t.Errorf("Expected delay 1000, got %d", config.DelayMs)
-	}
-
-	// Check default values
-	if config.GoogleCreds != DefaultGoogleCreds {
-		t.Errorf("Expected default GoogleCreds %s, got %s", DefaultGoogleCreds, config.GoogleCreds)
-	}
-}
-
-func TestLoadFromEnvMissingToken(t *testing.T) {
-	// Save original value
-	originalToken := os.Getenv(EnvTelegramToken)
-
-	// Remove token
-	if err := os.Unsetenv(EnvTelegramToken); err != nil {
-		t.Fatalf("Failed to unset environment variable: %v", err)
-	}
-
-	// Restore after test
-	defer func() {
-		if originalToken != "" {
-			if err := os.Setenv(EnvTelegramToken, originalToken); err != nil {
-				t.Logf("Failed to restore environment variable: %v", err)
-			}
-		}
-	}()
-
-	_, err := LoadFromEnv()
-	if err == nil {
-		t.Error("Expected error for missing token")
-	}
-}
-
-func TestLoadFromEnvInvalidDelay(t *testing.T) {
-	// Save original values
-	originalToken := os.Getenv(EnvTelegramToken)
-	originalDelay := os.Getenv(EnvDelayMs)
-
-	// Set test values
-	if err := os.Setenv(EnvTelegramToken, "test_token"); err != nil {
-		t.Fatalf("Failed to set environment variable: %v", err)
-	}
-	if err := os.Setenv(EnvDelayMs, "invalid_delay"); err != nil {
-		t.Fatalf("Failed to set environment variable: %v", err)
-	}
-
-	// Restore after test
-	defer func() {
-		if originalToken == "" {
-			if err := os.Unsetenv(EnvTelegramToken); err != nil {
-				t.Logf("Failed to unset environment variable: %v", err)
-			}
-		} else {
-			if err := os.Setenv(EnvTelegramToken, originalToken); err != nil {
-				t.Logf("Failed to restore environment variable: %v", err)
-			}
-		}
-		if originalDelay == "" {
-			if err := os.Unsetenv(EnvDelayMs); err != nil {
-				t.Logf("Failed to unset environment variable: %v", err)
-			}
-		} else {
-			if err := os.Setenv(EnvDelayMs, originalDelay); err != nil {
-				t.Logf("Failed to restore environment variable: %v", err)
-			}
-		}
-	}()
-
-	_, err := LoadFromEnv()
-	if err == nil {
-		t.Error("Expected error for invalid delay")
-	}
-}
-
 func TestLoadFromFileSuccess(t *testing.T) {
 	// Create temporary config file
 	tmpDir := t.TempDir()
@@ -261,69 +142,108 @@ func TestLoadQuestionsInvalidCases(t *testing.T) {
 	}
 }
 
-func TestGetDelayFromEnv(t *testing.T) {
-	originalDelay := os.Getenv(EnvDelayMs)
-	defer restoreEnvVar(EnvDelayMs, originalDelay)
-
-	tests := []struct {
-		name        string
-		envValue    string
-		expected    int
-		expectError bool
-	}{
-		{"default value", "", DefaultDelayMs, false},
-		{"valid positive", "1000", 1000, false},
-		{"valid zero", "0", 0, false},
-		{"invalid string", "not_a_number", 0, true},
-		{"negative value", "-100", 0, true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			setEnvVar(EnvDelayMs, tt.envValue)
-			result, err := getDelayFromEnv()
-			assertDelayResult(t, result, err, tt.expected, tt.expectError)
-		})
-	}
-}
+func TestLoadSurveys(t *testing.T) {
+	dir := t.TempDir()
 
-func setEnvVar(key, value string) {
-	var err error
-	if value == "" {
-		err = os.Unsetenv(key)
-	} else {
-		err = os.Setenv(key, value)
+	files := map[string]string{
+		"spring.json": `{"id": "spring_campaign", "title": "Spring", "start_question_id": "intro",
+			"questions": [{"id": "intro", "text": "Hi"}]}`,
+		"legacy.json": `[{"id": "start", "text": "Hello"}]`,
+		"notes.txt":   "not a survey",
 	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	surveys, err := LoadSurveys(dir, DefaultStartQuestionID)
 	if err != nil {
-		// In test context, we might want to log this but not fail
-		// since this is a helper function used in table tests
-		panic(fmt.Sprintf("Failed to set environment variable %s: %v", key, err))
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+
+	if len(surveys) != 2 {
+		t.Fatalf("Expected 2 surveys, got %d", len(surveys))
+	}
+
+	// Files are read in name order
+	if surveys[0].ID != "legacy" || surveys[0].StartQuestionID != "" {
+		t.Errorf("Expected survey ID from file name without own start question, got %+v", surveys[0])
+	}
+	if surveys[1].ID != "spring_campaign" || surveys[1].Title != "Spring" || surveys[1].StartQuestionID != "intro" {
+		t.Errorf("Unexpected survey: %+v", surveys[1])
 	}
 }
 
-func restoreEnvVar(key, originalValue string) {
-	var err error
-	if originalValue == "" {
-		err = os.Unsetenv(key)
-	} else {
-		err = os.Setenv(key, originalValue)
+func TestLoadSurveysConfiguredStartQuestion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "welcome.json"), []byte(`[{"id": "welcome", "text": "Hello"}]`), 0o600); err != nil {
+		t.Fatalf("Failed to write survey: %v", err)
 	}
+
+	// The survey follows the configured start question instead of the default one
+	surveys, err := LoadSurveys(dir, "welcome")
 	if err != nil {
-		// In cleanup context, we might want to log this but not fail
-		panic(fmt.Sprintf("Failed to restore environment variable %s: %v", key, err))
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+	if len(surveys) != 1 || surveys[0].StartQuestionID != "" {
+		t.Errorf("Expected survey to inherit the start question, got %+v", surveys)
+	}
+
+	if _, err := LoadSurveys(dir, DefaultStartQuestionID); err == nil {
+		t.Error("Expected error when the configured start question is missing")
 	}
 }
 
-func assertDelayResult(t *testing.T, result int, err error, expected int, expectError bool) {
-	if expectError && err == nil {
-		t.Error("Expected error but got none")
-		return
+func TestLoadSurveysInvalidCases(t *testing.T) {
+	if _, err := LoadSurveys("", DefaultStartQuestionID); err == nil {
+		t.Error("Expected error for empty path")
+	}
+	if _, err := LoadSurveys("/nonexistent/surveys", DefaultStartQuestionID); err == nil {
+		t.Error("Expected error for nonexistent directory")
+	}
+	if _, err := LoadSurveys(t.TempDir(), DefaultStartQuestionID); err == nil {
+		t.Error("Expected error for directory without surveys")
+	}
+
+	tests := map[string]map[string]string{
+		"missing start question": {
+			"a.json": `{"id": "a", "start_question_id": "intro", "questions": [{"id": "start"}]}`,
+		},
+		"duplicate survey ID": {
+			"a.json": `{"id": "same", "questions": [{"id": "start"}]}`,
+			"b.json": `{"id": "same", "questions": [{"id": "start"}]}`,
+		},
+		"invalid JSON": {
+			"a.json": `{"id": `,
+		},
+	}
+
+	for name, files := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			for fileName, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, fileName), []byte(content), 0o600); err != nil {
+					t.Fatalf("Failed to write %s: %v", fileName, err)
+				}
+			}
+
+			if _, err := LoadSurveys(dir, DefaultStartQuestionID); err == nil {
+				t.Error("Expected error")
+			}
+		})
 	}
-	if !expectError && err != nil {
-		t.Errorf("Expected no error but got: %v", err)
-		return
+}
+
+func TestLoadFromFileUnknownField(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	content := "{\n  \"telegram_token\": \"token\",\n  \"delay\": 500\n}"
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
 	}
-	if !expectError && result != expected {
-		t.Errorf("Expected %d, got %d", expected, result)
+
+	_, err := LoadFromFile(configPath)
+	if err == nil || !strings.Contains(err.Error(), `line 3, column 3: unknown field "delay"`) {
+		t.Errorf("Expected unknown field error with position, got %v", err)
 	}
 }
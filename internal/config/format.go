@@ -0,0 +1,243 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"tlgbot/internal/models"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Question file formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// formatExtensions maps file extensions to question file formats
+var formatExtensions = map[string]string{
+	".json": FormatJSON,
+	".yaml": FormatYAML,
+	".yml":  FormatYAML,
+	".toml": FormatTOML,
+}
+
+// FormatFromPath detects question file format by file extension
+func FormatFromPath(path string) (string, error) {
+	format, ok := formatExtensions[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "", fmt.Errorf("unsupported file extension %q, expected .json, .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	return format, nil
+}
+
+// questionFile is a question file in object form: a survey definition or questions
+// with includes and shared fragments and option sets
+type questionFile struct {
+	// Schema is the JSON Schema reference editors use for validation and autocomplete
+	Schema          string                     `json:"$schema,omitempty" yaml:"$schema,omitempty" toml:"$schema,omitempty"`
+	ID              string                     `json:"id,omitempty" yaml:"id,omitempty" toml:"id,omitempty"`
+	Title           string                     `json:"title,omitempty" yaml:"title,omitempty" toml:"title,omitempty"`
+	StartQuestionID string                     `json:"start_question_id,omitempty" yaml:"start_question_id,omitempty" toml:"start_question_id,omitempty"`
+	Include         []string                   `json:"include,omitempty" yaml:"include,omitempty" toml:"include,omitempty"`
+	Fragments       map[string]string          `json:"fragments,omitempty" yaml:"fragments,omitempty" toml:"fragments,omitempty"`
+	OptionSets      map[string][]models.Option `json:"option_sets,omitempty" yaml:"option_sets,omitempty" toml:"option_sets,omitempty"`
+	Questions       []models.Question          `json:"questions,omitempty" yaml:"questions,omitempty" toml:"questions,omitempty"`
+}
+
+// isSurvey checks if the file defines survey metadata
+func (f *questionFile) isSurvey() bool {
+	return f.ID != "" || f.Title != "" || f.StartQuestionID != ""
+}
+
+// hasShared checks if the file includes other files or defines fragments or option sets
+func (f *questionFile) hasShared() bool {
+	return len(f.Include) > 0 || len(f.Fragments) > 0 || len(f.OptionSets) > 0
+}
+
+// survey returns survey definition with questions of this file only
+func (f *questionFile) survey() models.Survey {
+	return models.Survey{ID: f.ID, Title: f.Title, StartQuestionID: f.StartQuestionID, Questions: f.Questions}
+}
+
+// document is the content of a question file: an object or a plain list of questions
+type document struct {
+	file questionFile
+	// plain is set for files holding only the questions list
+	plain bool
+}
+
+// decodeDocument decodes question file object or plain question list in the given format.
+// TOML has no top-level arrays, so a TOML questions file is a table with a questions key.
+// Unknown fields are rejected so typos do not silently drop settings.
+func decodeDocument(data []byte, format string) (document, error) {
+	var doc document
+	var err error
+
+	switch format {
+	case FormatJSON:
+		trimmed := bytes.TrimSpace(data)
+		doc.plain = len(trimmed) > 0 && trimmed[0] == '['
+		if doc.plain {
+			err = decodeJSONStrict(data, &doc.file.Questions)
+		} else {
+			err = decodeJSONStrict(data, &doc.file)
+		}
+	case FormatYAML:
+		var root yaml.Node
+		if err = yaml.Unmarshal(data, &root); err != nil {
+			break
+		}
+		if len(root.Content) == 0 {
+			err = errors.New("empty input")
+			break
+		}
+		doc.plain = root.Content[0].Kind == yaml.SequenceNode
+		if doc.plain {
+			err = decodeYAMLStrict(data, &root, &doc.file.Questions)
+		} else {
+			err = decodeYAMLStrict(data, &root, &doc.file)
+		}
+	case FormatTOML:
+		err = decodeTOMLStrict(data, &doc.file)
+		doc.plain = !doc.file.isSurvey() && !doc.file.hasShared()
+	default:
+		return doc, fmt.Errorf("unsupported format %q", format)
+	}
+
+	return doc, err
+}
+
+// encodeDocument encodes question file object or plain question list in the given format
+func encodeDocument(doc document, format string) ([]byte, error) {
+	var value interface{} = doc.file
+	if doc.plain && format != FormatTOML {
+		value = doc.file.Questions
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(value); err != nil {
+			return nil, err
+		}
+	case FormatYAML:
+		return encodeYAML(value)
+	case FormatTOML:
+		encoder := toml.NewEncoder(&buf)
+		encoder.SetIndentTables(true)
+		if err := encoder.Encode(value); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Convert translates question or survey file content between formats.
+// Questions are validated the same way as on load; a plain question list stays a list where the format allows.
+// Includes, fragments and option sets are kept as references.
+func Convert(data []byte, from, to string) ([]byte, error) {
+	doc, err := decodeDocument(data, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", from, err)
+	}
+
+	// Files with shared definitions may hold no questions of their own
+	if len(doc.file.Questions) > 0 || !doc.file.hasShared() {
+		if _, err := buildQuestionMap(doc.file.Questions, "input"); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := encodeDocument(doc, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", to, err)
+	}
+	return out, nil
+}
+
+// encodeYAML encodes value as YAML with multiline strings in literal block style.
+// The encoder escapes characters outside the Basic Multilingual Plane, such as emoji, and then
+// cannot use block style, so they are swapped for unused private use characters while encoding.
+func encodeYAML(value interface{}) ([]byte, error) {
+	var root yaml.Node
+	if err := root.Encode(value); err != nil {
+		return nil, err
+	}
+
+	used := make(map[rune]bool)
+	walkScalars(&root, func(node *yaml.Node) {
+		for _, r := range node.Value {
+			used[r] = true
+		}
+	})
+
+	placeholders := make(map[rune]rune)
+	next := privateUseFirst
+	var restore []string
+	walkScalars(&root, func(node *yaml.Node) {
+		node.Value = strings.Map(func(r rune) rune {
+			if r <= 0xFFFF {
+				return r
+			}
+			if placeholder, ok := placeholders[r]; ok {
+				return placeholder
+			}
+			for next <= privateUseLast && used[next] {
+				next++
+			}
+			if next > privateUseLast {
+				return r // out of placeholders, leave the character escaped
+			}
+			placeholders[r] = next
+			restore = append(restore, string(next), string(r))
+			next++
+			return placeholders[r]
+		}, node.Value)
+
+		if node.Tag == "!!str" && strings.Contains(node.Value, "\n") {
+			node.Style = yaml.LiteralStyle
+		}
+	})
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.NewReplacer(restore...).Replace(buf.String())), nil
+}
+
+// Private use area of the Basic Multilingual Plane
+const (
+	privateUseFirst = '\uE000'
+	privateUseLast  = '\uF8FF'
+)
+
+// walkScalars calls fn for every scalar node of the tree
+func walkScalars(node *yaml.Node, fn func(node *yaml.Node)) {
+	if node.Kind == yaml.ScalarNode {
+		fn(node)
+	}
+	for _, child := range node.Content {
+		walkScalars(child, fn)
+	}
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Same questions in every supported format
+var formatSamples = map[string]string{
+	FormatJSON: `[
+		{"id": "start", "messages": ["Hi {name}! 👋", "Line one\nLine two"], "delay_ms": 0, "reminder_after": "30m",
+			"options": [{"text": "🎯 Go", "next_id": "end"}]},
+		{"id": "end", "text": "Bye", "terminal": true}
+	]`,
+	FormatYAML: `
+- id: start
+  messages:
+    - Hi {name}! 👋
+    - |-
+      Line one
+      Line two
+  delay_ms: 0
+  reminder_after: 30m
+  options:
+    - text: 🎯 Go
+      next_id: end
+- id: end
+  text: Bye
+  terminal: true
+`,
+	FormatTOML: `
+[[questions]]
+id = "start"
+messages = ["Hi {name}! 👋", """Line one
+Line two"""]
+delay_ms = 0
+reminder_after = "30m"
+
+[[questions.options]]
+text = "🎯 Go"
+next_id = "end"
+
+[[questions]]
+id = "end"
+text = "Bye"
+terminal = true
+`,
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path        string
+		expected    string
+		expectError bool
+	}{
+		{"questions.json", FormatJSON, false},
+		{"dir/questions.yaml", FormatYAML, false},
+		{"questions.YML", FormatYAML, false},
+		{"questions.toml", FormatTOML, false},
+		{"questions.txt", "", true},
+		{"questions", "", true},
+	}
+
+	for _, tt := range tests {
+		format, err := FormatFromPath(tt.path)
+		if (err != nil) != tt.expectError || format != tt.expected {
+			t.Errorf("FormatFromPath(%q) = %q, %v; expected %q", tt.path, format, err, tt.expected)
+		}
+	}
+}
+
+func TestLoadQuestionsFormats(t *testing.T) {
+	dir := t.TempDir()
+	var loaded []interface{}
+
+	for format, content := range formatSamples {
+		path := filepath.Join(dir, "questions."+format)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+
+		questions, err := LoadQuestions(path)
+		if err != nil {
+			t.Fatalf("Failed to load %s: %v", format, err)
+		}
+
+		start := questions["start"]
+		if len(start.Messages) != 2 || start.Messages[1] != "Line one\nLine two" ||
+			start.DelayMs == nil || start.GetReminderAfter(0) != 30*time.Minute || start.Options[0].Text != "🎯 Go" || !questions["end"].Terminal {
+			t.Errorf("Unexpected questions loaded from %s: %+v", format, questions)
+		}
+		loaded = append(loaded, questions)
+	}
+
+	for i := 1; i < len(loaded); i++ {
+		if !reflect.DeepEqual(loaded[0], loaded[i]) {
+			t.Error("Expected identical questions from every format")
+		}
+	}
+}
+
+func TestLoadQuestionsFormatErrors(t *testing.T) {
+	tests := map[string]string{
+		"questions.yaml":       "- id: start\n- id: start\n",
+		"questions.toml":       "id = \"survey\"\n[[questions]]\nid = \"start\"\n",
+		"survey.json":          `{"id": "survey", "questions": [{"id": "start"}]}`,
+		"survey.yaml":          "title: Survey\nquestions:\n  - id: start\n",
+		"broken.yaml":          "- id: [start\n",
+		"empty.yaml":           "",
+		"legacy-extension.txt": `{"id": "start"}`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatalf("Failed to write %s: %v", path, err)
+			}
+
+			if _, err := LoadQuestions(path); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestLoadSurveysFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yaml": "id: spring\nstart_question_id: intro\nquestions:\n  - id: intro\n    text: Hi\n",
+		"b.toml": "title = \"Autumn\"\n[[questions]]\nid = \"start\"\n",
+		"c.yml":  "- id: start\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	surveys, err := LoadSurveys(dir, DefaultStartQuestionID)
+	if err != nil {
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+
+	if len(surveys) != 3 {
+		t.Fatalf("Expected 3 surveys, got %d", len(surveys))
+	}
+	if surveys[0].ID != "spring" || surveys[0].StartQuestionID != "intro" {
+		t.Errorf("Unexpected YAML survey: %+v", surveys[0])
+	}
+	if surveys[1].ID != "b" || surveys[1].Title != "Autumn" {
+		t.Errorf("Unexpected TOML survey: %+v", surveys[1])
+	}
+	if surveys[2].ID != "c" || len(surveys[2].Questions) != 1 {
+		t.Errorf("Unexpected YAML question list: %+v", surveys[2])
+	}
+}
+
+func TestConvert(t *testing.T) {
+	formats := []string{FormatJSON, FormatYAML, FormatTOML}
+
+	for _, from := range formats {
+		for _, to := range formats {
+			t.Run(from+" to "+to, func(t *testing.T) {
+				converted, err := Convert([]byte(formatSamples[from]), from, to)
+				if err != nil {
+					t.Fatalf("Failed to convert: %v", err)
+				}
+
+				back, err := decodeDocument(converted, to)
+				if err != nil {
+					t.Fatalf("Failed to decode converted output: %v\n%s", err, converted)
+				}
+				original, _ := decodeDocument([]byte(formatSamples[from]), from)
+				if !reflect.DeepEqual(back.file, original.file) || !back.plain {
+					t.Errorf("Converted questions differ:\n%s", converted)
+				}
+			})
+		}
+	}
+}
+
+func TestConvertYAMLOutput(t *testing.T) {
+	converted, err := Convert([]byte(formatSamples[FormatJSON]), FormatJSON, FormatYAML)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+
+	output := string(converted)
+	for _, expected := range []string{"- id: start\n", "🎯 Go", "👋", "- |-\n      Line one\n      Line two\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected YAML output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestConvertSurveyObject(t *testing.T) {
+	survey := `{"id": "spring", "title": "Spring", "questions": [{"id": "start", "text": "Hi"}]}`
+
+	converted, err := Convert([]byte(survey), FormatJSON, FormatYAML)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+
+	expected := "id: spring\ntitle: Spring\nquestions:\n  - id: start\n    text: Hi\n"
+	if string(converted) != expected {
+		t.Errorf("Expected survey object, got:\n%s", converted)
+	}
+}
+
+func TestConvertInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		from string
+		to   string
+	}{
+		{"duplicate IDs", `[{"id": "a"}, {"id": "a"}]`, FormatJSON, FormatYAML},
+		{"empty ID", `[{"text": "no id"}]`, FormatJSON, FormatYAML},
+		{"broken input", `[{"id": `, FormatJSON, FormatYAML},
+		{"unknown input format", `[]`, "xml", FormatYAML},
+		{"unknown output format", `[{"id": "a"}]`, FormatJSON, "xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Convert([]byte(tt.data), tt.from, tt.to); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestConvertKeepsReferences(t *testing.T) {
+	shared := `{"include": ["parts/*.yaml"], "fragments": {"disclaimer": "Anonymous"},
+		"option_sets": {"yes_no": [{"text": "Yes"}, {"text": "No"}]}}`
+
+	converted, err := Convert([]byte(shared), FormatJSON, FormatYAML)
+	if err != nil {
+		t.Fatalf("Failed to convert: %v", err)
+	}
+
+	expected := "include:\n  - parts/*.yaml\nfragments:\n  disclaimer: Anonymous\noption_sets:\n  yes_no:\n    - text: \"Yes\"\n    - text: \"No\"\n"
+	if string(converted) != expected {
+		t.Errorf("Expected references kept, got:\n%s", converted)
+	}
+}
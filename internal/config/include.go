@@ -0,0 +1,300 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"tlgbot/internal/models"
+)
+
+// fragmentPattern matches {{key}} references to shared message fragments
+var fragmentPattern = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+
+// questionLoader reads questions split across files and directories.
+// Questions, fragments and option sets from all files share one namespace.
+type questionLoader struct {
+	questions  []models.Question
+	fragments  map[string]string
+	optionSets map[string][]models.Option
+
+	// sources map question IDs and shared keys to files defining them
+	questionSources map[string]string
+	fragmentSources map[string]string
+	setSources      map[string]string
+
+	loaded map[string]bool
+	// allowSurvey permits survey metadata in the next loaded file, which is the survey definition
+	allowSurvey bool
+	// including holds files being loaded to detect include cycles
+	including []string
+}
+
+// newQuestionLoader creates an empty loader
+func newQuestionLoader() *questionLoader {
+	return &questionLoader{
+		fragments:       make(map[string]string),
+		optionSets:      make(map[string][]models.Option),
+		questionSources: make(map[string]string),
+		fragmentSources: make(map[string]string),
+		setSources:      make(map[string]string),
+		loaded:          make(map[string]bool),
+	}
+}
+
+// loadPath loads a question file or all question files of a directory in name order
+func (l *questionLoader) loadPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to read questions file %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		_, err := l.loadFile(path)
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("failed to read questions directory %s: %w", path, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, err := FormatFromPath(entry.Name()); err != nil {
+			continue
+		}
+		if _, err := l.loadFile(filepath.Join(path, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadFile loads a question file and its includes and returns its content.
+// A file reached again through another include is skipped.
+func (l *questionLoader) loadFile(path string) (questionFile, error) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = filepath.Clean(path)
+	}
+	for _, including := range l.including {
+		if including == key {
+			return questionFile{}, fmt.Errorf("include cycle: %s", strings.Join(append(l.including, key), " -> "))
+		}
+	}
+	if l.loaded[key] {
+		return questionFile{}, nil
+	}
+	l.loaded[key] = true
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: Questions file path is controlled by application
+	if err != nil {
+		return questionFile{}, fmt.Errorf("failed to read questions file %s: %w", path, err)
+	}
+
+	// Files with unknown extensions are read as JSON for compatibility
+	format, err := FormatFromPath(path)
+	if err != nil {
+		format = FormatJSON
+	}
+
+	doc, err := decodeDocument(data, format)
+	if err != nil {
+		return questionFile{}, fmt.Errorf("failed to unmarshal questions from %s: %w", path, err)
+	}
+	file := doc.file
+
+	allowSurvey := l.allowSurvey
+	l.allowSurvey = false
+	if file.isSurvey() && !allowSurvey {
+		return file, fmt.Errorf("%s: survey ID, title and start question are only allowed in survey definitions", path)
+	}
+
+	if err := l.add(file, path); err != nil {
+		return file, err
+	}
+
+	l.including = append(l.including, key)
+	defer func() { l.including = l.including[:len(l.including)-1] }()
+
+	for _, pattern := range file.Include {
+		if err := l.include(pattern, path); err != nil {
+			return file, err
+		}
+	}
+	return file, nil
+}
+
+// include loads files matching the pattern relative to the including file
+func (l *questionLoader) include(pattern, from string) error {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(from), pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid include %s in %s: %w", pattern, from, err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("include %s in %s matched no files", pattern, from)
+	}
+
+	for _, match := range matches {
+		if err := l.loadPath(match); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add registers questions and shared definitions of a file, rejecting duplicates across files
+func (l *questionLoader) add(file questionFile, source string) error {
+	for key, fragment := range file.Fragments {
+		if other, exists := l.fragmentSources[key]; exists {
+			return fmt.Errorf("duplicate fragment %s in %s and %s", key, other, source)
+		}
+		l.fragmentSources[key] = source
+		l.fragments[key] = fragment
+	}
+
+	for key, options := range file.OptionSets {
+		if other, exists := l.setSources[key]; exists {
+			return fmt.Errorf("duplicate option set %s in %s and %s", key, other, source)
+		}
+		l.setSources[key] = source
+		l.optionSets[key] = options
+	}
+
+	for i, question := range file.Questions {
+		if question.ID == "" {
+			return fmt.Errorf("question at index %d in %s has empty ID", i, source)
+		}
+		if other, exists := l.questionSources[question.ID]; exists {
+			return fmt.Errorf("duplicate question ID %s in %s and %s", question.ID, other, source)
+		}
+		l.questionSources[question.ID] = source
+		l.questions = append(l.questions, question)
+	}
+	return nil
+}
+
+// resolve returns loaded questions with option sets expanded and fragments substituted
+func (l *questionLoader) resolve() ([]models.Question, error) {
+	questions := make([]models.Question, 0, len(l.questions))
+	for _, question := range l.questions {
+		resolved, err := l.resolveQuestion(question)
+		if err != nil {
+			return nil, fmt.Errorf("question %s in %s: %w", question.ID, l.questionSources[question.ID], err)
+		}
+		questions = append(questions, resolved)
+	}
+	return questions, nil
+}
+
+// resolveQuestion expands option sets and fragments of a single question
+func (l *questionLoader) resolveQuestion(question models.Question) (models.Question, error) {
+	var err error
+	if question.Options, err = l.expandOptions(question.Options); err != nil {
+		return question, err
+	}
+
+	if question.Text, err = l.substitute(question.Text); err != nil {
+		return question, err
+	}
+	if question.ExternalText, err = l.substitute(question.ExternalText); err != nil {
+		return question, err
+	}
+	if question.InputPlaceholder, err = l.substitute(question.InputPlaceholder); err != nil {
+		return question, err
+	}
+	if question.CorrectAnswer, err = l.substitute(question.CorrectAnswer); err != nil {
+		return question, err
+	}
+	if question.CorrectFeedback, err = l.substitute(question.CorrectFeedback); err != nil {
+		return question, err
+	}
+	if question.WrongFeedback, err = l.substitute(question.WrongFeedback); err != nil {
+		return question, err
+	}
+
+	if len(question.Messages) > 0 {
+		messages := make([]string, len(question.Messages))
+		for i, message := range question.Messages {
+			if messages[i], err = l.substitute(message); err != nil {
+				return question, err
+			}
+		}
+		question.Messages = messages
+	}
+
+	return question, nil
+}
+
+// expandOptions replaces option set references with the set's options.
+// Next question and action of the reference apply to set options that leave them empty.
+func (l *questionLoader) expandOptions(options []models.Option) ([]models.Option, error) {
+	if len(options) == 0 {
+		return options, nil
+	}
+
+	expanded := make([]models.Option, 0, len(options))
+	for _, option := range options {
+		if option.OptionSet == "" {
+			text, err := l.substitute(option.Text)
+			if err != nil {
+				return nil, err
+			}
+			option.Text = text
+			expanded = append(expanded, option)
+			continue
+		}
+
+		set, exists := l.optionSets[option.OptionSet]
+		if !exists {
+			return nil, fmt.Errorf("unknown option set %s", option.OptionSet)
+		}
+		for _, setOption := range set {
+			if setOption.OptionSet != "" {
+				return nil, fmt.Errorf("option set %s references another option set", option.OptionSet)
+			}
+
+			text, err := l.substitute(setOption.Text)
+			if err != nil {
+				return nil, err
+			}
+			setOption.Text = text
+			if setOption.NextID == "" {
+				setOption.NextID = option.NextID
+			}
+			if setOption.Action == "" {
+				setOption.Action = option.Action
+			}
+			expanded = append(expanded, setOption)
+		}
+	}
+	return expanded, nil
+}
+
+// substitute replaces {{key}} references with fragments
+func (l *questionLoader) substitute(text string) (string, error) {
+	var missing string
+	result := fragmentPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		key := fragmentPattern.FindStringSubmatch(ref)[1]
+		fragment, exists := l.fragments[key]
+		if !exists {
+			if missing == "" {
+				missing = key
+			}
+			return ref
+		}
+		return fragment
+	})
+
+	if missing != "" {
+		return text, fmt.Errorf("unknown fragment %s", missing)
+	}
+	return result, nil
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tlgbot/internal/models"
+)
+
+// writeFiles writes files relative to dir, creating subdirectories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoadQuestionsWithIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.yaml": `
+include:
+  - shared.yaml
+  - parts/*.json
+questions:
+  - id: start
+    messages: ["Hi {name}!", "{{disclaimer}}"]
+    options:
+      - option_set: yes_no
+        next_id: car
+`,
+		"shared.yaml": `
+fragments:
+  disclaimer: Answers are anonymous.
+  skip: Skip
+option_sets:
+  yes_no:
+    - text: "Yes"
+    - text: "No"
+      next_id: end
+`,
+		"parts/a.json": `{"include": ["../shared.yaml"], "questions": [
+			{"id": "car", "text": "Own a car? {{ disclaimer }}", "options": [
+				{"option_set": "yes_no", "next_id": "end"},
+				{"text": "{{skip}}", "next_id": "end"}
+			]}
+		]}`,
+		"parts/b.json": `[{"id": "end", "text": "Bye", "terminal": true}]`,
+	})
+
+	questions, err := LoadQuestions(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+
+	if len(questions) != 3 {
+		t.Fatalf("Expected 3 questions, got %d", len(questions))
+	}
+
+	start := questions["start"]
+	if start.Messages[1] != "Answers are anonymous." {
+		t.Errorf("Expected fragment in messages, got %q", start.Messages[1])
+	}
+	expectedOptions := []string{"Yes->car", "No->end"}
+	if got := optionSummary(start.Options); strings.Join(got, ",") != strings.Join(expectedOptions, ",") {
+		t.Errorf("Expected start options %v, got %v", expectedOptions, got)
+	}
+
+	car := questions["car"]
+	if car.Text != "Own a car? Answers are anonymous." {
+		t.Errorf("Expected fragment in text, got %q", car.Text)
+	}
+	expectedOptions = []string{"Yes->end", "No->end", "Skip->end"}
+	if got := optionSummary(car.Options); strings.Join(got, ",") != strings.Join(expectedOptions, ",") {
+		t.Errorf("Expected car options %v, got %v", expectedOptions, got)
+	}
+	for _, option := range car.Options {
+		if option.OptionSet != "" {
+			t.Errorf("Expected option set reference to be expanded, got %+v", option)
+		}
+	}
+}
+
+func TestLoadQuestionsFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"01-start.yaml":  "- id: start\n  text: \"{{welcome}}\"\n",
+		"02-end.toml":    "[[questions]]\nid = \"end\"\ntext = \"Bye\"\n",
+		"fragments.json": `{"fragments": {"welcome": "Welcome!"}}`,
+		"README.md":      "not questions",
+		"drafts/x.json":  `[{"id": "start"}]`,
+	})
+
+	questions, err := LoadQuestions(dir)
+	if err != nil {
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+
+	if len(questions) != 2 || questions["start"].Text != "Welcome!" || questions["end"].Text != "Bye" {
+		t.Errorf("Unexpected questions: %+v", questions)
+	}
+}
+
+func TestLoadQuestionsIncludeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name: "duplicate question across files",
+			files: map[string]string{
+				"main.json":  `{"include": ["other.json"], "questions": [{"id": "start"}]}`,
+				"other.json": `[{"id": "start"}]`,
+			},
+			expected: "duplicate question ID start in",
+		},
+		{
+			name: "duplicate fragment",
+			files: map[string]string{
+				"main.json":  `{"include": ["other.json"], "fragments": {"a": "A"}, "questions": [{"id": "start"}]}`,
+				"other.json": `{"fragments": {"a": "B"}}`,
+			},
+			expected: "duplicate fragment a",
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"main.json":  `{"include": ["other.json"], "questions": [{"id": "start"}]}`,
+				"other.json": `{"include": ["main.json"], "questions": [{"id": "end"}]}`,
+			},
+			expected: "include cycle",
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"main.json": `{"include": ["missing/*.json"], "questions": [{"id": "start"}]}`,
+			},
+			expected: "matched no files",
+		},
+		{
+			name: "unknown fragment",
+			files: map[string]string{
+				"main.json": `[{"id": "start", "text": "{{missing}}"}]`,
+			},
+			expected: "question start in",
+		},
+		{
+			name: "unknown option set",
+			files: map[string]string{
+				"main.json": `[{"id": "start", "options": [{"option_set": "missing"}]}]`,
+			},
+			expected: "unknown option set missing",
+		},
+		{
+			name: "survey metadata in questions file",
+			files: map[string]string{
+				"main.json": `{"start_question_id": "intro", "questions": [{"id": "intro"}]}`,
+			},
+			expected: "only allowed in survey definitions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			_, err := LoadQuestions(filepath.Join(dir, "main.json"))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoadSurveysWithIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"spring.yaml": `
+id: spring
+include: [shared/common.yaml]
+questions:
+  - id: start
+    text: Spring survey
+    options:
+      - option_set: continue
+        next_id: end
+`,
+		"shared/common.yaml": `
+option_sets:
+  continue:
+    - text: Continue
+questions:
+  - id: end
+    text: Thanks
+`,
+	})
+
+	surveys, err := LoadSurveys(dir, DefaultStartQuestionID)
+	if err != nil {
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+
+	if len(surveys) != 1 || len(surveys[0].Questions) != 2 {
+		t.Fatalf("Expected one survey with included questions, got %+v", surveys)
+	}
+	if option := surveys[0].Questions[0].Options[0]; option.Text != "Continue" || option.NextID != "end" {
+		t.Errorf("Expected expanded option set, got %+v", option)
+	}
+}
+
+// optionSummary describes options as text->next_id pairs
+func optionSummary(options []models.Option) []string {
+	summary := make([]string, 0, len(options))
+	for _, option := range options {
+		summary = append(summary, option.Text+"->"+option.NextID)
+	}
+	return summary
+}
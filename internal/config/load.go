@@ -0,0 +1,281 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"tlgbot/internal/models"
+	"tlgbot/internal/secrets"
+
+	"github.com/joho/godotenv"
+)
+
+// setting is a configuration field that can be set in the config file, by environment variable and by flag
+type setting struct {
+	// key is the JSON field name in the config file
+	key   string
+	env   string
+	flag  string
+	usage string
+}
+
+// settings lists every configuration field with its environment variable and flag
+var settings = []setting{
+	{"telegram_token", EnvTelegramToken, "token", "Telegram bot token"},
+	{"questions_file_path", EnvQuestionsFilePath, "questions", "questions file (JSON, YAML or TOML) or directory"},
+	{"surveys_dir", EnvSurveysDir, "surveys-dir", "directory with one survey definition per file"},
+	{"start_question_id", EnvStartQuestionID, "start", "start question ID"},
+	{"delay_ms", EnvDelayMs, "delay-ms", "default delay between messages in milliseconds"},
+	{"admin_chat_id", EnvAdminChatID, "admin-chat-id", "chat or channel ID that receives survey reports"},
+	{"admin_user_ids", EnvAdminUserIDs, "admin-user-ids", "comma-separated user IDs allowed to run admin commands"},
+	{"http_addr", EnvHTTPAddr, "http-addr", "listen address for metrics and health endpoints"},
+	{"log_level", EnvLogLevel, "log-level", "minimum log level"},
+	{"log_format", EnvLogFormat, "log-format", "log output format (text or json)"},
+	{"google_creds", EnvGoogleCreds, "google-creds", "path to Google credentials"},
+	{"sheet_id", EnvSheetID, "sheet-id", "Google Sheet ID"},
+	{"reminder_after", EnvReminderAfter, "reminder-after", "inactivity on a question before a reminder, e.g. 30m"},
+	{"reminder_interval", EnvReminderInterval, "reminder-interval", "pause between repeated reminders, remind once when empty"},
+	{"reminder_limit", EnvReminderLimit, "reminder-limit", "maximum reminders per question"},
+	{"reminder_text", EnvReminderText, "reminder-text", "reminder message"},
+	{"session_timeout", EnvSessionTimeout, "session-timeout", "inactivity after which an unfinished survey expires, e.g. 24h"},
+	{"expired_text", EnvExpiredText, "expired-text", "message sent when a survey expires, none when empty"},
+	{"state_dir", EnvStateDir, "state-dir", "directory persisting user states across restarts, in memory only when empty"},
+	{"webhook_url", EnvWebhookURL, "webhook-url", "URL receiving completed submissions as JSON, disabled when empty"},
+	{"webhook_secret", EnvWebhookSecret, "webhook-secret", "key signing webhook requests with HMAC-SHA256, unsigned when empty"},
+	{"webhook_max_attempts", EnvWebhookMaxAttempts, "webhook-max-attempts", "delivery attempts per webhook event"},
+	{"webhook_dead_letter", EnvWebhookDeadLetter, "webhook-dead-letter", "file collecting webhook events that could not be delivered"},
+	{"webhook_answers", EnvWebhookAnswers, "webhook-answers", "also send every answer to the webhook as it is given (true or false)"},
+	{"outbox_dir", EnvOutboxDir, "outbox-dir", "directory keeping submissions until every sink accepts them, delivered directly when empty"},
+}
+
+// secretKeys are settings holding secrets, the only ones that may reference a secret instead of holding it
+var secretKeys = []string{"telegram_token", "webhook_secret"}
+
+// Defaults returns configuration with default values
+func Defaults() *models.Config {
+	return &models.Config{
+		GoogleCreds:        DefaultGoogleCreds,
+		SheetID:            DefaultSheetID,
+		DelayMs:            DefaultDelayMs,
+		StartQuestionID:    DefaultStartQuestionID,
+		QuestionsFilePath:  DefaultQuestionsFilePath,
+		LogLevel:           DefaultLogLevel,
+		LogFormat:          DefaultLogFormat,
+		ReminderLimit:      DefaultReminderLimit,
+		WebhookMaxAttempts: DefaultWebhookMaxAttempts,
+	}
+}
+
+// Load builds validated configuration from defaults, config file, environment variables and command-line flags.
+// Each layer overrides the previous one: flags > environment > config file > defaults.
+// The config file is given by -config flag or argument, CONFIG_FILE variable, or is config.json when it exists.
+// Flags are registered on the given flag set, which reports flag.ErrHelp for -h.
+func Load(flags *flag.FlagSet, args []string) (*models.Config, error) {
+	configFile := flags.String("config", "", fmt.Sprintf("config file (%s, default %s when it exists)", EnvConfigFile, DefaultConfigFile))
+	values := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		values[s.flag] = flags.String(s.flag, "", fmt.Sprintf("%s (%s)", s.usage, s.env))
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 1 || (flags.NArg() == 1 && *configFile != "") {
+		return nil, errors.New("expected at most one config file")
+	}
+	if flags.NArg() == 1 {
+		*configFile = flags.Arg(0)
+	}
+
+	// Variables from .env are applied as environment unless already set
+	if err := godotenv.Load(); err != nil {
+		slog.Debug("No .env file loaded", "error", err)
+	}
+
+	cfg := Defaults()
+
+	path, required := *configFile, true
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+	if path == "" {
+		path, required = DefaultConfigFile, false
+	}
+	if err := readConfigFile(path, cfg); err != nil {
+		if required || !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	} else {
+		slog.Debug("Configuration file loaded", "path", path)
+	}
+
+	for _, s := range settings {
+		value, env, err := lookupSetting(s.env)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			if err := s.apply(cfg, value); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		if value, exists := values[f.Name]; exists && flagErr == nil {
+			if err := settingByFlag(f.Name).apply(cfg, *value); err != nil {
+				flagErr = fmt.Errorf("failed to parse -%s: %w", f.Name, err)
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := resolveReferences(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation error: %w", err)
+	}
+	return cfg, nil
+}
+
+// lookupSetting returns value of the environment variable, or content of the file named by
+// the variable with _FILE suffix as used for Docker and Kubernetes secrets.
+// The name of the variable the value came from is returned for error messages.
+func lookupSetting(env string) (string, string, error) {
+	value := os.Getenv(env)
+	fileEnv := env + FileEnvSuffix
+	path := os.Getenv(fileEnv)
+	if path == "" {
+		return value, env, nil
+	}
+	if value != "" {
+		return "", fileEnv, fmt.Errorf("both %s and %s are set", env, fileEnv)
+	}
+
+	value, err := secrets.ReadFile(path)
+	if err != nil {
+		return "", fileEnv, fmt.Errorf("failed to load %s: %w", fileEnv, err)
+	}
+	return value, fileEnv, nil
+}
+
+// resolveReferences replaces secret references such as file:// and env:// in secret settings with their values.
+// Other settings are taken as they are, so a message or URL that happens to look like a reference is not read.
+func resolveReferences(cfg *models.Config) error {
+	for _, key := range secretKeys {
+		field, err := configField(cfg, key)
+		if err != nil {
+			return err
+		}
+		if !secrets.IsReference(field.String()) {
+			continue
+		}
+
+		value, err := secrets.Resolve(field.String())
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", key, err)
+		}
+		field.SetString(value)
+	}
+	return nil
+}
+
+// settingByFlag returns setting of the flag
+func settingByFlag(name string) setting {
+	for _, s := range settings {
+		if s.flag == name {
+			return s
+		}
+	}
+	return setting{}
+}
+
+// apply parses value into the configuration field of the setting
+func (s setting) apply(cfg *models.Config, value string) error {
+	field, err := configField(cfg, s.key)
+	if err != nil {
+		return err
+	}
+
+	// Types with text form, such as durations, parse themselves
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid boolean value %s: %w", value, err)
+		}
+		field.SetBool(enabled)
+	case reflect.Int, reflect.Int64:
+		number, err := parseInt64(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(number)
+	case reflect.Slice:
+		list, err := parseInt64List(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+// configField returns settable configuration field by its JSON name
+func configField(cfg *models.Config, key string) (reflect.Value, error) {
+	value := reflect.ValueOf(cfg).Elem()
+	for i, field := range reflect.VisibleFields(value.Type()) {
+		if jsonFieldName(field) == key {
+			return value.Field(i), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("unknown setting %s", key)
+}
+
+// parseInt64 parses an integer value
+func parseInt64(value string) (int64, error) {
+	number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer value %s: %w", value, err)
+	}
+	return number, nil
+}
+
+// parseInt64List parses a comma-separated list of integers, skipping empty items
+func parseInt64List(value string) ([]int64, error) {
+	parts := strings.Split(value, ",")
+	values := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		number, err := parseInt64(part)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, number)
+	}
+	return values, nil
+}
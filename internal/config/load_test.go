@@ -0,0 +1,311 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+// clearConfigEnv unsets all configuration variables for the test
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range append([]string{EnvConfigFile}, settingEnvs()...) {
+		t.Setenv(env, "")
+		t.Setenv(env+FileEnvSuffix, "")
+	}
+}
+
+func settingEnvs() []string {
+	envs := make([]string, 0, len(settings))
+	for _, s := range settings {
+		envs = append(envs, s.env)
+	}
+	return envs
+}
+
+func loadWithArgs(args ...string) (*models.Config, error) {
+	flags := flag.NewFlagSet("telegram-bot", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return Load(flags, args)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearConfigEnv(t)
+	configPath := filepath.Join(t.TempDir(), "bot.json")
+	content := `{"telegram_token": "file_token", "delay_ms": 100, "log_level": "debug", "admin_user_ids": [1, 2], "http_addr": ":8080", "session_timeout": "24h"}`
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	t.Setenv(EnvDelayMs, "200")
+	t.Setenv(EnvLogLevel, "warn")
+	t.Setenv(EnvAdminChatID, "-100")
+	t.Setenv(EnvReminderAfter, "30m")
+	t.Setenv(EnvWebhookURL, "https://example.com/hook")
+
+	cfg, err := loadWithArgs("-config", configPath, "-log-level", "error", "-admin-user-ids", "3", "-reminder-interval", "2h",
+		"-webhook-answers", "true")
+	if err != nil {
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+
+	expected := Defaults()
+	expected.TelegramToken = "file_token" // file over default
+	expected.HTTPAddr = ":8080"
+	expected.DelayMs = 200             // environment over file
+	expected.AdminChatID = -100        // environment over default
+	expected.LogLevel = "error"        // flag over environment and file
+	expected.AdminUserIDs = []int64{3} // flag over file
+	expected.SessionTimeout = models.Duration(24 * time.Hour)
+	expected.ReminderAfter = models.Duration(30 * time.Minute)
+	expected.ReminderInterval = models.Duration(2 * time.Hour)
+	expected.WebhookURL = "https://example.com/hook"
+	expected.WebhookAnswers = true
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, cfg)
+	}
+}
+
+func TestLoadConfigFileSources(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig := func(name, token string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(`{"telegram_token": "`+token+`"}`), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		return path
+	}
+	argPath := writeConfig("arg.json", "arg_token")
+	envPath := writeConfig("env.json", "env_token")
+
+	tests := []struct {
+		name          string
+		args          []string
+		envFile       string
+		expectedToken string
+		expectError   bool
+	}{
+		{name: "argument", args: []string{argPath}, envFile: envPath, expectedToken: "arg_token"},
+		{name: "flag", args: []string{"-config", argPath}, envFile: envPath, expectedToken: "arg_token"},
+		{name: "environment", envFile: envPath, expectedToken: "env_token"},
+		{name: "missing given file", args: []string{filepath.Join(dir, "missing.json")}, expectError: true},
+		{name: "flag and argument", args: []string{"-config", argPath, envPath}, expectError: true},
+		{name: "no file and no token", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv(EnvConfigFile, tt.envFile)
+
+			cfg, err := loadWithArgs(tt.args...)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(expectedNoErrorMsg, err)
+			}
+			if cfg.TelegramToken != tt.expectedToken {
+				t.Errorf("Expected token %s, got %s", tt.expectedToken, cfg.TelegramToken)
+			}
+		})
+	}
+}
+
+func TestLoadDefaultConfigFile(t *testing.T) {
+	clearConfigEnv(t)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Logf("Failed to restore working directory: %v", err)
+		}
+	})
+
+	if err := os.WriteFile(DefaultConfigFile, []byte(`{"telegram_token": "default_file_token"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := loadWithArgs()
+	if err != nil {
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+	if cfg.TelegramToken != "default_file_token" || cfg.DelayMs != DefaultDelayMs {
+		t.Errorf("Expected token from %s with default delay, got %+v", DefaultConfigFile, cfg)
+	}
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		args          []string
+		expectedError string
+	}{
+		{name: "invalid delay", env: map[string]string{EnvDelayMs: "invalid_delay"}, expectedError: "failed to parse DELAY_MS"},
+		{name: "negative delay", env: map[string]string{EnvDelayMs: "-100"}, expectedError: "delay must be non-negative"},
+		{name: "invalid admin chat flag", args: []string{"-admin-chat-id", "abc"}, expectedError: "failed to parse -admin-chat-id"},
+		{name: "invalid admin users", env: map[string]string{EnvAdminUserIDs: "1,abc"}, expectedError: "failed to parse ADMIN_USER_IDS"},
+		{name: "invalid duration", env: map[string]string{EnvReminderAfter: "soon"}, expectedError: "failed to parse REMINDER_AFTER: invalid duration"},
+		{name: "negative timeout flag", args: []string{"-session-timeout", "-1h"}, expectedError: "timeouts must be non-negative"},
+		{name: "invalid boolean", env: map[string]string{EnvWebhookAnswers: "sometimes"}, expectedError: "failed to parse WEBHOOK_ANSWERS: invalid boolean"},
+		{name: "relative webhook URL", env: map[string]string{EnvWebhookURL: "/hook"}, expectedError: "webhook URL must be an absolute"},
+		{name: "no webhook attempts", args: []string{"-webhook-url", "https://example.com", "-webhook-max-attempts", "0"}, expectedError: "webhook max attempts must be positive"},
+		{name: "unknown flag", args: []string{"-tokn", "x"}, expectedError: "flag provided but not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv(EnvTelegramToken, "token")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := loadWithArgs(tt.args...)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestLoadSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(secretPath, []byte("secret_from_file\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"telegram_token": "env://BOT_TOKEN_TEST"}`), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		args          []string
+		expectedToken string
+		expectError   bool
+	}{
+		{name: "file variable", env: map[string]string{EnvTelegramToken + FileEnvSuffix: secretPath}, expectedToken: "secret_from_file"},
+		{name: "file reference in variable", env: map[string]string{EnvTelegramToken: "file://" + secretPath}, expectedToken: "secret_from_file"},
+		{name: "env reference in config file", args: []string{configPath}, env: map[string]string{"BOT_TOKEN_TEST": "secret_from_env"}, expectedToken: "secret_from_env"},
+		{name: "flag over file variable", args: []string{"-token", "flag_token"}, env: map[string]string{EnvTelegramToken + FileEnvSuffix: secretPath}, expectedToken: "flag_token"},
+		{name: "variable and file variable", env: map[string]string{EnvTelegramToken: "token", EnvTelegramToken + FileEnvSuffix: secretPath}, expectError: true},
+		{name: "missing secret file", env: map[string]string{EnvTelegramToken + FileEnvSuffix: filepath.Join(dir, "missing")}, expectError: true},
+		{name: "unset referenced variable", args: []string{configPath}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv("BOT_TOKEN_TEST", "")
+			if err := os.Unsetenv("BOT_TOKEN_TEST"); err != nil {
+				t.Fatalf("Failed to unset environment variable: %v", err)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := loadWithArgs(tt.args...)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(expectedNoErrorMsg, err)
+			}
+			if cfg.TelegramToken != tt.expectedToken {
+				t.Errorf("Expected token %s, got %s", tt.expectedToken, cfg.TelegramToken)
+			}
+		})
+	}
+}
+
+func TestLoadResolvesReferencesOnlyInSecrets(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("WEBHOOK_KEY_TEST", "signing_key")
+	t.Setenv(EnvTelegramToken, "token")
+	t.Setenv(EnvWebhookSecret, "env://WEBHOOK_KEY_TEST")
+	t.Setenv(EnvReminderText, "env://WEBHOOK_KEY_TEST")
+
+	cfg, err := loadWithArgs()
+	if err != nil {
+		t.Fatalf(expectedNoErrorMsg, err)
+	}
+	if cfg.WebhookSecret != "signing_key" {
+		t.Errorf("Expected webhook secret to be resolved, got %q", cfg.WebhookSecret)
+	}
+	if cfg.ReminderText != "env://WEBHOOK_KEY_TEST" {
+		t.Errorf("Expected reminder text to be kept as written, got %q", cfg.ReminderText)
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	clearConfigEnv(t)
+	if _, err := loadWithArgs("-h"); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Expected flag.ErrHelp, got %v", err)
+	}
+}
+
+func TestSettingsCoverConfig(t *testing.T) {
+	keys := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		keys[s.key] = true
+		if _, err := configField(&models.Config{}, s.key); err != nil {
+			t.Errorf("Setting %s: %v", s.flag, err)
+		}
+	}
+
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(models.Config{})) {
+		key := jsonFieldName(field)
+		if key != "" && key != "$schema" && !keys[key] {
+			t.Errorf("Config field %s has no setting", key)
+		}
+	}
+}
+
+func TestParseInt64List(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    []int64
+		expectError bool
+	}{
+		{"empty", "", []int64{}, false},
+		{"single value", "123", []int64{123}, false},
+		{"multiple values with spaces", "1, 2 ,3,", []int64{1, 2, 3}, false},
+		{"channel chat", "-1001234567890", []int64{-1001234567890}, false},
+		{"invalid value", "1,abc", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseInt64List(tt.value)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+			if !tt.expectError && !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
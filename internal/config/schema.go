@@ -0,0 +1,240 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"tlgbot/internal/models"
+)
+
+// SchemaDialect is the JSON Schema version of generated schemas
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema names accepted by Schema
+const (
+	SchemaQuestions = "questions"
+	SchemaConfig    = "config"
+)
+
+// schemaDescriptions documents fields of generated schemas, keyed by type name and JSON field name
+var schemaDescriptions = map[string]string{
+	"Config.$schema":              "JSON Schema of this file, used by editors only",
+	"Config.telegram_token":       "Telegram Bot API token",
+	"Config.google_creds":         "Path to Google service account credentials",
+	"Config.sheet_id":             "Google Sheet ID",
+	"Config.delay_ms":             "Default delay between messages in milliseconds",
+	"Config.start_question_id":    "ID of the first question",
+	"Config.questions_file_path":  "Path to the questions file or directory (JSON, YAML or TOML)",
+	"Config.admin_chat_id":        "Chat ID receiving completed survey reports",
+	"Config.admin_user_ids":       "User IDs allowed to run admin commands",
+	"Config.surveys_dir":          "Directory with survey definitions, one survey per file",
+	"Config.http_addr":            "Address of the HTTP server for metrics and health checks, disabled when empty",
+	"Config.log_level":            "Log level: debug, info, warn or error",
+	"Config.log_format":           "Log format: text or json",
+	"Config.reminder_after":       "Inactivity on a question before a reminder is sent, e.g. 30m; disabled when empty",
+	"Config.reminder_interval":    "Pause between repeated reminders, e.g. 2h; a single reminder is sent when empty",
+	"Config.reminder_limit":       "Maximum number of reminders per question, 3 by default",
+	"Config.reminder_text":        "Reminder message, {name} is the user's name",
+	"Config.session_timeout":      "Inactivity after which an unfinished survey expires, e.g. 24h; disabled when empty",
+	"Config.expired_text":         "Message sent when a survey expires; none is sent when empty",
+	"Config.webhook_url":          "URL receiving completed submissions as JSON POST requests; the webhook is disabled when empty",
+	"Config.webhook_secret":       "Key signing webhook requests with HMAC-SHA256 in the X-Tlgbot-Signature header; requests are unsigned when empty",
+	"Config.webhook_max_attempts": "Delivery attempts per webhook event, with exponential backoff between them",
+	"Config.webhook_dead_letter":  "File collecting webhook events that could not be delivered, one JSON object per line",
+	"Config.webhook_answers":      "Also send every answer to the webhook as it is given, before the survey completes",
+	"Config.state_dir":            "Directory persisting user states across restarts and read by the analytics subcommand; states are kept in memory only when empty",
+	"Config.outbox_dir":           "Directory keeping completed submissions until the admin chat and the webhook accept them, retried with backoff; submissions are delivered once, directly, when empty",
+
+	"QuestionFile.$schema":           "JSON Schema of this file, used by editors only",
+	"QuestionFile.id":                "Survey ID, only allowed in survey definitions",
+	"QuestionFile.title":             "Survey title, only allowed in survey definitions",
+	"QuestionFile.start_question_id": "ID of the first survey question, only allowed in survey definitions",
+	"QuestionFile.include":           "Files, directories or glob patterns to load, relative to this file",
+	"QuestionFile.fragments":         "Shared texts referenced as {{key}} in question texts and options",
+	"QuestionFile.option_sets":       "Shared option groups referenced by option_set",
+	"QuestionFile.questions":         "Survey questions",
+
+	"Question.id":                    "Unique question identifier",
+	"Question.text":                  "Main question text",
+	"Question.messages":              "Messages for step-by-step display",
+	"Question.images":                "Paths to images",
+	"Question.options":               "Answer options with transitions",
+	"Question.external_link":         "External link",
+	"Question.external_text":         "Text for external link",
+	"Question.input":                 "Input hint",
+	"Question.input_type":            "Input type, e.g. text; free text answers are accepted when set",
+	"Question.input_placeholder":     "Placeholder for input field",
+	"Question.delay_ms":              "Delay before showing question in milliseconds",
+	"Question.auto_advance":          "Automatic transition to next question",
+	"Question.auto_advance_delay_ms": "Delay for auto-advance in milliseconds",
+	"Question.notify":                "Forward the answer to the admin chat immediately",
+	"Question.terminal":              "Reaching this question completes the survey",
+	"Question.show_summary":          "Append answers summary to the question, true for terminal questions by default",
+	"Question.correct_answer":        "Correct answer, matched against option text or text input ignoring case; makes the question graded",
+	"Question.points":                "Points for the correct answer, 1 by default",
+	"Question.correct_feedback":      "Message sent after a correct answer",
+	"Question.wrong_feedback":        "Message sent after a wrong answer, {answer} is the correct answer",
+	"Question.score_branches":        "Score ranges sending the user on to other questions; the first match wins",
+	"Question.reminder_after":        "Inactivity before a reminder, overrides reminder_after of the config; 0s disables reminders",
+	"Question.reminder_text":         "Reminder message for this question",
+	"Question.timeout":               "Inactivity after which the survey expires, overrides session_timeout of the config; 0s disables it",
+
+	"ScoreBranch.min_score": "Lowest matching score, inclusive; open when missing",
+	"ScoreBranch.max_score": "Highest matching score, inclusive; open when missing",
+	"ScoreBranch.next_id":   "ID of the question users with a matching score go to",
+
+	"Option.text":       "Button text",
+	"Option.next_id":    "ID of the next question",
+	"Option.action":     "Special action, e.g. get_location",
+	"Option.option_set": "Key of a shared option group replacing this option",
+	"Option.points":     "Points added to the score when the option is chosen",
+}
+
+// schemaRequired lists required fields by type name
+var schemaRequired = map[string][]string{
+	"Question":    {"id"},
+	"ScoreBranch": {"next_id"},
+}
+
+// Schema returns JSON Schema of questions files or the config file by name
+func Schema(name string) ([]byte, error) {
+	switch name {
+	case SchemaQuestions:
+		return QuestionsSchema()
+	case SchemaConfig:
+		return ConfigSchema()
+	default:
+		return nil, fmt.Errorf("unknown schema %q, expected %s or %s", name, SchemaQuestions, SchemaConfig)
+	}
+}
+
+// QuestionsSchema returns JSON Schema of question files: a question list or an object with questions
+func QuestionsSchema() ([]byte, error) {
+	g := newSchemaGenerator()
+	root := map[string]interface{}{
+		"$schema": SchemaDialect,
+		"title":   "Survey questions",
+		"oneOf": []interface{}{
+			g.typeSchema(reflect.TypeOf([]models.Question{})),
+			g.typeSchema(reflect.TypeOf(questionFile{})),
+		},
+		"$defs": g.defs,
+	}
+	return marshalSchema(root)
+}
+
+// ConfigSchema returns JSON Schema of the config file
+func ConfigSchema() ([]byte, error) {
+	g := newSchemaGenerator()
+	root := g.structSchema(reflect.TypeOf(models.Config{}))
+	root["$schema"] = SchemaDialect
+	root["title"] = "Telegram bot configuration"
+	return marshalSchema(root)
+}
+
+// marshalSchema encodes schema as indented JSON ending with a newline
+func marshalSchema(schema map[string]interface{}) ([]byte, error) {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// schemaGenerator builds JSON Schema from Go types using their JSON field names
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// newSchemaGenerator creates a generator with no definitions
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{defs: make(map[string]interface{})}
+}
+
+// textUnmarshalerType is implemented by types written as strings, such as durations
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// typeSchema returns schema of a type; structs are added to definitions and referenced
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		name := schemaTypeName(t)
+		if _, exists := g.defs[name]; !exists {
+			g.defs[name] = nil // reserve the name for recursive types
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns schema of a struct rejecting unknown fields
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	name := schemaTypeName(t)
+	properties := make(map[string]interface{})
+	for _, field := range reflect.VisibleFields(t) {
+		key := jsonFieldName(field)
+		if key == "" {
+			continue
+		}
+		property := g.typeSchema(field.Type)
+		if description, exists := schemaDescriptions[name+"."+key]; exists {
+			property["description"] = description
+		}
+		properties[key] = property
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, exists := schemaRequired[name]; exists {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// schemaTypeName returns definition name of a struct type, exported form of its Go name
+func schemaTypeName(t reflect.Type) string {
+	name := t.Name()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// jsonFieldName returns JSON name of a struct field, or empty string for fields not encoded
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() || field.Anonymous {
+		return ""
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return field.Name
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"tlgbot/internal/models"
+)
+
+func TestSchemaDescribesAllFields(t *testing.T) {
+	types := []reflect.Type{
+		reflect.TypeOf(models.Config{}),
+		reflect.TypeOf(questionFile{}),
+		reflect.TypeOf(models.Question{}),
+		reflect.TypeOf(models.Option{}),
+		reflect.TypeOf(models.ScoreBranch{}),
+	}
+
+	for _, typ := range types {
+		for _, field := range reflect.VisibleFields(typ) {
+			key := jsonFieldName(field)
+			if key == "" {
+				continue
+			}
+			if _, exists := schemaDescriptions[schemaTypeName(typ)+"."+key]; !exists {
+				t.Errorf("Missing schema description for %s.%s", schemaTypeName(typ), key)
+			}
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectError bool
+		expectedDef string
+	}{
+		{name: SchemaQuestions, expectedDef: "Question"},
+		{name: SchemaConfig},
+		{name: "scenario", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Schema(tt.name)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var schema struct {
+				Dialect string                     `json:"$schema"`
+				Defs    map[string]json.RawMessage `json:"$defs"`
+			}
+			if err := json.Unmarshal(data, &schema); err != nil {
+				t.Fatalf("Expected valid JSON, got %v", err)
+			}
+			if schema.Dialect != SchemaDialect {
+				t.Errorf("Expected dialect %s, got %s", SchemaDialect, schema.Dialect)
+			}
+			if _, exists := schema.Defs[tt.expectedDef]; tt.expectedDef != "" && !exists {
+				t.Errorf("Expected definition %s, got %v", tt.expectedDef, schema.Defs)
+			}
+		})
+	}
+}
+
+func TestQuestionSchemaRequiresID(t *testing.T) {
+	data, err := QuestionsSchema()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var schema struct {
+		Defs map[string]struct {
+			Required             []string `json:"required"`
+			AdditionalProperties *bool    `json:"additionalProperties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	question := schema.Defs["Question"]
+	if !reflect.DeepEqual(question.Required, []string{"id"}) {
+		t.Errorf("Expected required id, got %v", question.Required)
+	}
+	if question.AdditionalProperties == nil || *question.AdditionalProperties {
+		t.Error("Expected unknown question fields to be rejected")
+	}
+}
+
+func TestSchemaFilesUpToDate(t *testing.T) {
+	for _, name := range []string{SchemaQuestions, SchemaConfig} {
+		t.Run(name, func(t *testing.T) {
+			expected, err := Schema(name)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			path := filepath.Join("..", "..", "configs", "schema", name+".schema.json")
+			committed, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", path, err)
+			}
+			if !bytes.Equal(committed, expected) {
+				t.Errorf("%s is out of date, run make schema", path)
+			}
+		})
+	}
+}
+
+func TestSchemaKeyAllowed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "questions.json")
+	content := `{"$schema": "../configs/schema/questions.schema.json", "questions": [{"id": "start", "text": "Hi"}]}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+
+	questions, err := LoadQuestions(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, exists := questions["start"]; !exists {
+		t.Error("Expected start question")
+	}
+}
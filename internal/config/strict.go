@@ -0,0 +1,237 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlUnknownField matches unknown field errors reported by the YAML decoder
+var yamlUnknownField = regexp.MustCompile(`^line (\d+): field (.+) not found in type \S+$`)
+
+// decodeJSONStrict decodes a single JSON value rejecting unknown fields.
+// Errors carry line and column of the offending input.
+func decodeJSONStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return jsonPositionError(data, v, err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		line, column := position(data, int(decoder.InputOffset()))
+		return fmt.Errorf("line %d, column %d: unexpected data after top-level value", line, column)
+	}
+	return nil
+}
+
+// jsonPositionError adds line and column to JSON decoding errors of data decoded into v
+func jsonPositionError(data []byte, v interface{}, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		line, column := position(data, int(syntaxErr.Offset))
+		return fmt.Errorf("line %d, column %d: %s", line, column, syntaxErr.Error())
+	case errors.As(err, &typeErr):
+		line, column := position(data, int(typeErr.Offset))
+		return fmt.Errorf("line %d, column %d: cannot use %s as %s in field %s", line, column, typeErr.Value, typeErr.Type, typeErr.Field)
+	case strings.HasPrefix(err.Error(), `json: unknown field "`):
+		// Decoder does not report where the field is, so find the first key it rejects
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		if offset := unknownKeyOffset(data, reflect.TypeOf(v)); offset >= 0 {
+			line, column := position(data, offset)
+			return fmt.Errorf("line %d, column %d: unknown field %q", line, column, field)
+		}
+		return fmt.Errorf("unknown field %q", field)
+	case errors.Is(err, io.EOF):
+		return errors.New("empty input")
+	default:
+		return err
+	}
+}
+
+// unknownKeyOffset returns offset of the first object key that has no field in type t, or -1.
+// The document is walked along with the type, so keys of the same name elsewhere are not mistaken for it.
+func unknownKeyOffset(data []byte, t reflect.Type) int {
+	offset, _ := walkUnknownKey(json.NewDecoder(bytes.NewReader(data)), data, t)
+	return offset
+}
+
+// walkUnknownKey reads the next value from the decoder and returns offset of its first unknown key, or -1.
+// Nil type stands for a value decoded without field checks, such as an interface or custom unmarshaler.
+func walkUnknownKey(decoder *json.Decoder, data []byte, t reflect.Type) (int, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != nil && (reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)) {
+		t = nil
+	}
+
+	token, err := decoder.Token()
+	if err != nil {
+		return -1, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return -1, nil
+	}
+
+	var fields map[string]reflect.Type
+	var elem reflect.Type
+	if t != nil {
+		switch t.Kind() {
+		case reflect.Struct:
+			fields = jsonFields(t)
+		case reflect.Map, reflect.Slice, reflect.Array:
+			elem = t.Elem()
+		}
+	}
+
+	for decoder.More() {
+		if delim == '{' {
+			// Offset after the previous token is followed by a comma and spaces before the key
+			start := int(decoder.InputOffset())
+			for start < len(data) && bytes.IndexByte([]byte(", \t\r\n"), data[start]) >= 0 {
+				start++
+			}
+			token, err := decoder.Token()
+			if err != nil {
+				return -1, err
+			}
+			if fields != nil {
+				field, known := lookupField(fields, token.(string))
+				if !known {
+					return start, nil
+				}
+				elem = field
+			}
+		}
+		if offset, err := walkUnknownKey(decoder, data, elem); offset >= 0 || err != nil {
+			return offset, err
+		}
+	}
+	_, err = decoder.Token()
+	return -1, err
+}
+
+// jsonUnmarshalerType is implemented by types decoding JSON themselves
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// jsonFields returns types of struct fields by their JSON names; fields of embedded structs are promoted
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for _, field := range reflect.VisibleFields(t) {
+		if name := jsonFieldName(field); name != "" {
+			fields[name] = field.Type
+		}
+	}
+	return fields
+}
+
+// lookupField finds the field of a key, ignoring case like the decoder does
+func lookupField(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if field, exists := fields[key]; exists {
+		return field, true
+	}
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// position converts byte offset to 1-based line and column
+func position(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
+
+// decodeYAMLStrict decodes YAML rejecting unknown fields.
+// Root node of the same input is used to find columns of unknown keys.
+func decodeYAMLStrict(data []byte, root *yaml.Node, v interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(v)
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	messages := make([]string, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		match := yamlUnknownField.FindStringSubmatch(message)
+		if match == nil {
+			messages = append(messages, message)
+			continue
+		}
+
+		line, _ := strconv.Atoi(match[1])
+		if column := yamlKeyColumn(root, match[2], line); column > 0 {
+			messages = append(messages, fmt.Sprintf("line %d, column %d: unknown field %q", line, column, match[2]))
+		} else {
+			messages = append(messages, fmt.Sprintf("line %d: unknown field %q", line, match[2]))
+		}
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// yamlKeyColumn returns column of the mapping key on the line, or 0 when not found
+func yamlKeyColumn(node *yaml.Node, key string, line int) int {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			if keyNode := node.Content[i]; keyNode.Line == line && keyNode.Value == key {
+				return keyNode.Column
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if column := yamlKeyColumn(child, key, line); column > 0 {
+			return column
+		}
+	}
+	return 0
+}
+
+// decodeTOMLStrict decodes TOML rejecting unknown fields, with line and column in errors
+func decodeTOMLStrict(data []byte, v interface{}) error {
+	decoder := toml.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	var strictErr *toml.StrictMissingError
+	var decodeErr *toml.DecodeError
+
+	switch {
+	case errors.As(err, &strictErr):
+		messages := make([]string, 0, len(strictErr.Errors))
+		for _, fieldErr := range strictErr.Errors {
+			line, column := fieldErr.Position()
+			messages = append(messages, fmt.Sprintf("line %d, column %d: unknown field %q", line, column, strings.Join(fieldErr.Key(), ".")))
+		}
+		return errors.New(strings.Join(messages, "; "))
+	case errors.As(err, &decodeErr):
+		line, column := decodeErr.Position()
+		return fmt.Errorf("line %d, column %d: %s", line, column, decodeErr.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tlgbot/internal/models"
+)
+
+func TestLoadQuestionsStrictErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{
+			name: "JSON unknown option field",
+			file: "questions.json",
+			content: `[
+  {"id": "start", "options": [
+    {"text": "Go", "next-id": "end"}
+  ]}
+]`,
+			expected: `line 3, column 20: unknown field "next-id"`,
+		},
+		{
+			name: "JSON unknown nested field known in the parent",
+			file: "questions.json",
+			content: `[
+  {"id": "start", "options": [
+    {"text": "Go", "id": "end"}
+  ]}
+]`,
+			expected: `line 3, column 20: unknown field "id"`,
+		},
+		{
+			name:     "JSON unknown question field",
+			file:     "questions.json",
+			content:  "[{\"id\": \"start\",\n  \"autoadvance\": true}]",
+			expected: `line 2, column 3: unknown field "autoadvance"`,
+		},
+		{
+			name:     "JSON syntax error",
+			file:     "questions.json",
+			content:  "[\n  {\"id\": \"start\",}\n]",
+			expected: "line 2, column 19: invalid character '}'",
+		},
+		{
+			name:     "JSON wrong type",
+			file:     "questions.json",
+			content:  "[\n  {\"id\": \"start\", \"delay_ms\": \"fast\"}\n]",
+			expected: "line 2, column 37: cannot use string as int",
+		},
+		{
+			name:     "JSON trailing data",
+			file:     "questions.json",
+			content:  `[{"id": "start"}] []`,
+			expected: "unexpected data after top-level value",
+		},
+		{
+			name:     "YAML unknown field",
+			file:     "questions.yaml",
+			content:  "- id: start\n  options:\n    - text: Go\n      next-id: end\n",
+			expected: `line 4, column 7: unknown field "next-id"`,
+		},
+		{
+			name:     "YAML unknown field in object form",
+			file:     "questions.yaml",
+			content:  "fragment:\n  a: A\nquestions:\n  - id: start\n",
+			expected: `line 1, column 1: unknown field "fragment"`,
+		},
+		{
+			name:     "TOML unknown field",
+			file:     "questions.toml",
+			content:  "[[questions]]\nid = \"start\"\nautoadvance = true\n",
+			expected: `line 3, column 1: unknown field "questions.autoadvance"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write %s: %v", path, err)
+			}
+
+			_, err := LoadQuestions(path)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	data := []byte("ab\ncé\nx")
+
+	tests := []struct {
+		offset       int
+		line, column int
+	}{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		{6, 2, 3},
+		{8, 3, 2},
+		{100, 3, 2},
+	}
+
+	for _, tt := range tests {
+		line, column := position(data, tt.offset)
+		if line != tt.line || column != tt.column {
+			t.Errorf("position(%d) = %d:%d, expected %d:%d", tt.offset, line, column, tt.line, tt.column)
+		}
+	}
+}
+
+func TestUnknownKeyOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected int
+	}{
+		{name: "key name in a value", data: `{"text": "next-id", "next-id" : "end"}`, expected: 20},
+		{name: "no unknown key", data: `{"text": "Go", "next_id": "end"}`, expected: -1},
+		{name: "key in other case", data: `{"Text": "Go", "NEXT_ID": "end"}`, expected: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if offset := unknownKeyOffset([]byte(tt.data), reflect.TypeOf(&models.Option{})); offset != tt.expected {
+				t.Errorf("Expected offset %d, got %d", tt.expected, offset)
+			}
+		})
+	}
+
+	// The key is known in the question but not in the option nested in it
+	data := []byte(`[{"id": "start", "text": "Hi", "options": [{"id": "a", "text": "Go"}]}]`)
+	if offset := unknownKeyOffset(data, reflect.TypeOf(&[]models.Question{})); offset != 44 {
+		t.Errorf("Expected offset of the nested key 44, got %d", offset)
+	}
+}
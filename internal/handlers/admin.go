@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tlgbot/internal/models"
+	"tlgbot/internal/outbox"
+	"tlgbot/internal/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// defaultBroadcastInterval keeps broadcasts below Telegram's limit of ~30 messages per second
+const defaultBroadcastInterval = 50 * time.Millisecond
+
+// Outbox view settings
+const (
+	outboxTimeLayout = "2006-01-02 15:04:05 MST"
+	maxOutboxItems   = 20
+)
+
+// cmdStats sends survey statistics to admin, optionally for a single survey
+func (h *TelegramHandler) cmdStats(ctx context.Context, message *tgbotapi.Message, _ *models.UserState) error {
+	userID := message.From.ID
+	surveyID := strings.TrimSpace(message.CommandArguments())
+	stats := services.CollectStats(h.userStateManager.GetAllUserStates(), surveyID)
+	return h.bot.SendMessage(ctx, userID, formatStats(stats), nil)
+}
+
+// cmdFunnel sends drop-off funnels of surveys to admin, optionally for a single survey
+func (h *TelegramHandler) cmdFunnel(ctx context.Context, message *tgbotapi.Message, _ *models.UserState) error {
+	userID := message.From.ID
+	surveyID := strings.TrimSpace(message.CommandArguments())
+	funnels := services.BuildFunnels(h.userStateManager.GetAllUserStates(), surveyID)
+	if len(funnels) == 0 {
+		return h.bot.SendMessage(ctx, userID, "No survey attempts yet", nil)
+	}
+
+	texts := make([]string, 0, len(funnels))
+	for _, funnel := range funnels {
+		texts = append(texts, formatFunnel(funnel))
+	}
+	return h.bot.SendMessage(ctx, userID, strings.Join(texts, "\n\n"), nil)
+}
+
+// cmdOutbox sends number of submissions waiting in the outbox and details of stuck ones to admin
+func (h *TelegramHandler) cmdOutbox(ctx context.Context, message *tgbotapi.Message, _ *models.UserState) error {
+	userID := message.From.ID
+	if h.outbox == nil {
+		return h.bot.SendMessage(ctx, userID, "Outbox is disabled, set OUTBOX_DIR to enable it", nil)
+	}
+	return h.bot.SendMessage(ctx, userID, formatOutbox(h.outbox.Pending()), nil)
+}
+
+// cmdExport sends completed submissions to admin as a file, optionally of a single survey
+func (h *TelegramHandler) cmdExport(ctx context.Context, message *tgbotapi.Message, _ *models.UserState) error {
+	userID := message.From.ID
+	args := strings.Fields(message.CommandArguments())
+
+	format := services.ExportFormatCSV
+	if len(args) > 0 {
+		format = strings.ToLower(args[0])
+	}
+	if !slices.Contains(services.SubmissionExportFormats, format) {
+		return h.bot.SendMessage(ctx, userID, "Usage: /export [csv|json|jsonl|xlsx] [survey_id]", nil)
+	}
+	surveyID := ""
+	if len(args) > 1 {
+		surveyID = args[1]
+	}
+
+	records := services.CollectSubmissions(h.userStateManager.GetAllUserStates(), surveyID)
+	data, err := services.ExportSubmissions(records, services.SubmissionColumns(records), format)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to export submissions", "format", format, "error", err)
+		return h.bot.SendMessage(ctx, userID, "Failed to export submissions, see the bot log for details", nil)
+	}
+
+	fileName := fmt.Sprintf("responses_%s.%s", time.Now().Format("20060102_150405"), format)
+	if err := h.bot.SendDocument(ctx, userID, fileName, data); err != nil {
+		slog.ErrorContext(ctx, "Failed to send export", "file_name", fileName, "error", err)
+		return h.bot.SendMessage(ctx, userID, "Failed to send the export file, see the bot log for details", nil)
+	}
+	return nil
+}
+
+// cmdBroadcast sends message to all known users with throttling
+func (h *TelegramHandler) cmdBroadcast(ctx context.Context, message *tgbotapi.Message, _ *models.UserState) error {
+	userID := message.From.ID
+	text := strings.TrimSpace(message.CommandArguments())
+	if text == "" {
+		return h.bot.SendMessage(ctx, userID, "Usage: /broadcast <message>", nil)
+	}
+
+	states := h.userStateManager.GetAllUserStates()
+	recipients := make([]int64, 0, len(states))
+	for recipientID := range states {
+		recipients = append(recipients, recipientID)
+	}
+	sort.Slice(recipients, func(i, j int) bool { return recipients[i] < recipients[j] })
+
+	sent, failed := 0, 0
+	for i, recipientID := range recipients {
+		if i > 0 {
+			time.Sleep(h.broadcastInterval)
+		}
+
+		if err := h.bot.SendMessage(ctx, recipientID, text, nil); err != nil {
+			slog.WarnContext(ctx, "Failed to broadcast message", "recipient_id", recipientID, "error", err)
+			failed++
+			continue
+		}
+		sent++
+	}
+
+	return h.bot.SendMessage(ctx, userID, fmt.Sprintf("Broadcast finished: %d sent, %d failed", sent, failed), nil)
+}
+
+// cmdResetUser removes state of the given user
+func (h *TelegramHandler) cmdResetUser(ctx context.Context, message *tgbotapi.Message, _ *models.UserState) error {
+	userID := message.From.ID
+	targetID, err := strconv.ParseInt(strings.TrimSpace(message.CommandArguments()), 10, 64)
+	if err != nil {
+		return h.bot.SendMessage(ctx, userID, "Usage: /reset_user <user_id>", nil)
+	}
+
+	// A concurrent update of the user would otherwise save the deleted state again.
+	// Admins resetting themselves already hold their lock for this update.
+	if targetID != userID {
+		defer h.userStateManager.LockUser(targetID)()
+	}
+	if !h.userStateManager.DeleteUserState(targetID) {
+		return h.bot.SendMessage(ctx, userID, fmt.Sprintf("User %d not found", targetID), nil)
+	}
+	slog.InfoContext(ctx, "User reset by admin", "target_user_id", targetID)
+	return h.bot.SendMessage(ctx, userID, fmt.Sprintf("User %d has been reset", targetID), nil)
+}
+
+// formatStats formats survey statistics for display
+func formatStats(stats services.SurveyStats) string {
+	var sb strings.Builder
+
+	sb.WriteString("📊 Survey statistics\n\n")
+	fmt.Fprintf(&sb, "Started: %d\n", stats.Started)
+	fmt.Fprintf(&sb, "Completed: %d\n", stats.Completed)
+	for _, outcome := range sortedKeys(stats.Outcomes) {
+		fmt.Fprintf(&sb, "  • %s: %d\n", outcome, stats.Outcomes[outcome])
+	}
+	if stats.Expired > 0 {
+		fmt.Fprintf(&sb, "Expired: %d\n", stats.Expired)
+	}
+	if stats.Abandoned > 0 {
+		fmt.Fprintf(&sb, "Abandoned: %d\n", stats.Abandoned)
+	}
+
+	if len(stats.Campaigns) > 0 {
+		campaigns := make([]string, 0, len(stats.Campaigns))
+		for campaign := range stats.Campaigns {
+			campaigns = append(campaigns, campaign)
+		}
+		sort.Strings(campaigns)
+
+		sb.WriteString("\nCampaigns (started / completed):\n")
+		for _, campaign := range campaigns {
+			c := stats.Campaigns[campaign]
+			fmt.Fprintf(&sb, "• %s: %d / %d\n", campaign, c.Started, c.Completed)
+		}
+	}
+
+	if len(stats.DropOff) == 0 {
+		return strings.TrimSuffix(sb.String(), "\n")
+	}
+
+	// Sort by number of users, most dropped first
+	questionIDs := make([]string, 0, len(stats.DropOff))
+	for questionID := range stats.DropOff {
+		questionIDs = append(questionIDs, questionID)
+	}
+	sort.Slice(questionIDs, func(i, j int) bool {
+		if stats.DropOff[questionIDs[i]] != stats.DropOff[questionIDs[j]] {
+			return stats.DropOff[questionIDs[i]] > stats.DropOff[questionIDs[j]]
+		}
+		return questionIDs[i] < questionIDs[j]
+	})
+
+	sb.WriteString("\nIn progress / dropped off:\n")
+	for _, questionID := range questionIDs {
+		fmt.Fprintf(&sb, "• %s: %d\n", questionID, stats.DropOff[questionID])
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatFunnel formats survey funnel for display, one line per question
+func formatFunnel(funnel services.Funnel) string {
+	var sb strings.Builder
+
+	sb.WriteString("📉 Funnel")
+	if funnel.SurveyID != "" {
+		fmt.Fprintf(&sb, " of %s", funnel.SurveyID)
+	}
+	sb.WriteString("\n\n")
+	fmt.Fprintf(&sb, "Started: %d, completed: %d, in progress: %d, abandoned: %d\n", funnel.Started, funnel.Completed, funnel.InProgress, funnel.Abandoned)
+	if funnel.TopExit != "" {
+		fmt.Fprintf(&sb, "Most common exit: %s\n", funnel.TopExit)
+	}
+
+	sb.WriteString("\nQuestion: reached, went on, median time, in progress, exits\n")
+	for _, step := range funnel.Steps {
+		fmt.Fprintf(&sb, "• %s: %d, %.0f%%, %s, %d, %d\n", step.QuestionID, step.Reached, step.Conversion*100, step.MedianTime, step.InProgress, step.Exits)
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatOutbox formats pending outbox items for display, listing stuck ones oldest first
+func formatOutbox(items []outbox.Item) string {
+	var stuck []outbox.Item
+	for _, item := range items {
+		if item.Stuck() {
+			stuck = append(stuck, item)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📦 Outbox\n\n")
+	fmt.Fprintf(&sb, "Pending: %d, stuck: %d", len(items), len(stuck))
+	if len(stuck) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\n\nStuck deliveries:")
+	for i, item := range stuck {
+		if i == maxOutboxItems {
+			fmt.Fprintf(&sb, "\n…and %d more", len(stuck)-maxOutboxItems)
+			break
+		}
+		fmt.Fprintf(&sb, "\n• %s → %s: user %d, %d attempts, next at %s\n  %s",
+			item.Submission.ID, item.Sink, item.Submission.UserID, item.Attempts,
+			item.NextAttemptAt.Format(outboxTimeLayout), item.LastError)
+	}
+	return sb.String()
+}
+
+// sortedKeys returns map keys in alphabetical order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
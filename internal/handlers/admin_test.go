@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+	"tlgbot/internal/outbox"
+	"tlgbot/internal/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const adminID = int64(1)
+
+func newCommandMessage(fromID int64, command, args string) *tgbotapi.Message {
+	text := "/" + command
+	if args != "" {
+		text += " " + args
+	}
+	return &tgbotapi.Message{
+		From: &tgbotapi.User{ID: fromID, FirstName: testUserName},
+		Text: text,
+		Entities: []tgbotapi.MessageEntity{
+			{Type: "bot_command", Offset: 0, Length: len(command) + 1},
+		},
+	}
+}
+
+func createAdminTestHandler(t *testing.T) (*TelegramHandler, *mockTelegramBot, *services.UserStateManager) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	handler.config.AdminUserIDs = []int64{adminID}
+	handler.broadcastInterval = 0
+
+	finished := userStateManager.GetOrCreateUserState(10, "Alice")
+	finished.CurrentQuestionID = "end"
+	finished.AddAnswer("How are you today?", "Good")
+	finished.Complete(10, "end", finished.UpdatedAt)
+
+	dropped := userStateManager.GetOrCreateUserState(11, "Bob")
+	dropped.CurrentQuestionID = "question1"
+
+	return handler, mockBot, userStateManager
+}
+
+func TestAdminCommandsRefusedForNonAdmin(t *testing.T) {
+	handler, mockBot, userStateManager := createAdminTestHandler(t)
+
+	for _, command := range []string{"stats", "funnel", "outbox", "export", "broadcast", "reset_user"} {
+		t.Run(command, func(t *testing.T) {
+			mockBot.sendMessageCalled = false
+			mockBot.lastDocumentName = ""
+
+			handler.handleCommand(context.Background(), newCommandMessage(userID, command, "10"), nil)
+
+			if mockBot.sendMessageCalled || mockBot.lastDocumentName != "" {
+				t.Error("Expected no response for non-admin user")
+			}
+		})
+	}
+
+	if userStateManager.GetUserState(10) == nil {
+		t.Error("Expected user state to be kept")
+	}
+}
+
+func TestAdminStatsCommand(t *testing.T) {
+	handler, mockBot, _ := createAdminTestHandler(t)
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "stats", ""), nil)
+
+	for _, want := range []string{"Started: 2", "Completed: 1", "  • end: 1", "• question1: 1"} {
+		if !strings.Contains(mockBot.lastMessage, want) {
+			t.Errorf("Expected stats to contain %q, got %q", want, mockBot.lastMessage)
+		}
+	}
+}
+
+func TestAdminFunnelCommand(t *testing.T) {
+	handler, mockBot, userStateManager := createAdminTestHandler(t)
+	expired := userStateManager.GetOrCreateUserState(12, "Carol")
+	expired.CurrentQuestionID = "question1"
+	expired.Expire(time.Now())
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "funnel", ""), nil)
+
+	// Bob is still answering, only the expired attempt of Carol is abandoned
+	for _, want := range []string{"📉 Funnel", "Started: 3, completed: 1, in progress: 1, abandoned: 1", "Most common exit: question1", "• question1: 2, 0%, 0s, 1, 1"} {
+		if !strings.Contains(mockBot.lastMessage, want) {
+			t.Errorf("Expected funnel to contain %q, got %q", want, mockBot.lastMessage)
+		}
+	}
+
+	userStateManager.DeleteUserState(10)
+	userStateManager.DeleteUserState(11)
+	userStateManager.DeleteUserState(12)
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "funnel", ""), nil)
+	if mockBot.lastMessage != "No survey attempts yet" {
+		t.Errorf("Expected message about no attempts, got %q", mockBot.lastMessage)
+	}
+}
+
+// failingSink fails every delivery
+type failingSink struct{}
+
+func (failingSink) Deliver(_ *models.Submission) error {
+	return errors.New("sink is down")
+}
+
+func TestAdminOutboxCommand(t *testing.T) {
+	handler, mockBot, _ := createAdminTestHandler(t)
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "outbox", ""), nil)
+	if mockBot.lastMessage != "Outbox is disabled, set OUTBOX_DIR to enable it" {
+		t.Errorf("Expected message about disabled outbox, got %q", mockBot.lastMessage)
+	}
+
+	box, err := outbox.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	box.Register("webhook", failingSink{})
+	handler.SetOutbox(box)
+	submission := &models.Submission{ID: "10-1", UserID: 10}
+	if err := box.Deliver(submission); err != nil {
+		t.Fatalf("Failed to store submission: %v", err)
+	}
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "outbox", ""), nil)
+	if !strings.Contains(mockBot.lastMessage, "Pending: 1, stuck: 0") || strings.Contains(mockBot.lastMessage, "Stuck deliveries") {
+		t.Errorf("Expected a pending item that is not stuck, got %q", mockBot.lastMessage)
+	}
+
+	box.RunDue(context.Background())
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "outbox", ""), nil)
+	if !strings.Contains(mockBot.lastMessage, "Pending: 1, stuck: 0") {
+		t.Errorf("Expected the failed item to stay pending, got %q", mockBot.lastMessage)
+	}
+}
+
+func TestFormatOutbox(t *testing.T) {
+	nextAttemptAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	stuck := outbox.Item{
+		Sink:          "webhook",
+		Submission:    &models.Submission{ID: "10-1", UserID: 10},
+		Attempts:      outbox.StuckAttempts,
+		NextAttemptAt: nextAttemptAt,
+		LastError:     "unexpected status 503 Service Unavailable",
+	}
+	retrying := outbox.Item{Sink: "admin_chat", Submission: &models.Submission{ID: "11-1", UserID: 11}, Attempts: 1}
+
+	tests := []struct {
+		name     string
+		items    []outbox.Item
+		expected string
+	}{
+		{name: "empty", expected: "📦 Outbox\n\nPending: 0, stuck: 0"},
+		{name: "retrying only", items: []outbox.Item{retrying}, expected: "📦 Outbox\n\nPending: 1, stuck: 0"},
+		{
+			name:  "stuck listed",
+			items: []outbox.Item{stuck, retrying},
+			expected: "📦 Outbox\n\nPending: 2, stuck: 1\n\nStuck deliveries:\n" +
+				"• 10-1 → webhook: user 10, 3 attempts, next at 2024-05-01 12:00:00 UTC\n  unexpected status 503 Service Unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatOutbox(tt.items); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAdminExportCommand(t *testing.T) {
+	handler, mockBot, _ := createAdminTestHandler(t)
+
+	tests := []struct {
+		name       string
+		args       string
+		wantSuffix string
+		wantData   string
+	}{
+		{"default format", "", ".csv", "survey_id,user_id,username,name"},
+		{"json format", "json", ".json", `"user_id": 10`},
+		{"xlsx format", "xlsx", ".xlsx", "PK"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler.handleCommand(context.Background(), newCommandMessage(adminID, "export", tt.args), nil)
+
+			if !strings.HasSuffix(mockBot.lastDocumentName, tt.wantSuffix) {
+				t.Errorf("Expected file name with suffix %s, got %s", tt.wantSuffix, mockBot.lastDocumentName)
+			}
+			if !strings.Contains(string(mockBot.lastDocument), tt.wantData) {
+				t.Errorf("Expected export to contain %q, got %q", tt.wantData, mockBot.lastDocument)
+			}
+		})
+	}
+}
+
+func TestAdminExportErrors(t *testing.T) {
+	handler, mockBot, _ := createAdminTestHandler(t)
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "export", "xml"), nil)
+	if mockBot.lastMessage != "Usage: /export [csv|json|jsonl|xlsx] [survey_id]" {
+		t.Errorf("Expected usage for an unknown format, got %q", mockBot.lastMessage)
+	}
+
+	mockBot.sendDocumentErr = errors.New("connection reset")
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "export", "csv"), nil)
+	if mockBot.lastMessage != "Failed to send the export file, see the bot log for details" {
+		t.Errorf("Expected send failure to be reported, got %q", mockBot.lastMessage)
+	}
+}
+
+func TestAdminExportKeepsCompletedAttempts(t *testing.T) {
+	handler, mockBot, userStateManager := createAdminTestHandler(t)
+
+	// Alice started over after completing the survey
+	userStateManager.GetUserState(10).Reset()
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "export", "json"), nil)
+	if !strings.Contains(string(mockBot.lastDocument), `"user_id": 10`) {
+		t.Errorf("Expected the completed attempt in the export, got %s", mockBot.lastDocument)
+	}
+	if strings.Contains(string(mockBot.lastDocument), `"user_id": 11`) {
+		t.Errorf("Expected unfinished attempts to be left out, got %s", mockBot.lastDocument)
+	}
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "stats", ""), nil)
+	if !strings.Contains(mockBot.lastMessage, "Completed: 1") {
+		t.Errorf("Expected the completed attempt in stats, got %q", mockBot.lastMessage)
+	}
+}
+
+func TestAdminBroadcastCommand(t *testing.T) {
+	handler, mockBot, _ := createAdminTestHandler(t)
+	mockBot.sentMessageUserIDs = nil
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "broadcast", "Hello everyone"), nil)
+
+	// Two users plus the report to admin
+	expected := []int64{10, 11, adminID}
+	if len(mockBot.sentMessageUserIDs) != len(expected) {
+		t.Fatalf("Expected %d messages, got %d", len(expected), len(mockBot.sentMessageUserIDs))
+	}
+	for i, id := range expected {
+		if mockBot.sentMessageUserIDs[i] != id {
+			t.Errorf("Expected message %d to user %d, got %d", i, id, mockBot.sentMessageUserIDs[i])
+		}
+	}
+	if mockBot.lastMessage != "Broadcast finished: 2 sent, 0 failed" {
+		t.Errorf("Unexpected broadcast report: %s", mockBot.lastMessage)
+	}
+}
+
+func TestAdminResetUserCommand(t *testing.T) {
+	handler, mockBot, userStateManager := createAdminTestHandler(t)
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "reset_user", "10"), nil)
+
+	if userStateManager.GetUserState(10) != nil {
+		t.Error("Expected user state to be removed")
+	}
+	if mockBot.lastMessage != "User 10 has been reset" {
+		t.Errorf("Unexpected reply: %s", mockBot.lastMessage)
+	}
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "reset_user", "abc"), nil)
+	if mockBot.lastMessage != "Usage: /reset_user <user_id>" {
+		t.Errorf("Unexpected reply: %s", mockBot.lastMessage)
+	}
+}
+
+func TestAdminResetUserWaitsForUpdate(t *testing.T) {
+	handler, _, userStateManager := createAdminTestHandler(t)
+
+	// An update of user 11 is being handled
+	unlock := userStateManager.LockUser(11)
+	done := make(chan struct{})
+	go func() {
+		handler.handleCommand(context.Background(), newCommandMessage(adminID, "reset_user", "11"), nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected reset to wait for the update of the user")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-done
+	if userStateManager.GetUserState(11) != nil {
+		t.Error("Expected user state to be removed after the update")
+	}
+}
+
+func TestAdminResetSelf(t *testing.T) {
+	handler, mockBot, userStateManager := createAdminTestHandler(t)
+
+	done := make(chan struct{})
+	go func() {
+		handler.HandleMessage(context.Background(), newCommandMessage(adminID, "reset_user", "1"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Admin resetting themselves got stuck")
+	}
+	if userStateManager.GetUserState(adminID) != nil {
+		t.Error("Expected admin state to be removed")
+	}
+	if mockBot.lastMessage != "User 1 has been reset" {
+		t.Errorf("Unexpected reply: %s", mockBot.lastMessage)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"tlgbot/internal/models"
+	"tlgbot/internal/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// registerCommands registers built-in bot commands
+func (h *TelegramHandler) registerCommands() {
+	commands := []Command{
+		{Name: "start", Description: "Start the survey", Handler: h.cmdStart},
+		{Name: "help", Description: "Show available commands", Handler: h.cmdHelp},
+		{Name: "restart", Description: "Clear answers and start over", Handler: h.cmdRestart},
+		{Name: "cancel", Description: "Cancel the survey", Handler: h.cmdCancel},
+		{Name: "status", Description: "Show survey progress", Handler: h.cmdStatus},
+		{Name: "stats", Description: "Survey statistics: /stats [survey_id]", AdminOnly: true, Handler: h.cmdStats},
+		{Name: "funnel", Description: "Drop-off funnel: /funnel [survey_id]", AdminOnly: true, Handler: h.cmdFunnel},
+		{Name: "outbox", Description: "Undelivered submissions", AdminOnly: true, Handler: h.cmdOutbox},
+		{Name: "export", Description: "Export submissions: /export [csv|json|jsonl|xlsx] [survey_id]", AdminOnly: true, Handler: h.cmdExport},
+		{Name: "broadcast", Description: "Message all users: /broadcast <text>", AdminOnly: true, Handler: h.cmdBroadcast},
+		{Name: "reset_user", Description: "Reset user: /reset_user <id>", AdminOnly: true, Handler: h.cmdResetUser},
+	}
+
+	for _, cmd := range commands {
+		if err := h.commands.Register(cmd); err != nil {
+			// Built-in commands are static, so this is a programming error
+			panic(err)
+		}
+	}
+}
+
+// cmdStart starts the survey or offers to resume an unfinished one.
+// Deep link payload passed as /start argument may select survey and entry question
+// and carries campaign attribution.
+func (h *TelegramHandler) cmdStart(ctx context.Context, message *tgbotapi.Message, userState *models.UserState) error {
+	userID := message.From.ID
+
+	payload := h.parseStartPayload(ctx, message.CommandArguments())
+	userState.SetAttribution(payload.Campaign, payload.Source)
+
+	if payload.SurveyID != "" || payload.QuestionID != "" {
+		surveyID := payload.SurveyID
+		if surveyID == "" {
+			surveyID = userState.SurveyID
+		}
+
+		if payload.QuestionID == "" && userState.IsInProgress() && userState.SurveyID == surveyID {
+			return h.bot.SendMessage(ctx, userID, resumePromptText, buildResumeKeyboard())
+		}
+
+		h.switchSurvey(userState, surveyID)
+		h.startConversationAt(ctx, userID, userState, payload.QuestionID)
+		return nil
+	}
+
+	if userState.IsInProgress() {
+		return h.bot.SendMessage(ctx, userID, resumePromptText, buildResumeKeyboard())
+	}
+
+	if h.hasSurveyChoice() {
+		return h.bot.SendMessage(ctx, userID, surveyMenuText, buildSurveyMenu(h.questionManager.ListSurveys()))
+	}
+
+	h.startConversation(ctx, userID, userState)
+	return nil
+}
+
+// parseStartPayload decodes deep link payload and validates referenced survey.
+// A bare tag selects the survey with that ID, otherwise it is treated as campaign.
+func (h *TelegramHandler) parseStartPayload(ctx context.Context, args string) services.StartPayload {
+	payload := services.ParseStartPayload(args)
+
+	if payload.Tag != "" {
+		if _, err := h.questionManager.GetSurvey(payload.Tag); err == nil {
+			payload.SurveyID = payload.Tag
+		} else {
+			payload.Campaign = payload.Tag
+		}
+	}
+
+	if payload.SurveyID != "" {
+		if _, err := h.questionManager.GetSurvey(payload.SurveyID); err != nil {
+			slog.WarnContext(ctx, "Ignoring unknown survey in deep link", "deep_link_survey_id", payload.SurveyID)
+			payload.SurveyID = ""
+		}
+	}
+
+	return payload
+}
+
+// cmdHelp lists commands available to the user
+func (h *TelegramHandler) cmdHelp(ctx context.Context, message *tgbotapi.Message, _ *models.UserState) error {
+	var sb strings.Builder
+	sb.WriteString("Available commands:\n")
+	for _, cmd := range h.commands.List(h.config.IsAdmin(message.From.ID)) {
+		fmt.Fprintf(&sb, "/%s - %s\n", cmd.Name, cmd.Description)
+	}
+	return h.bot.SendMessage(ctx, message.From.ID, strings.TrimSuffix(sb.String(), "\n"), nil)
+}
+
+// cmdRestart wipes current answers and starts the survey again
+func (h *TelegramHandler) cmdRestart(ctx context.Context, message *tgbotapi.Message, userState *models.UserState) error {
+	h.startConversation(ctx, message.From.ID, userState)
+	return nil
+}
+
+// cmdCancel abandons the current survey
+func (h *TelegramHandler) cmdCancel(ctx context.Context, message *tgbotapi.Message, userState *models.UserState) error {
+	if userState.CurrentQuestionID == "" {
+		return h.bot.SendMessage(ctx, message.From.ID, "There is no survey in progress.", nil)
+	}
+	// The expired attempt is kept as it is, it was counted as abandoned when it expired
+	if userState.IsExpired() {
+		return h.bot.SendMessage(ctx, message.From.ID, expiredInputText, nil)
+	}
+
+	h.recordDropOff(userState)
+	userState.Reset()
+	h.userStateManager.SetUserState(message.From.ID, userState)
+	return h.bot.SendMessage(ctx, message.From.ID, "Survey cancelled. Send /start to begin again.", nil)
+}
+
+// cmdStatus shows user's survey progress
+func (h *TelegramHandler) cmdStatus(ctx context.Context, message *tgbotapi.Message, userState *models.UserState) error {
+	var text string
+	switch {
+	case userState.CurrentQuestionID == "":
+		text = "You haven't started the survey yet. Send /start to begin."
+	case userState.IsCompleted():
+		text = fmt.Sprintf("You have completed the survey. Answers given: %d.", len(userState.Answers))
+	case userState.IsExpired():
+		text = fmt.Sprintf("Your survey has expired. Answers given: %d. Send /start to begin again.", len(userState.Answers))
+	default:
+		text = fmt.Sprintf("Survey in progress. Answers given: %d.", len(userState.Answers))
+		if question, err := h.questionManager.GetSurveyQuestion(userState.SurveyID, userState.CurrentQuestionID); err == nil {
+			if display := question.GetDisplayText(); display != "" {
+				text += "\nCurrent question: " + strings.ReplaceAll(display, "{name}", userState.Name)
+			}
+		}
+	}
+
+	return h.bot.SendMessage(ctx, message.From.ID, text, nil)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHelpCommand(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	handler.config.AdminUserIDs = []int64{adminID}
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "help", ""), userState)
+	if !strings.Contains(mockBot.lastMessage, "/status - ") {
+		t.Errorf("Expected help to list /status, got %q", mockBot.lastMessage)
+	}
+	if strings.Contains(mockBot.lastMessage, "/stats") {
+		t.Errorf("Expected help to hide admin commands, got %q", mockBot.lastMessage)
+	}
+
+	handler.handleCommand(context.Background(), newCommandMessage(adminID, "help", ""), userState)
+	if !strings.Contains(mockBot.lastMessage, "/stats - ") {
+		t.Errorf("Expected help to list admin commands for admin, got %q", mockBot.lastMessage)
+	}
+}
+
+func TestRestartCommand(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+	userState.CurrentQuestionID = "question1"
+	userState.AddAnswer("Welcome! What's your name?", "Continue")
+
+	mockBot.processQuestionCalled = false
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "restart", ""), userState)
+
+	if len(userState.Answers) != 0 {
+		t.Errorf("Expected answers to be cleared, got %v", userState.Answers)
+	}
+	if userState.CurrentQuestionID != startQuestionID {
+		t.Errorf("Expected current question %s, got %s", startQuestionID, userState.CurrentQuestionID)
+	}
+	if !mockBot.processQuestionCalled {
+		t.Error("Expected start question to be sent")
+	}
+}
+
+func TestCancelCommand(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "cancel", ""), userState)
+	if mockBot.lastMessage != "There is no survey in progress." {
+		t.Errorf("Unexpected reply: %s", mockBot.lastMessage)
+	}
+
+	userState.CurrentQuestionID = "question1"
+	userState.AddAnswer("Welcome! What's your name?", "Continue")
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "cancel", ""), userState)
+
+	if userState.CurrentQuestionID != "" || len(userState.Answers) != 0 {
+		t.Errorf("Expected survey to be abandoned, got %+v", userState)
+	}
+	if !strings.HasPrefix(mockBot.lastMessage, "Survey cancelled.") {
+		t.Errorf("Unexpected reply: %s", mockBot.lastMessage)
+	}
+	if len(userState.DropOffs) != 1 || userState.DropOffs[0].Visits[0].QuestionID != "question1" {
+		t.Errorf("Expected cancelled attempt to be kept as a drop-off at question1, got %+v", userState.DropOffs)
+	}
+}
+
+func TestCancelExpiredSurvey(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+	userState.Reach("question1", time.Now())
+	userState.Expire(time.Now())
+
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "cancel", ""), userState)
+
+	if mockBot.lastMessage != expiredInputText {
+		t.Errorf("Unexpected reply: %s", mockBot.lastMessage)
+	}
+	if !userState.IsExpired() || userState.CurrentQuestionID != "question1" || len(userState.DropOffs) != 0 {
+		t.Errorf("Expected expired attempt to be kept as it is, got %+v", userState)
+	}
+}
+
+func TestStatusCommand(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	tests := []struct {
+		name              string
+		currentQuestionID string
+		expected          string
+	}{
+		{"not started", "", "You haven't started the survey yet."},
+		{"in progress", "question1", "Current question: How are you today?"},
+		{"completed", "end", "You have completed the survey."},
+		{"expired", "question1", "Your survey has expired."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userState.CurrentQuestionID = tt.currentQuestionID
+			switch tt.name {
+			case "completed":
+				userState.Complete(userID, "end", time.Now())
+			case "expired":
+				userState.CompletedAt = time.Time{}
+				userState.Expire(time.Now())
+			}
+			handler.handleCommand(context.Background(), newCommandMessage(userID, "status", ""), userState)
+
+			if !strings.Contains(mockBot.lastMessage, tt.expected) {
+				t.Errorf("Expected status to contain %q, got %q", tt.expected, mockBot.lastMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tlgbot/internal/services"
+)
+
+func TestStartWithCampaignPayload(t *testing.T) {
+	handler, _, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "c-spring__src-instagram"), userState)
+
+	if userState.Campaign != "spring" || userState.Source != "instagram" {
+		t.Errorf("Expected attribution spring/instagram, got %s/%s", userState.Campaign, userState.Source)
+	}
+	if userState.CurrentQuestionID != startQuestionID {
+		t.Errorf("Expected start question, got %s", userState.CurrentQuestionID)
+	}
+
+	// Attribution is carried to submissions
+	submission := userState.Complete(userID, "end", time.Now())
+	if submission.Campaign != "spring" || submission.Source != "instagram" {
+		t.Errorf("Expected attribution in submission, got %+v", submission)
+	}
+}
+
+func TestStartWithBareTagPayload(t *testing.T) {
+	handler, _, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	// Not a survey ID, so the tag is a campaign
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "newsletter"), userState)
+
+	if userState.Campaign != "newsletter" {
+		t.Errorf("Expected campaign newsletter, got %q", userState.Campaign)
+	}
+}
+
+func TestStartWithEntryQuestionPayload(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "q-question1__c-ads"), userState)
+
+	if userState.CurrentQuestionID != "question1" {
+		t.Errorf("Expected entry question question1, got %s", userState.CurrentQuestionID)
+	}
+	if mockBot.lastQuestion == nil || mockBot.lastQuestion.ID != "question1" {
+		t.Errorf("Expected question1 to be sent, got %+v", mockBot.lastQuestion)
+	}
+
+	// Unknown entry question falls back to the start question
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "q-missing"), userState)
+	if userState.CurrentQuestionID != startQuestionID {
+		t.Errorf("Expected start question, got %s", userState.CurrentQuestionID)
+	}
+	if userState.Campaign != "ads" {
+		t.Errorf("Expected campaign to be kept, got %q", userState.Campaign)
+	}
+}
+
+func TestStartWithTerminalEntryQuestionPayload(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	// A link straight to the end must not record an empty submission
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "q-end"), userState)
+
+	if userState.CurrentQuestionID != startQuestionID {
+		t.Errorf("Expected start question, got %s", userState.CurrentQuestionID)
+	}
+	if mockBot.lastQuestion == nil || mockBot.lastQuestion.ID != startQuestionID {
+		t.Errorf("Expected start question to be sent, got %+v", mockBot.lastQuestion)
+	}
+	if len(userState.Submissions) != 0 {
+		t.Errorf("Expected no submission, got %d", len(userState.Submissions))
+	}
+}
+
+func TestStartWithSurveyPayload(t *testing.T) {
+	handler, _, userStateManager := createMultiSurveyHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "s-feedback__c-spring"), userState)
+
+	if userState.SurveyID != "feedback" || userState.CurrentQuestionID != "intro" {
+		t.Errorf("Expected feedback survey at intro, got survey %q question %q", userState.SurveyID, userState.CurrentQuestionID)
+	}
+	if userState.Campaign != "spring" {
+		t.Errorf("Expected campaign spring, got %q", userState.Campaign)
+	}
+}
+
+func TestStartWithEntryQuestionStoresDefaultSurvey(t *testing.T) {
+	handler, _, userStateManager := createMultiSurveyHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	// Without s- the link enters the default survey, which is stored on the user
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "q-intro__c-spring"), userState)
+
+	if userState.SurveyID != "feedback" || userState.CurrentQuestionID != "intro" {
+		t.Errorf("Expected feedback survey at intro, got survey %q question %q", userState.SurveyID, userState.CurrentQuestionID)
+	}
+	if stats := services.CollectStats(userStateManager.GetAllUserStates(), "feedback"); stats.Started != 1 {
+		t.Errorf("Expected the attempt in stats of feedback, got %+v", stats)
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"tlgbot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// CommandHandler handles a bot command
+type CommandHandler func(ctx context.Context, message *tgbotapi.Message, userState *models.UserState) error
+
+// Command describes a bot command
+type Command struct {
+	Name        string
+	Description string
+	AdminOnly   bool
+	Handler     CommandHandler
+}
+
+// CommandRegistry keeps registered bot commands in registration order
+type CommandRegistry struct {
+	commands map[string]Command
+	order    []string
+}
+
+// commandSyncer is the part of Telegram API needed to publish commands
+type commandSyncer interface {
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// NewCommandRegistry creates an empty command registry
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{
+		commands: make(map[string]Command),
+	}
+}
+
+// Register adds a command to the registry
+func (r *CommandRegistry) Register(cmd Command) error {
+	if cmd.Name == "" {
+		return fmt.Errorf("command name cannot be empty")
+	}
+	if cmd.Handler == nil {
+		return fmt.Errorf("command %s has no handler", cmd.Name)
+	}
+	if _, exists := r.commands[cmd.Name]; exists {
+		return fmt.Errorf("duplicate command: %s", cmd.Name)
+	}
+
+	r.commands[cmd.Name] = cmd
+	r.order = append(r.order, cmd.Name)
+	return nil
+}
+
+// Get returns command by name
+func (r *CommandRegistry) Get(name string) (Command, bool) {
+	cmd, exists := r.commands[name]
+	return cmd, exists
+}
+
+// List returns commands in registration order, optionally including admin-only ones
+func (r *CommandRegistry) List(includeAdmin bool) []Command {
+	commands := make([]Command, 0, len(r.order))
+	for _, name := range r.order {
+		cmd := r.commands[name]
+		if cmd.AdminOnly && !includeAdmin {
+			continue
+		}
+		commands = append(commands, cmd)
+	}
+	return commands
+}
+
+// Sync publishes command list to Telegram via setMyCommands.
+// Regular users see public commands, admins additionally see admin-only ones.
+func (r *CommandRegistry) Sync(api commandSyncer, adminIDs []int64) error {
+	publicCmd := tgbotapi.NewSetMyCommands(toBotCommands(r.List(false))...)
+	if _, err := api.Request(publicCmd); err != nil {
+		return fmt.Errorf("failed to set commands: %w", err)
+	}
+
+	allCommands := toBotCommands(r.List(true))
+	for _, adminID := range adminIDs {
+		adminCmd := tgbotapi.NewSetMyCommandsWithScope(tgbotapi.NewBotCommandScopeChat(adminID), allCommands...)
+		if _, err := api.Request(adminCmd); err != nil {
+			return fmt.Errorf("failed to set admin commands for %d: %w", adminID, err)
+		}
+	}
+
+	return nil
+}
+
+// toBotCommands converts commands to Telegram representation
+func toBotCommands(commands []Command) []tgbotapi.BotCommand {
+	botCommands := make([]tgbotapi.BotCommand, len(commands))
+	for i, cmd := range commands {
+		botCommands[i] = tgbotapi.BotCommand{Command: cmd.Name, Description: cmd.Description}
+	}
+	return botCommands
+}
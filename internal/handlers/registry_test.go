@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"tlgbot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// mockCommandSyncer records setMyCommands requests
+type mockCommandSyncer struct {
+	requests []tgbotapi.SetMyCommandsConfig
+	err      error
+}
+
+func (m *mockCommandSyncer) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if cfg, ok := c.(tgbotapi.SetMyCommandsConfig); ok {
+		m.requests = append(m.requests, cfg)
+	}
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func noopCommand(_ context.Context, _ *tgbotapi.Message, _ *models.UserState) error {
+	return nil
+}
+
+func createTestRegistry(t *testing.T) *CommandRegistry {
+	registry := NewCommandRegistry()
+	commands := []Command{
+		{Name: "start", Description: "Start", Handler: noopCommand},
+		{Name: "stats", Description: "Stats", AdminOnly: true, Handler: noopCommand},
+		{Name: "help", Description: "Help", Handler: noopCommand},
+	}
+	for _, cmd := range commands {
+		if err := registry.Register(cmd); err != nil {
+			t.Fatalf("Failed to register %s: %v", cmd.Name, err)
+		}
+	}
+	return registry
+}
+
+func TestCommandRegistryRegister(t *testing.T) {
+	registry := createTestRegistry(t)
+
+	tests := []struct {
+		name string
+		cmd  Command
+	}{
+		{"duplicate name", Command{Name: "start", Handler: noopCommand}},
+		{"empty name", Command{Handler: noopCommand}},
+		{"missing handler", Command{Name: "other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := registry.Register(tt.cmd); err == nil {
+				t.Error("Expected registration error")
+			}
+		})
+	}
+
+	if _, exists := registry.Get("stats"); !exists {
+		t.Error("Expected stats command to be registered")
+	}
+}
+
+func TestCommandRegistryList(t *testing.T) {
+	registry := createTestRegistry(t)
+
+	public := registry.List(false)
+	if len(public) != 2 || public[0].Name != "start" || public[1].Name != "help" {
+		t.Errorf("Unexpected public commands: %+v", public)
+	}
+
+	all := registry.List(true)
+	if len(all) != 3 || all[1].Name != "stats" {
+		t.Errorf("Unexpected admin commands: %+v", all)
+	}
+}
+
+func TestCommandRegistrySync(t *testing.T) {
+	registry := createTestRegistry(t)
+	syncer := &mockCommandSyncer{}
+
+	if err := registry.Sync(syncer, []int64{adminID}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(syncer.requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(syncer.requests))
+	}
+	if syncer.requests[0].Scope != nil || len(syncer.requests[0].Commands) != 2 {
+		t.Errorf("Unexpected public commands request: %+v", syncer.requests[0])
+	}
+	adminRequest := syncer.requests[1]
+	if adminRequest.Scope == nil || adminRequest.Scope.ChatID != adminID || len(adminRequest.Commands) != 3 {
+		t.Errorf("Unexpected admin commands request: %+v", adminRequest)
+	}
+
+	syncer.err = errors.New("network error")
+	if err := registry.Sync(syncer, nil); err == nil {
+		t.Error("Expected error when Telegram request fails")
+	}
+}
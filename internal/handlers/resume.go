@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// Callback data of the resume prompt buttons
+const (
+	callbackResumeContinue = "resume:continue"
+	callbackResumeRestart  = "resume:restart"
+)
+
+// resumePromptText is shown when a user with unfinished survey sends /start
+const resumePromptText = "You have an unfinished survey. Would you like to continue where you left off or start over?"
+
+// buildResumeKeyboard builds keyboard for the resume prompt
+func buildResumeKeyboard() *tgbotapi.InlineKeyboardMarkup {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("▶️ Continue where I left off", callbackResumeContinue)),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🔄 Start over", callbackResumeRestart)),
+	)
+	return &keyboard
+}
+
+// isResumeCallback checks if callback data belongs to the resume prompt
+func isResumeCallback(data string) bool {
+	return data == callbackResumeContinue || data == callbackResumeRestart
+}
+
+// handleResumeCallback continues or restarts the survey depending on user's choice
+func (h *TelegramHandler) handleResumeCallback(ctx context.Context, userID int64, data string) error {
+	userState := h.userStateManager.GetUserState(userID)
+	if userState == nil {
+		return fmt.Errorf("user state not found for user %d", userID)
+	}
+
+	if data == callbackResumeRestart || !userState.IsInProgress() {
+		h.startConversation(ctx, userID, userState)
+		return nil
+	}
+
+	currentQuestion, err := h.questionManager.GetSurveyQuestion(userState.SurveyID, userState.CurrentQuestionID)
+	if err != nil {
+		// Question may have been removed from the survey, start over
+		h.startConversation(ctx, userID, userState)
+		return nil
+	}
+
+	if err := h.bot.ProcessQuestion(ctx, userID, currentQuestion); err != nil {
+		return fmt.Errorf("failed to resend current question: %w", err)
+	}
+	return h.bot.HandleAutoAdvance(ctx, userID, currentQuestion)
+}
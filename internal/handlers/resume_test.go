@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestStartOffersResumeForUnfinishedSurvey(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+	userState.CurrentQuestionID = "question1"
+	userState.AddAnswer("Welcome! What's your name?", "Continue")
+
+	mockBot.processQuestionCalled = false
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", ""), userState)
+
+	if mockBot.processQuestionCalled {
+		t.Error("Expected survey not to be restarted")
+	}
+	if mockBot.lastMessage != resumePromptText {
+		t.Errorf("Expected resume prompt, got %q", mockBot.lastMessage)
+	}
+	keyboard, ok := mockBot.lastKeyboard.(*tgbotapi.InlineKeyboardMarkup)
+	if !ok || len(keyboard.InlineKeyboard) != 2 {
+		t.Fatalf("Expected resume keyboard with 2 rows, got %#v", mockBot.lastKeyboard)
+	}
+	if userState.CurrentQuestionID != "question1" || len(userState.Answers) != 1 {
+		t.Errorf("Expected progress to be kept, got %+v", userState)
+	}
+}
+
+func TestStartAfterCompletionStartsNewAttempt(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+	userState.CurrentQuestionID = "end"
+	userState.AddAnswer("How are you today?", "Good")
+	userState.Complete(userID, "end", time.Now())
+
+	mockBot.processQuestionCalled = false
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", ""), userState)
+
+	if !mockBot.processQuestionCalled {
+		t.Error("Expected start question to be sent")
+	}
+	if userState.IsCompleted() || len(userState.Answers) != 0 {
+		t.Errorf("Expected clean attempt, got %+v", userState)
+	}
+	if len(userState.Submissions) != 1 || userState.Submissions[0].Answers["How are you today?"] != "Good" {
+		t.Errorf("Expected previous submission to be kept, got %+v", userState.Submissions)
+	}
+}
+
+func TestHandleResumeCallback(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            string
+		wantQuestionID  string
+		wantAnswerCount int
+	}{
+		{"continue", callbackResumeContinue, "question1", 1},
+		{"start over", callbackResumeRestart, startQuestionID, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockBot, userStateManager, _ := createTestHandler(t)
+			userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+			userState.CurrentQuestionID = "question1"
+			userState.AddAnswer("Welcome! What's your name?", "Continue")
+
+			handler.HandleCallbackQuery(context.Background(), &tgbotapi.CallbackQuery{
+				ID:   "callback_1",
+				From: &tgbotapi.User{ID: userID, FirstName: testUserName},
+				Data: tt.data,
+			})
+
+			if mockBot.processOptionAnswerCalled {
+				t.Error("Expected resume choice not to be treated as an option")
+			}
+			if mockBot.lastQuestion == nil || mockBot.lastQuestion.ID != tt.wantQuestionID {
+				t.Errorf("Expected question %s to be sent, got %+v", tt.wantQuestionID, mockBot.lastQuestion)
+			}
+			if userState.CurrentQuestionID != tt.wantQuestionID {
+				t.Errorf("Expected current question %s, got %s", tt.wantQuestionID, userState.CurrentQuestionID)
+			}
+			if len(userState.Answers) != tt.wantAnswerCount {
+				t.Errorf("Expected %d answers, got %d", tt.wantAnswerCount, len(userState.Answers))
+			}
+		})
+	}
+}
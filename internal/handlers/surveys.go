@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tlgbot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// callbackSurveyPrefix prefixes callback data of the survey menu buttons
+const callbackSurveyPrefix = "survey:"
+
+// surveyMenuText is shown above the survey menu
+const surveyMenuText = "Please choose a survey:"
+
+// buildSurveyMenu builds keyboard listing available surveys
+func buildSurveyMenu(surveys []models.Survey) *tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(surveys))
+	for i := range surveys {
+		btn := tgbotapi.NewInlineKeyboardButtonData(surveys[i].GetTitle(), callbackSurveyPrefix+surveys[i].ID)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
+
+// isSurveyCallback checks if callback data belongs to the survey menu
+func isSurveyCallback(data string) bool {
+	return strings.HasPrefix(data, callbackSurveyPrefix)
+}
+
+// handleSurveyCallback starts the survey chosen from the menu
+func (h *TelegramHandler) handleSurveyCallback(ctx context.Context, userID int64, data string) error {
+	userState := h.userStateManager.GetUserState(userID)
+	if userState == nil {
+		return fmt.Errorf("user state not found for user %d", userID)
+	}
+
+	surveyID := strings.TrimPrefix(data, callbackSurveyPrefix)
+	if _, err := h.questionManager.GetSurvey(surveyID); err != nil {
+		return fmt.Errorf("failed to get survey: %w", err)
+	}
+
+	h.switchSurvey(userState, surveyID)
+	h.startConversation(ctx, userID, userState)
+	return nil
+}
+
+// startQuestionID returns start question of the survey, falling back to the configured one
+func (h *TelegramHandler) startQuestionID(surveyID string) string {
+	survey, err := h.questionManager.GetSurvey(surveyID)
+	if err == nil && survey.StartQuestionID != "" {
+		return survey.StartQuestionID
+	}
+	return h.config.StartQuestionID
+}
+
+// hasSurveyChoice checks if user should pick a survey from the menu
+func (h *TelegramHandler) hasSurveyChoice() bool {
+	return len(h.questionManager.ListSurveys()) > 1
+}
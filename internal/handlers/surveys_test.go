@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"tlgbot/internal/models"
+	"tlgbot/internal/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func createMultiSurveyHandler(t *testing.T) (*TelegramHandler, *mockTelegramBot, *services.UserStateManager) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+
+	questionManager, err := services.NewSurveyQuestionManager([]models.Survey{
+		{
+			ID:              "feedback",
+			Title:           "Feedback",
+			StartQuestionID: "intro",
+			Questions: []models.Question{
+				{ID: "intro", Text: "Tell us about your experience", Options: []models.Option{{Text: "OK", NextID: "done"}}},
+				{ID: "done", Text: "Thanks!", Terminal: true},
+			},
+		},
+		{
+			ID:              "quiz",
+			StartQuestionID: "q1",
+			Questions:       []models.Question{{ID: "q1", Text: "2 + 2?"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create survey manager: %v", err)
+	}
+	handler.questionManager = questionManager
+
+	return handler, mockBot, userStateManager
+}
+
+func TestStartShowsSurveyMenu(t *testing.T) {
+	handler, mockBot, userStateManager := createMultiSurveyHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", ""), userState)
+
+	if mockBot.lastMessage != surveyMenuText {
+		t.Fatalf("Expected survey menu, got %q", mockBot.lastMessage)
+	}
+	keyboard, ok := mockBot.lastKeyboard.(*tgbotapi.InlineKeyboardMarkup)
+	if !ok || len(keyboard.InlineKeyboard) != 2 {
+		t.Fatalf("Expected menu with 2 surveys, got %#v", mockBot.lastKeyboard)
+	}
+	button := keyboard.InlineKeyboard[0][0]
+	if button.Text != "Feedback" || button.CallbackData == nil || *button.CallbackData != "survey:feedback" {
+		t.Errorf("Unexpected menu button: %+v", button)
+	}
+}
+
+func TestStartWithSurveyDeepLink(t *testing.T) {
+	handler, mockBot, userStateManager := createMultiSurveyHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "quiz"), userState)
+
+	if userState.SurveyID != "quiz" || userState.CurrentQuestionID != "q1" {
+		t.Errorf("Expected quiz survey at q1, got survey %q question %q", userState.SurveyID, userState.CurrentQuestionID)
+	}
+	if mockBot.lastQuestion == nil || mockBot.lastQuestion.Text != "2 + 2?" {
+		t.Errorf("Expected quiz start question to be sent, got %+v", mockBot.lastQuestion)
+	}
+
+	// Same survey link while in progress offers to resume
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "quiz"), userState)
+	if mockBot.lastMessage != resumePromptText {
+		t.Errorf("Expected resume prompt, got %q", mockBot.lastMessage)
+	}
+
+	// Another survey link switches survey
+	handler.handleCommand(context.Background(), newCommandMessage(userID, "start", "feedback"), userState)
+	if userState.SurveyID != "feedback" || userState.CurrentQuestionID != "intro" {
+		t.Errorf("Expected feedback survey at intro, got survey %q question %q", userState.SurveyID, userState.CurrentQuestionID)
+	}
+}
+
+func TestHandleSurveyCallback(t *testing.T) {
+	handler, _, userStateManager := createMultiSurveyHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+
+	handler.HandleCallbackQuery(context.Background(), &tgbotapi.CallbackQuery{
+		ID:   "callback_1",
+		From: &tgbotapi.User{ID: userID, FirstName: testUserName},
+		Data: "survey:feedback",
+	})
+
+	if userState.SurveyID != "feedback" || userState.CurrentQuestionID != "intro" {
+		t.Errorf("Expected feedback survey at intro, got survey %q question %q", userState.SurveyID, userState.CurrentQuestionID)
+	}
+
+	// Moving forward stays within the survey
+	if err := handler.moveToNextQuestion(context.Background(), userID, "done"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := handler.moveToNextQuestion(context.Background(), userID, "q1"); err == nil {
+		t.Error("Expected error for question of another survey")
+	}
+}
@@ -2,20 +2,34 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"tlgbot/internal/bot"
+	"tlgbot/internal/logging"
+	"tlgbot/internal/metrics"
 	"tlgbot/internal/models"
+	"tlgbot/internal/outbox"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
 )
 
+// expiredInputText answers input to a survey that expired after inactivity
+const expiredInputText = "This survey has expired. Send /start to begin again."
+
 // TelegramHandler handles Telegram events
 type TelegramHandler struct {
 	bot              models.BotService
 	config           *models.Config
 	userStateManager models.UserStateService
 	questionManager  models.QuestionService
+
+	commands          *CommandRegistry
+	broadcastInterval time.Duration
+	metrics           *metrics.Metrics
+	outbox            *outbox.Outbox
 }
 
 // NewTelegramHandler creates a new Telegram handler
@@ -25,126 +39,297 @@ func NewTelegramHandler(
 	userStateManager models.UserStateService,
 	questionManager models.QuestionService,
 ) *TelegramHandler {
-	return &TelegramHandler{
+	h := &TelegramHandler{
 		bot:              telegramBot,
 		config:           config,
 		userStateManager: userStateManager,
 		questionManager:  questionManager,
+
+		commands:          NewCommandRegistry(),
+		broadcastInterval: defaultBroadcastInterval,
+	}
+	h.registerCommands()
+	return h
+}
+
+// Commands returns the command registry
+func (h *TelegramHandler) Commands() *CommandRegistry {
+	return h.commands
+}
+
+// SetMetrics enables recording of handler metrics
+func (h *TelegramHandler) SetMetrics(m *metrics.Metrics) {
+	h.metrics = m
+}
+
+// SetOutbox enables the admin view of submissions waiting in the outbox
+func (h *TelegramHandler) SetOutbox(box *outbox.Outbox) {
+	h.outbox = box
+}
+
+// HandleUpdate dispatches an incoming update to the matching handler
+func (h *TelegramHandler) HandleUpdate(update tgbotapi.Update) {
+	ctx := logging.With(context.Background(), logging.KeyUpdateID, update.UpdateID)
+
+	switch {
+	case update.Message != nil:
+		h.metrics.UpdateReceived("message")
+		h.HandleMessage(ctx, update.Message)
+	case update.CallbackQuery != nil:
+		h.metrics.UpdateReceived("callback_query")
+		h.HandleCallbackQuery(ctx, update.CallbackQuery)
+	case update.EditedMessage != nil:
+		h.metrics.UpdateReceived("edited_message")
+		slog.DebugContext(ctx, "Ignoring edited message")
+	default:
+		h.metrics.UpdateReceived("other")
+		slog.DebugContext(ctx, "Ignoring unsupported update")
 	}
 }
 
 // HandleMessage handles incoming messages
-func (h *TelegramHandler) HandleMessage(message *tgbotapi.Message) {
+func (h *TelegramHandler) HandleMessage(ctx context.Context, message *tgbotapi.Message) {
 	userID := message.From.ID
 	userName := bot.GetTelegramName(message.From)
+	ctx = logging.With(ctx, logging.KeyUserID, userID, logging.KeyChatID, chatID(message))
+	defer h.userStateManager.LockUser(userID)()
+	defer h.saveUserState(ctx, userID)
 
 	// Get or create user state
 	userState := h.userStateManager.GetOrCreateUserState(userID, userName)
+	userState.UserName = message.From.UserName
+	ctx = logging.WithUserState(ctx, userState)
 
 	if message.IsCommand() {
-		h.handleCommand(message, userState)
+		h.handleCommand(ctx, message, userState)
 	} else {
-		h.handleTextInput(message, userState)
+		h.handleTextInput(ctx, message, userState)
 	}
 }
 
 // HandleCallbackQuery handles callback queries
-func (h *TelegramHandler) HandleCallbackQuery(callback *tgbotapi.CallbackQuery) {
+func (h *TelegramHandler) HandleCallbackQuery(ctx context.Context, callback *tgbotapi.CallbackQuery) {
 	userID := callback.From.ID
 	data := callback.Data
+	ctx = logging.With(ctx, logging.KeyUserID, userID, logging.KeyChatID, chatID(callback.Message))
+	defer h.userStateManager.LockUser(userID)()
+	defer h.saveUserState(ctx, userID)
 
-	// Acknowledge callback - это КРИТИЧЕСКИ ВАЖНО для Telegram!
-	callbackConfig := tgbotapi.NewCallback(callback.ID, "")
-	if _, err := h.bot.GetAPI().Request(callbackConfig); err != nil {
-		log.Printf("Error acknowledging callback: %v", err)
+	// Acknowledge callback, otherwise Telegram client keeps showing a loading indicator
+	if err := h.bot.AnswerCallback(ctx, callback.ID); err != nil {
+		slog.WarnContext(ctx, "Failed to acknowledge callback", "error", err)
 	}
 
 	userState := h.userStateManager.GetUserState(userID)
 	if userState == nil {
-		log.Printf("User state not found for user %d", userID)
+		slog.WarnContext(ctx, "User state not found for callback", "data", data)
+		return
+	}
+	ctx = logging.WithUserState(ctx, userState)
+
+	// Resume prompt and survey menu are not survey options
+	if isResumeCallback(data) {
+		if err := h.handleResumeCallback(ctx, userID, data); err != nil {
+			slog.ErrorContext(ctx, "Failed to handle resume choice", "data", data, "error", err)
+		}
+		return
+	}
+	if isSurveyCallback(data) {
+		if err := h.handleSurveyCallback(ctx, userID, data); err != nil {
+			slog.ErrorContext(ctx, "Failed to handle survey choice", "data", data, "error", err)
+		}
+		return
+	}
+
+	if userState.IsExpired() {
+		h.replyExpired(ctx, userID)
 		return
 	}
 
 	// Process option selection
-	if err := h.bot.ProcessOptionAnswer(userID, data); err != nil {
-		log.Printf("Error processing option answer: %v", err)
+	if err := h.bot.ProcessOptionAnswer(ctx, userID, data); err != nil {
+		slog.ErrorContext(ctx, "Failed to process option answer", "option", data, "error", err)
 	}
 }
 
 // handleCommand handles commands
-func (h *TelegramHandler) handleCommand(message *tgbotapi.Message, userState *models.UserState) {
-	switch message.Command() {
-	case "start":
-		h.startConversation(message.From.ID, userState)
-	default:
-		log.Printf("Unknown command: %s", message.Command())
+func (h *TelegramHandler) handleCommand(ctx context.Context, message *tgbotapi.Message, userState *models.UserState) {
+	cmd, exists := h.commands.Get(message.Command())
+	if !exists {
+		slog.InfoContext(ctx, "Unknown command", "command", message.Command())
+		return
+	}
+
+	if cmd.AdminOnly && !h.config.IsAdmin(message.From.ID) {
+		// Non-admins are refused silently
+		slog.WarnContext(ctx, "Admin command refused", "command", cmd.Name)
+		return
+	}
+
+	if err := cmd.Handler(ctx, message, userState); err != nil {
+		slog.ErrorContext(ctx, "Failed to handle command", "command", cmd.Name, "error", err)
 	}
 }
 
 // handleTextInput handles text input
-func (h *TelegramHandler) handleTextInput(message *tgbotapi.Message, userState *models.UserState) {
+func (h *TelegramHandler) handleTextInput(ctx context.Context, message *tgbotapi.Message, userState *models.UserState) {
 	if userState.CurrentQuestionID == "" {
+		slog.DebugContext(ctx, "Ignoring text input outside of survey")
+		return
+	}
+	if userState.IsExpired() {
+		h.replyExpired(ctx, message.From.ID)
 		return
 	}
 
-	currentQuestion, err := h.questionManager.GetQuestion(userState.CurrentQuestionID)
+	currentQuestion, err := h.questionManager.GetSurveyQuestion(userState.SurveyID, userState.CurrentQuestionID)
 	if err != nil {
-		log.Printf("Failed to get current question: %v", err)
+		slog.ErrorContext(ctx, "Failed to get current question", "error", err)
 		return
 	}
 
 	// Check if text input is expected
-	if currentQuestion.InputType != "" {
-		// Save answer and move to next question
-		if err := h.bot.ProcessAnswer(message.From.ID, message.Text); err != nil {
-			log.Printf("Failed to process answer: %v", err)
-			return
-		}
+	if currentQuestion.InputType == "" {
+		slog.DebugContext(ctx, "Ignoring text input for question without input")
+		return
+	}
 
-		// Move to next question if there are options
-		if len(currentQuestion.Options) > 0 {
-			nextQuestionID := currentQuestion.Options[0].NextID
-			if err := h.moveToNextQuestion(message.From.ID, nextQuestionID); err != nil {
-				log.Printf("Failed to move to next question: %v", err)
-			}
+	// Save answer and move to next question
+	if err := h.bot.ProcessAnswer(ctx, message.From.ID, message.Text); err != nil {
+		slog.ErrorContext(ctx, "Failed to process answer", "error", err)
+		return
+	}
+
+	// Move to next question if there are options
+	if len(currentQuestion.Options) > 0 {
+		nextQuestionID := currentQuestion.Options[0].NextID
+		if err := h.moveToNextQuestion(ctx, message.From.ID, nextQuestionID); err != nil {
+			slog.ErrorContext(ctx, "Failed to move to next question", "next_question_id", nextQuestionID, "error", err)
 		}
 	}
 }
 
-// startConversation starts conversation with user
-func (h *TelegramHandler) startConversation(userID int64, userState *models.UserState) {
-	startQuestion, err := h.questionManager.GetQuestion(h.config.StartQuestionID)
+// saveUserState persists state of the user after the update was handled
+func (h *TelegramHandler) saveUserState(ctx context.Context, userID int64) {
+	if err := h.userStateManager.SaveUserState(userID); err != nil {
+		slog.ErrorContext(ctx, "Failed to save user state", "error", err)
+	}
+}
+
+// replyExpired tells the user that the survey answered to has expired
+func (h *TelegramHandler) replyExpired(ctx context.Context, userID int64) {
+	slog.DebugContext(ctx, "Ignoring input to expired survey")
+	if err := h.bot.SendMessage(ctx, userID, expiredInputText, nil); err != nil {
+		slog.ErrorContext(ctx, "Failed to reply to input to expired survey", "error", err)
+	}
+}
+
+// startConversation starts a new survey attempt with clean answers
+func (h *TelegramHandler) startConversation(ctx context.Context, userID int64, userState *models.UserState) {
+	h.startConversationAt(ctx, userID, userState, "")
+}
+
+// startConversationAt starts a new survey attempt from the given entry question.
+// Empty or unknown entry question falls back to the survey start question.
+func (h *TelegramHandler) startConversationAt(ctx context.Context, userID int64, userState *models.UserState, entryQuestionID string) {
+	// Store the default survey by its ID, so reports of that survey include the attempt
+	if userState.SurveyID == "" {
+		if survey, err := h.questionManager.GetSurvey(""); err == nil {
+			userState.SurveyID = survey.ID
+		}
+	}
+
+	startQuestionID := h.startQuestionID(userState.SurveyID)
+	if entryQuestionID != "" {
+		entryQuestion, err := h.questionManager.GetSurveyQuestion(userState.SurveyID, entryQuestionID)
+		switch {
+		case err != nil:
+			slog.WarnContext(ctx, "Ignoring unknown entry question in deep link", "entry_question_id", entryQuestionID)
+		case entryQuestion.IsTerminal() || len(entryQuestion.Options) == 0:
+			// Entering at the end would complete the survey without a single answer
+			slog.WarnContext(ctx, "Ignoring terminal entry question in deep link", "entry_question_id", entryQuestionID)
+		default:
+			startQuestionID = entryQuestionID
+		}
+	}
+
+	startQuestion, err := h.questionManager.GetSurveyQuestion(userState.SurveyID, startQuestionID)
 	if err != nil {
-		log.Printf("Failed to get start question: %v", err)
+		slog.ErrorContext(ctx, "Failed to get start question", "start_question_id", startQuestionID, "error", err)
 		return
 	}
 
-	userState.CurrentQuestionID = h.config.StartQuestionID
+	h.recordDropOff(userState)
+	userState.Reset()
+	userState.StartedAt = time.Now()
+	userState.Reach(startQuestionID, userState.StartedAt)
 	h.userStateManager.SetUserState(userID, userState)
+	h.metrics.SurveyStarted(userState.SurveyID)
+	h.metrics.QuestionReached(userState.SurveyID, startQuestionID)
+	ctx = logging.WithUserState(ctx, userState)
+	slog.InfoContext(ctx, "Survey started")
 
-	if err := h.bot.ProcessQuestion(userID, startQuestion); err != nil {
-		log.Printf("Failed to process start question: %v", err)
+	if err := h.bot.ProcessQuestion(ctx, userID, startQuestion); err != nil {
+		slog.ErrorContext(ctx, "Failed to process start question", "error", err)
 		return
 	}
 
 	// Handle automatic transition
-	if err := h.bot.HandleAutoAdvance(userID, startQuestion); err != nil {
-		log.Printf("Failed to handle auto advance: %v", err)
+	if err := h.bot.HandleAutoAdvance(ctx, userID, startQuestion); err != nil {
+		slog.ErrorContext(ctx, "Failed to handle auto advance", "error", err)
 	}
 }
 
 // moveToNextQuestion moves to next question
-func (h *TelegramHandler) moveToNextQuestion(userID int64, nextQuestionID string) error {
-	nextQuestion, err := h.questionManager.GetQuestion(nextQuestionID)
+func (h *TelegramHandler) moveToNextQuestion(ctx context.Context, userID int64, nextQuestionID string) error {
+	userState := h.userStateManager.GetUserState(userID)
+	if userState == nil {
+		return fmt.Errorf("user state not found for user %d", userID)
+	}
+
+	nextQuestion, err := h.questionManager.GetSurveyQuestion(userState.SurveyID, nextQuestionID)
 	if err != nil {
 		return err
 	}
+	if nextQuestion, err = bot.FollowScoreBranches(h.questionManager, userState.SurveyID, nextQuestion, userState.Score()); err != nil {
+		return err
+	}
 
-	h.userStateManager.UpdateCurrentQuestion(userID, nextQuestionID)
+	h.userStateManager.UpdateCurrentQuestion(userID, nextQuestion.ID)
+	h.metrics.QuestionReached(userState.SurveyID, nextQuestion.ID)
+	ctx = logging.WithUserState(ctx, userState)
 
-	if err := h.bot.ProcessQuestion(userID, nextQuestion); err != nil {
+	if err := h.bot.ProcessQuestion(ctx, userID, nextQuestion); err != nil {
 		return err
 	}
 
-	return h.bot.HandleAutoAdvance(userID, nextQuestion)
+	return h.bot.HandleAutoAdvance(ctx, userID, nextQuestion)
+}
+
+// recordDropOff keeps an unfinished survey attempt being abandoned for reports and counts it.
+// Expired attempts were counted when they expired.
+func (h *TelegramHandler) recordDropOff(userState *models.UserState) {
+	if userState.IsInProgress() {
+		h.metrics.QuestionDroppedOff(userState.SurveyID, userState.CurrentQuestionID)
+	}
+	userState.Abandon(time.Now())
+}
+
+// switchSurvey selects survey for the next attempt, abandoning unfinished attempt of another survey
+func (h *TelegramHandler) switchSurvey(userState *models.UserState, surveyID string) {
+	if userState.SurveyID == surveyID {
+		return
+	}
+
+	h.recordDropOff(userState)
+	userState.Reset()
+	userState.SurveyID = surveyID
+}
+
+// chatID returns chat ID of the message, or zero when unknown
+func chatID(message *tgbotapi.Message) int64 {
+	if message == nil || message.Chat == nil {
+		return 0
+	}
+	return message.Chat.ID
 }
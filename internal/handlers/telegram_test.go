@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+	"tlgbot/internal/metrics"
 	"tlgbot/internal/models"
 	"tlgbot/internal/services"
+	"tlgbot/internal/storage"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -29,63 +36,73 @@ type mockTelegramBot struct {
 	lastAnswer                string
 	lastOption                string
 	lastQuestion              *models.Question
-
-	// Mock API for callback acknowledgment
-	api *tgbotapi.BotAPI
+	sentMessageUserIDs        []int64
+	lastKeyboard              interface{}
+	lastDocumentName          string
+	lastDocument              []byte
+	sendDocumentErr           error
+	answeredCallbacks         []string
 }
 
-func (m *mockTelegramBot) SendImages(userID int64, _ []string, _ int) error {
+func (m *mockTelegramBot) SendImages(_ context.Context, userID int64, _ []string, _ int) error {
 	m.sendImagesCalled = true
 	m.lastUserID = userID
 	return nil
 }
 
-func (m *mockTelegramBot) SendMessage(userID int64, text string, _ interface{}) error {
+func (m *mockTelegramBot) SendMessage(_ context.Context, userID int64, text string, keyboard interface{}) error {
 	m.sendMessageCalled = true
+	m.lastKeyboard = keyboard
 	m.lastUserID = userID
 	m.lastMessage = text
+	m.sentMessageUserIDs = append(m.sentMessageUserIDs, userID)
 	return nil
 }
 
-func (m *mockTelegramBot) SendMessages(userID int64, _ []string, _ string, _ interface{}) error {
+func (m *mockTelegramBot) SendDocument(_ context.Context, userID int64, fileName string, data []byte) error {
+	m.lastUserID = userID
+	m.lastDocumentName = fileName
+	m.lastDocument = data
+	return m.sendDocumentErr
+}
+
+func (m *mockTelegramBot) SendMessages(_ context.Context, userID int64, _ []string, _ string, _ interface{}) error {
 	m.sendMessagesCalled = true
 	m.lastUserID = userID
 	return nil
 }
 
-func (m *mockTelegramBot) ProcessQuestion(userID int64, question *models.Question) error {
+func (m *mockTelegramBot) ProcessQuestion(_ context.Context, userID int64, question *models.Question) error {
 	m.processQuestionCalled = true
 	m.lastUserID = userID
 	m.lastQuestion = question
 	return nil
 }
 
-func (m *mockTelegramBot) ProcessAnswer(userID int64, answer string) error {
+func (m *mockTelegramBot) ProcessAnswer(_ context.Context, userID int64, answer string) error {
 	m.processAnswerCalled = true
 	m.lastUserID = userID
 	m.lastAnswer = answer
 	return nil
 }
 
-func (m *mockTelegramBot) ProcessOptionAnswer(userID int64, optionText string) error {
+func (m *mockTelegramBot) ProcessOptionAnswer(_ context.Context, userID int64, optionText string) error {
 	m.processOptionAnswerCalled = true
 	m.lastUserID = userID
 	m.lastOption = optionText
 	return nil
 }
 
-func (m *mockTelegramBot) HandleAutoAdvance(userID int64, question *models.Question) error {
+func (m *mockTelegramBot) HandleAutoAdvance(_ context.Context, userID int64, question *models.Question) error {
 	m.handleAutoAdvanceCalled = true
 	m.lastUserID = userID
 	m.lastQuestion = question
 	return nil
 }
 
-func (m *mockTelegramBot) GetAPI() *tgbotapi.BotAPI {
-	if m.api == nil {
-		m.api = &tgbotapi.BotAPI{}
-	}
-	return m.api
+func (m *mockTelegramBot) AnswerCallback(_ context.Context, callbackID string) error {
+	m.answeredCallbacks = append(m.answeredCallbacks, callbackID)
+	return nil
 }
 
 func createTestHandler(_ *testing.T) (*TelegramHandler, *mockTelegramBot, *services.UserStateManager, *services.QuestionManager) {
@@ -201,7 +218,7 @@ func TestHandleMessage(t *testing.T) {
 			mockBot.processQuestionCalled = false
 			mockBot.processAnswerCalled = false
 
-			handler.HandleMessage(tt.message)
+			handler.HandleMessage(context.Background(), tt.message)
 
 			if tt.expectStart && !mockBot.processQuestionCalled {
 				t.Error("Expected ProcessQuestion to be called for start command")
@@ -247,7 +264,7 @@ func TestHandleCallbackQuery(t *testing.T) {
 			// Reset mock
 			mockBot.processOptionAnswerCalled = false
 
-			handler.HandleCallbackQuery(tt.callback)
+			handler.HandleCallbackQuery(context.Background(), tt.callback)
 
 			if tt.expectOption && !mockBot.processOptionAnswerCalled {
 				t.Error("Expected ProcessOptionAnswer to be called")
@@ -256,6 +273,11 @@ func TestHandleCallbackQuery(t *testing.T) {
 			if tt.expectOption && mockBot.lastOption != tt.callback.Data {
 				t.Errorf("Expected option %s, got %s", tt.callback.Data, mockBot.lastOption)
 			}
+
+			answered := mockBot.answeredCallbacks
+			if len(answered) == 0 || answered[len(answered)-1] != tt.callback.ID {
+				t.Errorf("Expected callback %s to be acknowledged, got %v", tt.callback.ID, answered)
+			}
 		})
 	}
 }
@@ -299,7 +321,7 @@ func TestHandleCommand(t *testing.T) {
 				},
 			}
 
-			handler.handleCommand(message, userState)
+			handler.handleCommand(context.Background(), message, userState)
 
 			if tt.expectStart {
 				if !mockBot.processQuestionCalled {
@@ -361,7 +383,7 @@ func TestHandleTextInput(t *testing.T) {
 				Text: tt.messageText,
 			}
 
-			handler.handleTextInput(message, userState)
+			handler.handleTextInput(context.Background(), message, userState)
 
 			if tt.expectProcessed {
 				if !mockBot.processAnswerCalled {
@@ -388,7 +410,7 @@ func TestStartConversation(t *testing.T) {
 	mockBot.processQuestionCalled = false
 	mockBot.handleAutoAdvanceCalled = false
 
-	handler.startConversation(userID, userState)
+	handler.startConversation(context.Background(), userID, userState)
 
 	// Check that ProcessQuestion was called
 	if !mockBot.processQuestionCalled {
@@ -414,6 +436,32 @@ func TestStartConversation(t *testing.T) {
 	if retrievedState.CurrentQuestionID != startQuestionID {
 		t.Errorf("Expected saved current question ID to be '%s', got %s", startQuestionID, retrievedState.CurrentQuestionID)
 	}
+	if len(userState.Visits) != 1 || userState.Visits[0].QuestionID != startQuestionID {
+		t.Errorf("Expected visit of the start question, got %v", userState.Visits)
+	}
+}
+
+func TestHandleMessageSavesUserState(t *testing.T) {
+	handler, _, _, _ := createTestHandler(t)
+	dir, err := storage.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+	userStateManager, err := services.NewPersistentUserStateManager(dir)
+	if err != nil {
+		t.Fatalf("NewPersistentUserStateManager failed: %v", err)
+	}
+	handler.userStateManager = userStateManager
+
+	handler.HandleMessage(context.Background(), newCommandMessage(userID, "start", ""))
+
+	states, err := dir.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	if state := states[userID]; state == nil || state.CurrentQuestionID != startQuestionID {
+		t.Errorf("Expected state at %s to be saved, got %+v", startQuestionID, state)
+	}
 }
 
 func TestMoveToNextQuestion(t *testing.T) {
@@ -445,7 +493,7 @@ func TestMoveToNextQuestion(t *testing.T) {
 			mockBot.processQuestionCalled = false
 			mockBot.handleAutoAdvanceCalled = false
 
-			err := handler.moveToNextQuestion(userID, tt.nextQuestionID)
+			err := handler.moveToNextQuestion(context.Background(), userID, tt.nextQuestionID)
 
 			if tt.expectError {
 				if err == nil {
@@ -490,7 +538,7 @@ func TestHandleCallbackQueryWithoutUserState(t *testing.T) {
 	// Reset mock
 	mockBot.processOptionAnswerCalled = false
 
-	handler.HandleCallbackQuery(callback)
+	handler.HandleCallbackQuery(context.Background(), callback)
 
 	// Should not process option answer when user state doesn't exist
 	if mockBot.processOptionAnswerCalled {
@@ -562,3 +610,52 @@ func TestMessageIsCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleUpdateRecordsMetrics(t *testing.T) {
+	handler, _, _, _ := createTestHandler(t)
+	m := metrics.New()
+	handler.SetMetrics(m)
+
+	handler.HandleUpdate(tgbotapi.Update{Message: newCommandMessage(userID, "start", "")})
+	handler.HandleUpdate(tgbotapi.Update{Message: newCommandMessage(userID, "cancel", "")})
+	handler.HandleUpdate(tgbotapi.Update{EditedMessage: &tgbotapi.Message{}})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+
+	for _, want := range []string{
+		`tlgbot_updates_received_total{type="message"} 2`,
+		`tlgbot_updates_received_total{type="edited_message"} 1`,
+		`tlgbot_surveys_started_total{survey="default"} 1`,
+		`tlgbot_question_arrivals_total{question="start",survey="default"} 1`,
+		`tlgbot_question_dropoffs_total{question="start",survey="default"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected metrics output to contain %q", want)
+		}
+	}
+}
+
+func TestInputToExpiredSurvey(t *testing.T) {
+	handler, mockBot, userStateManager, _ := createTestHandler(t)
+	userState := userStateManager.GetOrCreateUserState(userID, testUserName)
+	userState.CurrentQuestionID = "input_question"
+	userState.Expire(time.Now())
+
+	message := &tgbotapi.Message{From: &tgbotapi.User{ID: userID, FirstName: testUserName}, Text: "John"}
+	handler.handleTextInput(context.Background(), message, userState)
+
+	callback := &tgbotapi.CallbackQuery{ID: "callback_1", From: &tgbotapi.User{ID: userID}, Data: "Continue"}
+	handler.HandleCallbackQuery(context.Background(), callback)
+
+	if mockBot.processAnswerCalled || mockBot.processOptionAnswerCalled {
+		t.Error("Expected answers to expired survey to be ignored")
+	}
+	if len(mockBot.sentMessageUserIDs) != 2 || mockBot.lastMessage != expiredInputText {
+		t.Errorf("Expected expired notice for both inputs, got %d messages, last %q", len(mockBot.sentMessageUserIDs), mockBot.lastMessage)
+	}
+}
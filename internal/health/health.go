@@ -0,0 +1,167 @@
+// Package health provides liveness and readiness HTTP endpoints.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// checkTimeout limits the time a single readiness request may take
+const checkTimeout = 5 * time.Second
+
+// CheckFunc reports readiness of a component, returning nil when it is ready
+type CheckFunc func(ctx context.Context) error
+
+// namedCheck is a registered readiness check
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker runs registered readiness checks
+type Checker struct {
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// NewChecker creates a checker without checks
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add registers a readiness check under the given name
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Check runs all checks and returns errors of failed ones by check name
+func (c *Checker) Check(ctx context.Context) map[string]error {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	failed := make(map[string]error)
+	for _, nc := range checks {
+		if err := nc.check(ctx); err != nil {
+			failed[nc.name] = err
+		}
+	}
+	return failed
+}
+
+// response is the JSON body of health endpoints
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// LivenessHandler reports that the process is up
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeResponse(w, http.StatusOK, response{Status: "ok"})
+	})
+}
+
+// ReadinessHandler reports whether all checks pass
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		c.mu.RLock()
+		checks := make(map[string]string, len(c.checks))
+		for _, nc := range c.checks {
+			checks[nc.name] = "ok"
+		}
+		c.mu.RUnlock()
+
+		failed := c.Check(ctx)
+		for name, err := range failed {
+			checks[name] = err.Error()
+		}
+
+		if len(failed) > 0 {
+			slog.WarnContext(ctx, "Readiness check failed", "checks", checks)
+			writeResponse(w, http.StatusServiceUnavailable, response{Status: "unavailable", Checks: checks})
+			return
+		}
+		writeResponse(w, http.StatusOK, response{Status: "ok", Checks: checks})
+	})
+}
+
+// writeResponse writes JSON response with the given status code
+func writeResponse(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Warn("Failed to write health response", "error", err)
+	}
+}
+
+// Heartbeat records the time of the last successful activity of a component
+type Heartbeat struct {
+	mu   sync.RWMutex
+	last time.Time
+	now  func() time.Time
+}
+
+// NewHeartbeat creates a heartbeat that has not beaten yet
+func NewHeartbeat() *Heartbeat {
+	return &Heartbeat{now: time.Now}
+}
+
+// Beat records successful activity
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = h.now()
+}
+
+// Last returns the time of the last beat, zero if there was none
+func (h *Heartbeat) Last() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.last
+}
+
+// Check returns readiness check failing when the last beat is older than maxAge
+func (h *Heartbeat) Check(maxAge time.Duration) CheckFunc {
+	return func(_ context.Context) error {
+		last := h.Last()
+		if last.IsZero() {
+			return errors.New("no activity yet")
+		}
+		if age := h.now().Sub(last); age > maxAge {
+			return fmt.Errorf("last activity %s ago", age.Round(time.Second))
+		}
+		return nil
+	}
+}
+
+// Watch runs probe every interval until ctx is done, beating on success
+func (h *Heartbeat) Watch(ctx context.Context, interval time.Duration, probe func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := probe(); err != nil {
+			slog.WarnContext(ctx, "Health probe failed", "error", err)
+		} else {
+			h.Beat()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLivenessHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		checkErr       error
+		expectedStatus int
+		expectedCheck  string
+	}{
+		{"all checks pass", nil, http.StatusOK, "ok"},
+		{"failing check", errors.New("boom"), http.StatusServiceUnavailable, "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker()
+			checker.Add("always", func(context.Context) error { return nil })
+			checker.Add("component", func(context.Context) error { return tt.checkErr })
+
+			rec := httptest.NewRecorder()
+			checker.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+
+			var body response
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Checks["component"] != tt.expectedCheck {
+				t.Errorf("Expected component check %q, got %q", tt.expectedCheck, body.Checks["component"])
+			}
+			if body.Checks["always"] != "ok" {
+				t.Errorf("Expected passing check to be reported as ok, got %q", body.Checks["always"])
+			}
+		})
+	}
+}
+
+func TestHeartbeatCheck(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	heartbeat := NewHeartbeat()
+	heartbeat.now = func() time.Time { return now }
+	check := heartbeat.Check(time.Minute)
+
+	if err := check(context.Background()); err == nil {
+		t.Error("Expected error before first beat")
+	}
+
+	heartbeat.Beat()
+	now = now.Add(30 * time.Second)
+	if err := check(context.Background()); err != nil {
+		t.Errorf("Expected recent beat to pass, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := check(context.Background()); err == nil {
+		t.Error("Expected stale beat to fail")
+	}
+}
+
+func TestHeartbeatWatch(t *testing.T) {
+	heartbeat := NewHeartbeat()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	heartbeat.Watch(ctx, time.Millisecond, func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("unavailable")
+		}
+		cancel()
+		return nil
+	})
+
+	if calls != 2 {
+		t.Errorf("Expected 2 probe calls, got %d", calls)
+	}
+	if heartbeat.Last().IsZero() {
+		t.Error("Expected successful probe to beat")
+	}
+}
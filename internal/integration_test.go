@@ -2,8 +2,10 @@
 package internal
 
 import (
+	"strings"
 	"testing"
 
+	"tlgbot/internal/botapitest"
 	"tlgbot/internal/models"
 	"tlgbot/internal/services"
 )
@@ -203,3 +205,55 @@ func TestIntegrationConcurrentUsers(t *testing.T) {
 		}
 	}
 }
+
+func TestIntegrationTranscript(t *testing.T) {
+	questionManager := services.NewQuestionManager(map[string]models.Question{
+		"start": {
+			ID:      "start",
+			Text:    "How are you feeling today?",
+			Options: []models.Option{{Text: "Good", NextID: "end"}, {Text: notGoodAnswer, NextID: "help"}},
+		},
+		"help": {
+			ID:       "help",
+			Messages: []string{"Sorry to hear that, {name}.", "Here is some help."},
+			Options:  []models.Option{{Text: "Thanks", NextID: "end"}},
+		},
+		"end": {ID: "end", Text: "Bye!", ShowSummary: new(bool)},
+	})
+	cfg := &models.Config{TelegramToken: botapitest.Token, StartQuestionID: "start"}
+
+	h, err := botapitest.NewHarness(cfg, questionManager)
+	if err != nil {
+		t.Fatalf("Failed to create harness: %v", err)
+	}
+	defer h.Close()
+
+	const userID = int64(1001)
+	if err := h.Send(userID, "/start"); err != nil {
+		t.Fatalf("Failed to send /start: %v", err)
+	}
+	if err := h.Press(userID, notGoodAnswer); err != nil {
+		t.Fatalf("Failed to press button: %v", err)
+	}
+	if err := h.Press(userID, "Thanks"); err != nil {
+		t.Fatalf("Failed to press button: %v", err)
+	}
+	if err := h.Send(userID, "/status"); err != nil {
+		t.Fatalf("Failed to send /status: %v", err)
+	}
+
+	expected := []string{
+		"user: /start",
+		"bot: How are you feeling today?\n  [Good]\n  [Not good]",
+		"user: [Not good]",
+		"bot: Sorry to hear that, Test.",
+		"bot: Here is some help.\n  [Thanks]",
+		"user: [Thanks]",
+		"bot: Bye!",
+		"user: /status",
+		"bot: You have completed the survey. Answers given: 2.",
+	}
+	if got := h.Transcript(userID); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected transcript:\n%s", strings.Join(got, "\n"))
+	}
+}
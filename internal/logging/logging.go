@@ -0,0 +1,128 @@
+// Package logging configures structured logging and carries per-update log fields in context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"tlgbot/internal/models"
+)
+
+// Supported output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Log attribute keys shared across the bot
+const (
+	KeyUpdateID   = "update_id"
+	KeyUserID     = "user_id"
+	KeyChatID     = "chat_id"
+	KeySurveyID   = "survey_id"
+	KeyQuestionID = "question_id"
+)
+
+// New creates a logger writing records of the given level and format to w.
+// Attributes stored in context with With are added to every record.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unsupported log format: %s", format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// ParseLevel parses log level name. Empty name means info.
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return lvl, fmt.Errorf("unsupported log level: %s", level)
+	}
+	return lvl, nil
+}
+
+// ctxKey is the context key of log attributes
+type ctxKey struct{}
+
+// With returns context carrying additional log attributes.
+// Later values of the same key take precedence.
+func With(ctx context.Context, args ...any) context.Context {
+	existing, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+
+	record := slog.Record{}
+	record.Add(args...)
+
+	attrs := make([]slog.Attr, 0, len(existing)+record.NumAttrs())
+	record.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for _, a := range existing {
+		if !hasKey(attrs, a.Key) {
+			attrs = append(attrs, a)
+		}
+	}
+
+	return context.WithValue(ctx, ctxKey{}, attrs)
+}
+
+// WithUserState returns context carrying survey and question of the user
+func WithUserState(ctx context.Context, userState *models.UserState) context.Context {
+	if userState == nil {
+		return ctx
+	}
+	return With(ctx, KeySurveyID, userState.SurveyID, KeyQuestionID, userState.CurrentQuestionID)
+}
+
+// hasKey checks if attribute with the key is present
+func hasKey(attrs []slog.Attr, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// contextHandler adds attributes stored in context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds context attributes to the record
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs returns handler with additional attributes
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns handler with attributes group
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"tlgbot/internal/models"
+)
+
+func TestNewInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  string
+		format string
+	}{
+		{"unknown level", "verbose", FormatText},
+		{"unknown format", "info", "xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(&bytes.Buffer{}, tt.level, tt.format); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected slog.Level
+	}{
+		{"", slog.LevelInfo},
+		{"debug", slog.LevelDebug},
+		{"WARN", slog.LevelWarn},
+		{"error", slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			level, err := ParseLevel(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if level != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, level)
+			}
+		})
+	}
+}
+
+func TestContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "info", FormatJSON)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+
+	ctx := With(context.Background(), KeyUpdateID, 42, KeyUserID, int64(7))
+	ctx = WithUserState(ctx, &models.UserState{SurveyID: "quiz", CurrentQuestionID: "q1"})
+	ctx = With(ctx, KeyQuestionID, "q2")
+
+	logger.InfoContext(ctx, "hello")
+	logger.DebugContext(ctx, "hidden")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 record, got %d: %s", len(lines), buf.String())
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("Failed to decode record: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		KeyUpdateID:   float64(42),
+		KeyUserID:     float64(7),
+		KeySurveyID:   "quiz",
+		KeyQuestionID: "q2",
+	}
+	for key, want := range expected {
+		if record[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, record[key])
+		}
+	}
+}
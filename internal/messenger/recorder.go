@@ -0,0 +1,143 @@
+package messenger
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// Call is a request captured by Recorder
+type Call struct {
+	Method     string
+	ChatID     int64
+	MessageID  int
+	Text       string
+	Markup     interface{}
+	Files      []string
+	FileName   string
+	Data       []byte
+	CallbackID string
+	Action     string
+}
+
+// Recorder is a Messenger that records requests instead of sending them
+type Recorder struct {
+	mu            sync.Mutex
+	calls         []Call
+	nextMessageID int
+	errors        map[string]error
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{errors: make(map[string]error)}
+}
+
+// FailWith makes requests of the given method fail with err; nil err clears the failure
+func (r *Recorder) FailWith(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		delete(r.errors, method)
+		return
+	}
+	r.errors[method] = err
+}
+
+// Calls returns all recorded requests in order
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// CallsTo returns recorded requests of the given method
+func (r *Recorder) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range r.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Texts returns texts of messages sent to the chat
+func (r *Recorder) Texts(chatID int64) []string {
+	var texts []string
+	for _, call := range r.CallsTo(MethodSendMessage) {
+		if call.ChatID == chatID {
+			texts = append(texts, call.Text)
+		}
+	}
+	return texts
+}
+
+// Reset forgets recorded requests
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+// record stores the call unless its method is set to fail
+func (r *Recorder) record(call Call) error {
+	_, err := r.recordMessage(call)
+	return err
+}
+
+// recordMessage stores the call and assigns sent messages sequential IDs
+func (r *Recorder) recordMessage(call Call) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.errors[call.Method]; err != nil {
+		return 0, err
+	}
+	if call.Method == MethodSendMessage {
+		r.nextMessageID++
+		call.MessageID = r.nextMessageID
+	}
+	r.calls = append(r.calls, call)
+	return call.MessageID, nil
+}
+
+// SendText records a text message and returns its sequential ID
+func (r *Recorder) SendText(chatID int64, text string, markup interface{}) (int, error) {
+	return r.recordMessage(Call{Method: MethodSendMessage, ChatID: chatID, Text: text, Markup: markup})
+}
+
+// SendPhoto records a photo
+func (r *Recorder) SendPhoto(chatID int64, path string) error {
+	return r.record(Call{Method: MethodSendPhoto, ChatID: chatID, Files: []string{path}})
+}
+
+// SendMediaGroup records an album
+func (r *Recorder) SendMediaGroup(chatID int64, paths []string) error {
+	return r.record(Call{Method: MethodSendMediaGroup, ChatID: chatID, Files: append([]string(nil), paths...)})
+}
+
+// SendDocument records a document
+func (r *Recorder) SendDocument(chatID int64, fileName string, data []byte) error {
+	return r.record(Call{Method: MethodSendDocument, ChatID: chatID, FileName: fileName, Data: data})
+}
+
+// AnswerCallback records a callback acknowledgement
+func (r *Recorder) AnswerCallback(callbackID, text string) error {
+	return r.record(Call{Method: MethodAnswerCallbackQuery, CallbackID: callbackID, Text: text})
+}
+
+// EditMessageText records a message edit
+func (r *Recorder) EditMessageText(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) error {
+	call := Call{Method: MethodEditMessageText, ChatID: chatID, MessageID: messageID, Text: text}
+	if markup != nil {
+		call.Markup = markup
+	}
+	return r.record(call)
+}
+
+// SendChatAction records a chat action
+func (r *Recorder) SendChatAction(chatID int64, action string) error {
+	return r.record(Call{Method: MethodSendChatAction, ChatID: chatID, Action: action})
+}
@@ -0,0 +1,66 @@
+package messenger
+
+import (
+	"errors"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestRecorderRecordsCalls(t *testing.T) {
+	r := NewRecorder()
+
+	firstID, err := r.SendText(1, "Hello", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	secondID, _ := r.SendText(2, "Hi", nil)
+	if firstID != 1 || secondID != 2 {
+		t.Errorf("Expected sequential message IDs, got %d and %d", firstID, secondID)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup()
+	if err := r.EditMessageText(1, firstID, "Edited", &keyboard); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := r.SendMediaGroup(1, []string{"a.jpg", "b.jpg"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := r.SendChatAction(1, tgbotapi.ChatTyping); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if texts := r.Texts(1); len(texts) != 1 || texts[0] != "Hello" {
+		t.Errorf("Expected texts of chat 1, got %v", texts)
+	}
+	edits := r.CallsTo(MethodEditMessageText)
+	if len(edits) != 1 || edits[0].MessageID != firstID || edits[0].Markup == nil {
+		t.Errorf("Unexpected edit calls: %+v", edits)
+	}
+	if len(r.Calls()) != 5 {
+		t.Errorf("Expected 5 calls, got %d", len(r.Calls()))
+	}
+
+	r.Reset()
+	if len(r.Calls()) != 0 {
+		t.Error("Expected no calls after reset")
+	}
+}
+
+func TestRecorderFailWith(t *testing.T) {
+	r := NewRecorder()
+	failure := errors.New("blocked by user")
+
+	r.FailWith(MethodSendPhoto, failure)
+	if err := r.SendPhoto(1, "a.jpg"); !errors.Is(err, failure) {
+		t.Errorf("Expected injected error, got %v", err)
+	}
+
+	r.FailWith(MethodSendPhoto, nil)
+	if err := r.SendPhoto(1, "a.jpg"); err != nil {
+		t.Errorf("Expected cleared failure, got %v", err)
+	}
+	if len(r.CallsTo(MethodSendPhoto)) != 1 {
+		t.Errorf("Expected only successful call to be recorded, got %+v", r.Calls())
+	}
+}
@@ -0,0 +1,125 @@
+// Package messenger provides Telegram API access behind the models.Messenger interface.
+package messenger
+
+import (
+	"fmt"
+
+	"tlgbot/internal/metrics"
+	"tlgbot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// Telegram Bot API method names, used in metrics and recorded calls
+const (
+	MethodSendMessage         = "sendMessage"
+	MethodSendPhoto           = "sendPhoto"
+	MethodSendMediaGroup      = "sendMediaGroup"
+	MethodSendDocument        = "sendDocument"
+	MethodAnswerCallbackQuery = "answerCallbackQuery"
+	MethodEditMessageText     = "editMessageText"
+	MethodSendChatAction      = "sendChatAction"
+)
+
+// Compile-time interface checks
+var (
+	_ models.Messenger = (*Telegram)(nil)
+	_ models.Messenger = (*Recorder)(nil)
+	_ models.Messenger = (*Terminal)(nil)
+)
+
+// Telegram sends requests through tgbotapi.BotAPI
+type Telegram struct {
+	api     *tgbotapi.BotAPI
+	metrics *metrics.Metrics
+}
+
+// NewTelegram creates a messenger using the given API client.
+// Metrics may be nil.
+func NewTelegram(api *tgbotapi.BotAPI, m *metrics.Metrics) *Telegram {
+	return &Telegram{
+		api:     api,
+		metrics: m,
+	}
+}
+
+// SendText sends a text message with optional reply markup
+func (t *Telegram) SendText(chatID int64, text string, markup interface{}) (int, error) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	if markup != nil {
+		msg.ReplyMarkup = markup
+	}
+
+	done := t.metrics.RequestStarted(MethodSendMessage)
+	sent, err := t.api.Send(msg)
+	done(err)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", MethodSendMessage, err)
+	}
+	return sent.MessageID, nil
+}
+
+// SendPhoto sends a photo from local file
+func (t *Telegram) SendPhoto(chatID int64, path string) error {
+	return t.send(MethodSendPhoto, tgbotapi.NewPhoto(chatID, tgbotapi.FilePath(path)))
+}
+
+// SendMediaGroup sends photos from local files as an album
+func (t *Telegram) SendMediaGroup(chatID int64, paths []string) error {
+	media := make([]interface{}, len(paths))
+	for i, path := range paths {
+		media[i] = tgbotapi.NewInputMediaPhoto(tgbotapi.FilePath(path))
+	}
+
+	done := t.metrics.RequestStarted(MethodSendMediaGroup)
+	_, err := t.api.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media))
+	done(err)
+	if err != nil {
+		return fmt.Errorf("%s: %w", MethodSendMediaGroup, err)
+	}
+	return nil
+}
+
+// SendDocument sends in-memory data as a file
+func (t *Telegram) SendDocument(chatID int64, fileName string, data []byte) error {
+	return t.send(MethodSendDocument, tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: fileName, Bytes: data}))
+}
+
+// AnswerCallback acknowledges a callback query, optionally showing a notification
+func (t *Telegram) AnswerCallback(callbackID, text string) error {
+	return t.request(MethodAnswerCallbackQuery, tgbotapi.NewCallback(callbackID, text))
+}
+
+// EditMessageText replaces text and inline keyboard of a sent message
+func (t *Telegram) EditMessageText(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) error {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ReplyMarkup = markup
+	return t.send(MethodEditMessageText, edit)
+}
+
+// SendChatAction shows a chat action such as "typing" to the user
+func (t *Telegram) SendChatAction(chatID int64, action string) error {
+	return t.request(MethodSendChatAction, tgbotapi.NewChatAction(chatID, action))
+}
+
+// send sends a request returning a message, recording its outcome and latency
+func (t *Telegram) send(method string, c tgbotapi.Chattable) error {
+	done := t.metrics.RequestStarted(method)
+	_, err := t.api.Send(c)
+	done(err)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
+
+// request sends a request returning no message, recording its outcome and latency
+func (t *Telegram) request(method string, c tgbotapi.Chattable) error {
+	done := t.metrics.RequestStarted(method)
+	_, err := t.api.Request(c)
+	done(err)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
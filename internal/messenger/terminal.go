@@ -0,0 +1,146 @@
+package messenger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// Terminal is a Messenger printing bot output as plain text.
+// Inline keyboard buttons are listed as numbered choices that can be picked with Choice.
+type Terminal struct {
+	mu            sync.Mutex
+	out           io.Writer
+	choices       []string
+	nextMessageID int
+}
+
+// NewTerminal creates a messenger writing to out
+func NewTerminal(out io.Writer) *Terminal {
+	return &Terminal{out: out}
+}
+
+// Choice returns callback data of the numbered button from the latest keyboard
+func (t *Terminal) Choice(number int) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if number < 1 || number > len(t.choices) {
+		return "", false
+	}
+	return t.choices[number-1], true
+}
+
+// HasChoices reports whether the latest message offered buttons to choose from
+func (t *Terminal) HasChoices() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.choices) > 0
+}
+
+// SendText prints a message with its keyboard
+func (t *Terminal) SendText(_ int64, text string, markup interface{}) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.printf("bot: %s\n", indent(text))
+	switch keyboard := markup.(type) {
+	case *tgbotapi.InlineKeyboardMarkup:
+		t.printInlineKeyboard(keyboard)
+	case tgbotapi.InlineKeyboardMarkup:
+		t.printInlineKeyboard(&keyboard)
+	case tgbotapi.ReplyKeyboardMarkup:
+		t.choices = nil
+		for _, row := range keyboard.Keyboard {
+			for _, button := range row {
+				t.printf("  (%s)\n", button.Text)
+			}
+		}
+	default:
+		t.choices = nil
+	}
+
+	t.nextMessageID++
+	return t.nextMessageID, nil
+}
+
+// printInlineKeyboard lists callback buttons as numbered choices and link buttons with their URLs
+func (t *Terminal) printInlineKeyboard(keyboard *tgbotapi.InlineKeyboardMarkup) {
+	t.choices = nil
+	for _, row := range keyboard.InlineKeyboard {
+		for _, button := range row {
+			switch {
+			case button.CallbackData != nil:
+				t.choices = append(t.choices, *button.CallbackData)
+				t.printf("  %d) %s\n", len(t.choices), button.Text)
+			case button.URL != nil:
+				t.printf("  -> %s: %s\n", button.Text, *button.URL)
+			}
+		}
+	}
+}
+
+// SendPhoto prints a photo placeholder
+func (t *Terminal) SendPhoto(_ int64, path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.printf("bot: [photo %s]\n", path)
+	return nil
+}
+
+// SendMediaGroup prints an album placeholder
+func (t *Terminal) SendMediaGroup(_ int64, paths []string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.printf("bot: [album %s]\n", strings.Join(paths, ", "))
+	return nil
+}
+
+// SendDocument prints a document placeholder
+func (t *Terminal) SendDocument(_ int64, fileName string, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.printf("bot: [document %s, %d bytes]\n", fileName, len(data))
+	return nil
+}
+
+// AnswerCallback does nothing, terminal has no loading indicator
+func (t *Terminal) AnswerCallback(_, _ string) error {
+	return nil
+}
+
+// EditMessageText prints the new text of an edited message
+func (t *Terminal) EditMessageText(_ int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.printf("bot (edited #%d): %s\n", messageID, indent(text))
+	if markup != nil {
+		t.printInlineKeyboard(markup)
+	}
+	return nil
+}
+
+// SendChatAction does nothing, chat actions are not shown
+func (t *Terminal) SendChatAction(_ int64, _ string) error {
+	return nil
+}
+
+// printf writes to output; write errors are ignored as there is nowhere to report them
+func (t *Terminal) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(t.out, format, args...)
+}
+
+// indent aligns non-empty continuation lines of multiline text with the first one
+func indent(text string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = "     " + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
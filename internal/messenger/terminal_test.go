@@ -0,0 +1,68 @@
+package messenger
+
+import (
+	"bytes"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestTerminalPrintsMessages(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal(&out)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Yes", "yes")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("No", "no")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL("Site", "https://example.com")),
+	)
+	if _, err := term.SendText(1, "Hello\nthere", &keyboard); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = term.SendMediaGroup(1, []string{"a.jpg", "b.jpg"})
+	_ = term.SendDocument(1, "export.csv", []byte("id"))
+
+	expected := "bot: Hello\n     there\n" +
+		"  1) Yes\n" +
+		"  2) No\n" +
+		"  -> Site: https://example.com\n" +
+		"bot: [album a.jpg, b.jpg]\n" +
+		"bot: [document export.csv, 2 bytes]\n"
+	if out.String() != expected {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
+
+func TestTerminalChoices(t *testing.T) {
+	var out bytes.Buffer
+	term := NewTerminal(&out)
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Yes", "yes")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("No", "no")),
+	)
+	_, _ = term.SendText(1, "Question?", &keyboard)
+
+	tests := []struct {
+		number   int
+		expected string
+		ok       bool
+	}{
+		{1, "yes", true},
+		{2, "no", true},
+		{0, "", false},
+		{3, "", false},
+	}
+	for _, tt := range tests {
+		data, ok := term.Choice(tt.number)
+		if data != tt.expected || ok != tt.ok {
+			t.Errorf("Choice(%d) = %q, %v; expected %q, %v", tt.number, data, ok, tt.expected, tt.ok)
+		}
+	}
+
+	// Message without keyboard clears choices
+	_, _ = term.SendText(1, "Thanks", nil)
+	if term.HasChoices() {
+		t.Error("Expected no choices after message without keyboard")
+	}
+}
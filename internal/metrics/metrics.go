@@ -0,0 +1,288 @@
+// Package metrics provides Prometheus instrumentation for the telegram bot.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes all bot metrics
+const namespace = "tlgbot"
+
+// defaultSurveyLabel is used as survey label value for the default survey
+const defaultSurveyLabel = "default"
+
+// Metrics holds bot metrics. A nil *Metrics is valid and records nothing,
+// so instrumented components work without metrics configured.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	updatesReceived  *prometheus.CounterVec
+	messagesSent     *prometheus.CounterVec
+	messagesFailed   *prometheus.CounterVec
+	apiLatency       *prometheus.HistogramVec
+	surveysStarted   *prometheus.CounterVec
+	surveysCompleted *prometheus.CounterVec
+	questionArrivals *prometheus.CounterVec
+	questionDropOffs *prometheus.CounterVec
+	remindersSent    *prometheus.CounterVec
+	surveysExpired   *prometheus.CounterVec
+	webhookEvents    *prometheus.CounterVec
+	outboxAttempts   *prometheus.CounterVec
+	requestsInFlight prometheus.Gauge
+}
+
+// New creates metrics registered in a dedicated registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		updatesReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "updates_received_total",
+			Help:      "Telegram updates received by type.",
+		}, []string{"type"}),
+		messagesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_sent_total",
+			Help:      "Telegram API requests completed successfully by method.",
+		}, []string{"method"}),
+		messagesFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_failed_total",
+			Help:      "Telegram API requests failed by method.",
+		}, []string{"method"}),
+		apiLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "telegram_api_latency_seconds",
+			Help:      "Telegram API request latency by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		surveysStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "surveys_started_total",
+			Help:      "Survey attempts started.",
+		}, []string{"survey"}),
+		surveysCompleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "surveys_completed_total",
+			Help:      "Survey attempts completed by terminal question.",
+		}, []string{"survey", "outcome"}),
+		questionArrivals: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "question_arrivals_total",
+			Help:      "Users reaching a question.",
+		}, []string{"survey", "question"}),
+		questionDropOffs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "question_dropoffs_total",
+			Help:      "Unfinished survey attempts abandoned at a question.",
+		}, []string{"survey", "question"}),
+		remindersSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reminders_sent_total",
+			Help:      "Inactivity reminders sent by question.",
+		}, []string{"survey", "question"}),
+		surveysExpired: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "surveys_expired_total",
+			Help:      "Unfinished survey attempts expired after inactivity.",
+		}, []string{"survey"}),
+		webhookEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_events_total",
+			Help:      "Webhook events by type and result, delivered or failed after all attempts.",
+		}, []string{"event", "result"}),
+		outboxAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "outbox_attempts_total",
+			Help:      "Outbox delivery attempts by sink and result.",
+		}, []string{"sink", "result"}),
+		requestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "telegram_requests_in_flight",
+			Help:      "Telegram API requests in flight.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.updatesReceived,
+		m.messagesSent,
+		m.messagesFailed,
+		m.apiLatency,
+		m.surveysStarted,
+		m.surveysCompleted,
+		m.questionArrivals,
+		m.questionDropOffs,
+		m.remindersSent,
+		m.surveysExpired,
+		m.webhookEvents,
+		m.outboxAttempts,
+		m.requestsInFlight,
+	)
+
+	return m
+}
+
+// Handler returns HTTP handler exposing metrics in Prometheus format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RegisterActiveUsers exposes number of active users computed on scrape
+func (m *Metrics) RegisterActiveUsers(count func() int) {
+	if m == nil {
+		return
+	}
+
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_users",
+		Help:      "Users with a survey in progress.",
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// RegisterOutboxPending exposes number of submissions waiting in the outbox computed on scrape
+func (m *Metrics) RegisterOutboxPending(count func() int) {
+	if m == nil {
+		return
+	}
+
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "outbox_pending",
+		Help:      "Submission deliveries waiting in the outbox.",
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// RegisterWebhookQueued exposes number of webhook events waiting to be sent computed on scrape
+func (m *Metrics) RegisterWebhookQueued(count func() int) {
+	if m == nil {
+		return
+	}
+
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "webhook_queued",
+		Help:      "Webhook events waiting to be sent.",
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// UpdateReceived counts an incoming Telegram update
+func (m *Metrics) UpdateReceived(updateType string) {
+	if m == nil {
+		return
+	}
+	m.updatesReceived.WithLabelValues(updateType).Inc()
+}
+
+// RequestStarted tracks an outbound request and returns function completing it
+func (m *Metrics) RequestStarted(method string) func(err error) {
+	if m == nil {
+		return func(error) {}
+	}
+
+	start := time.Now()
+	m.requestsInFlight.Inc()
+
+	return func(err error) {
+		m.requestsInFlight.Dec()
+		m.apiLatency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.messagesFailed.WithLabelValues(method).Inc()
+			return
+		}
+		m.messagesSent.WithLabelValues(method).Inc()
+	}
+}
+
+// SurveyStarted counts a started survey attempt
+func (m *Metrics) SurveyStarted(surveyID string) {
+	if m == nil {
+		return
+	}
+	m.surveysStarted.WithLabelValues(surveyLabel(surveyID)).Inc()
+}
+
+// SurveyCompleted counts a completed survey attempt
+func (m *Metrics) SurveyCompleted(surveyID, outcome string) {
+	if m == nil {
+		return
+	}
+	m.surveysCompleted.WithLabelValues(surveyLabel(surveyID), outcome).Inc()
+}
+
+// QuestionReached counts a user reaching a question
+func (m *Metrics) QuestionReached(surveyID, questionID string) {
+	if m == nil {
+		return
+	}
+	m.questionArrivals.WithLabelValues(surveyLabel(surveyID), questionID).Inc()
+}
+
+// QuestionDroppedOff counts an unfinished attempt abandoned at a question
+func (m *Metrics) QuestionDroppedOff(surveyID, questionID string) {
+	if m == nil {
+		return
+	}
+	m.questionDropOffs.WithLabelValues(surveyLabel(surveyID), questionID).Inc()
+}
+
+// ReminderSent counts an inactivity reminder sent for a question
+func (m *Metrics) ReminderSent(surveyID, questionID string) {
+	if m == nil {
+		return
+	}
+	m.remindersSent.WithLabelValues(surveyLabel(surveyID), questionID).Inc()
+}
+
+// SurveyExpired counts an unfinished attempt expired after inactivity
+func (m *Metrics) SurveyExpired(surveyID string) {
+	if m == nil {
+		return
+	}
+	m.surveysExpired.WithLabelValues(surveyLabel(surveyID)).Inc()
+}
+
+// WebhookEvent counts a webhook event delivered or failed after all attempts
+func (m *Metrics) WebhookEvent(eventType string, delivered bool) {
+	if m == nil {
+		return
+	}
+	result := "delivered"
+	if !delivered {
+		result = "failed"
+	}
+	m.webhookEvents.WithLabelValues(eventType, result).Inc()
+}
+
+// OutboxAttempt counts an outbox delivery attempt to the sink
+func (m *Metrics) OutboxAttempt(sink string, delivered bool) {
+	if m == nil {
+		return
+	}
+	result := "delivered"
+	if !delivered {
+		result = "failed"
+	}
+	m.outboxAttempts.WithLabelValues(sink, result).Inc()
+}
+
+// surveyLabel converts survey ID to label value
+func surveyLabel(surveyID string) string {
+	if surveyID == "" {
+		return defaultSurveyLabel
+	}
+	return surveyID
+}
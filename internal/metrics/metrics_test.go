@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNilMetricsRecordsNothing(t *testing.T) {
+	var m *Metrics
+
+	m.RegisterActiveUsers(func() int { return 1 })
+	m.UpdateReceived("message")
+	m.RequestStarted("sendMessage")(nil)
+	m.SurveyStarted("")
+	m.SurveyCompleted("", "end")
+	m.QuestionReached("", "start")
+	m.QuestionDroppedOff("", "start")
+	m.ReminderSent("", "start")
+	m.SurveyExpired("")
+	m.WebhookEvent("submission.completed", true)
+	m.OutboxAttempt("webhook", false)
+	m.RegisterOutboxPending(func() int { return 0 })
+	m.RegisterWebhookQueued(func() int { return 0 })
+}
+
+func TestRequestStarted(t *testing.T) {
+	m := New()
+
+	done := m.RequestStarted("sendMessage")
+	if got := testutil.ToFloat64(m.requestsInFlight); got != 1 {
+		t.Errorf("Expected 1 requests in flight during request, got %v", got)
+	}
+	done(nil)
+	m.RequestStarted("sendMessage")(errors.New("boom"))
+
+	if got := testutil.ToFloat64(m.requestsInFlight); got != 0 {
+		t.Errorf("Expected 0 requests in flight after requests, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.messagesSent.WithLabelValues("sendMessage")); got != 1 {
+		t.Errorf("Expected 1 sent message, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.messagesFailed.WithLabelValues("sendMessage")); got != 1 {
+		t.Errorf("Expected 1 failed message, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.apiLatency); got != 1 {
+		t.Errorf("Expected 1 latency series, got %d", got)
+	}
+}
+
+func TestSurveyLabels(t *testing.T) {
+	m := New()
+
+	m.SurveyStarted("")
+	m.SurveyStarted("quiz")
+	m.SurveyCompleted("quiz", "passed")
+	m.QuestionReached("", "start")
+	m.QuestionDroppedOff("quiz", "q1")
+	m.ReminderSent("quiz", "q1")
+	m.SurveyExpired("")
+	m.WebhookEvent("submission.completed", true)
+	m.WebhookEvent("answer.recorded", false)
+	m.OutboxAttempt("webhook", false)
+
+	tests := []struct {
+		name string
+		got  float64
+	}{
+		{"default survey started", testutil.ToFloat64(m.surveysStarted.WithLabelValues("default"))},
+		{"quiz started", testutil.ToFloat64(m.surveysStarted.WithLabelValues("quiz"))},
+		{"quiz completed", testutil.ToFloat64(m.surveysCompleted.WithLabelValues("quiz", "passed"))},
+		{"question reached", testutil.ToFloat64(m.questionArrivals.WithLabelValues("default", "start"))},
+		{"question dropped off", testutil.ToFloat64(m.questionDropOffs.WithLabelValues("quiz", "q1"))},
+		{"reminder sent", testutil.ToFloat64(m.remindersSent.WithLabelValues("quiz", "q1"))},
+		{"default survey expired", testutil.ToFloat64(m.surveysExpired.WithLabelValues("default"))},
+		{"webhook event delivered", testutil.ToFloat64(m.webhookEvents.WithLabelValues("submission.completed", "delivered"))},
+		{"webhook event failed", testutil.ToFloat64(m.webhookEvents.WithLabelValues("answer.recorded", "failed"))},
+		{"outbox attempt failed", testutil.ToFloat64(m.outboxAttempts.WithLabelValues("webhook", "failed"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != 1 {
+				t.Errorf("Expected 1, got %v", tt.got)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.RegisterActiveUsers(func() int { return 3 })
+	m.RegisterOutboxPending(func() int { return 2 })
+	m.RegisterWebhookQueued(func() int { return 4 })
+	m.UpdateReceived("callback_query")
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+
+	for _, want := range []string{
+		"tlgbot_active_users 3",
+		"tlgbot_outbox_pending 2",
+		"tlgbot_webhook_queued 4",
+		`tlgbot_updates_received_total{type="callback_query"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected metrics output to contain %q", want)
+		}
+	}
+}
@@ -2,19 +2,49 @@
 package models
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // Config structure for storing settings
 type Config struct {
-	TelegramToken     string `json:"telegram_token"`
-	GoogleCreds       string `json:"google_creds"`
-	SheetID           string `json:"sheet_id"`
-	DelayMs           int    `json:"delay_ms"`
-	StartQuestionID   string `json:"start_question_id"`
-	QuestionsFilePath string `json:"questions_file_path"`
+	// Schema is the JSON Schema reference editors use for validation and autocomplete
+	Schema            string  `json:"$schema,omitempty"`
+	TelegramToken     string  `json:"telegram_token"`
+	GoogleCreds       string  `json:"google_creds"`
+	SheetID           string  `json:"sheet_id"`
+	DelayMs           int     `json:"delay_ms"`
+	StartQuestionID   string  `json:"start_question_id"`
+	QuestionsFilePath string  `json:"questions_file_path"`
+	AdminChatID       int64   `json:"admin_chat_id"`
+	AdminUserIDs      []int64 `json:"admin_user_ids"`
+	SurveysDir        string  `json:"surveys_dir"`
+	HTTPAddr          string  `json:"http_addr"`
+	LogLevel          string  `json:"log_level"`
+	LogFormat         string  `json:"log_format"`
+	// Inactivity reminders and session timeout, disabled when zero
+	ReminderAfter    Duration `json:"reminder_after"`
+	ReminderInterval Duration `json:"reminder_interval"`
+	ReminderLimit    int      `json:"reminder_limit"`
+	ReminderText     string   `json:"reminder_text"`
+	SessionTimeout   Duration `json:"session_timeout"`
+	ExpiredText      string   `json:"expired_text"`
+	// StateDir persists user states across restarts, kept in memory only when empty
+	StateDir string `json:"state_dir"`
+	// Webhook receiving submissions as JSON, disabled when the URL is empty
+	WebhookURL         string `json:"webhook_url"`
+	WebhookSecret      string `json:"webhook_secret"`
+	WebhookMaxAttempts int    `json:"webhook_max_attempts"`
+	WebhookDeadLetter  string `json:"webhook_dead_letter"`
+	WebhookAnswers     bool   `json:"webhook_answers"`
+	// OutboxDir keeps submissions until every sink accepts them, delivered directly when empty
+	OutboxDir string `json:"outbox_dir"`
 }
 
 // Validate checks configuration correctness
@@ -28,31 +58,164 @@ func (c *Config) Validate() error {
 	if c.DelayMs < 0 {
 		return errors.New("delay must be non-negative")
 	}
+	if c.ReminderAfter < 0 || c.ReminderInterval < 0 || c.SessionTimeout < 0 {
+		return errors.New("reminder and session timeouts must be non-negative")
+	}
+	if c.ReminderLimit < 0 {
+		return errors.New("reminder limit must be non-negative")
+	}
+	if c.WebhookURL != "" {
+		if err := validateWebhookURL(c.WebhookURL); err != nil {
+			return err
+		}
+		if c.WebhookMaxAttempts < 1 {
+			return errors.New("webhook max attempts must be positive")
+		}
+	}
 	return nil
 }
 
+// validateWebhookURL checks that webhook URL is an absolute HTTP or HTTPS URL
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("webhook URL must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// IsAdmin checks if user is allowed to run admin commands
+func (c *Config) IsAdmin(userID int64) bool {
+	for _, adminID := range c.AdminUserIDs {
+		if adminID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration is a time span written as text such as "30m" or "2h" in configuration and question files
+type Duration time.Duration
+
+// String formats the duration without trailing zero units, e.g. "2h" instead of "2h0m0s"
+func (d Duration) String() string {
+	text := time.Duration(d).String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// MarshalText encodes the duration as text
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses duration text such as "90s", "30m" or "1h30m"; empty text is zero
+func (d *Duration) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if value == "" {
+		*d = 0
+		return nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q, expected a value such as 30m or 2h", text)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// EndQuestionID is the legacy ID of the final survey question.
+// Questions with this ID are treated as terminal even without the terminal flag.
+const EndQuestionID = "end"
+
 // Option represents an answer option for a question
 type Option struct {
-	Text   string `json:"text"`
-	NextID string `json:"next_id"`
-	Action string `json:"action"`
+	Text   string `json:"text,omitempty" yaml:"text,omitempty" toml:"text,omitempty"`
+	NextID string `json:"next_id,omitempty" yaml:"next_id,omitempty" toml:"next_id,omitempty"`
+	Action string `json:"action,omitempty" yaml:"action,omitempty" toml:"action,omitempty"`
+	// OptionSet references shared options that replace this option when questions are loaded
+	OptionSet string `json:"option_set,omitempty" yaml:"option_set,omitempty" toml:"option_set,omitempty"`
+	// Points are added to the score when the option is chosen, negative values are penalties
+	Points int `json:"points,omitempty" yaml:"points,omitempty" toml:"points,omitempty"`
+}
+
+// ScoreBranch routes users whose score is within the inclusive range to another question
+type ScoreBranch struct {
+	MinScore *int   `json:"min_score,omitempty" yaml:"min_score,omitempty" toml:"min_score,omitempty"`
+	MaxScore *int   `json:"max_score,omitempty" yaml:"max_score,omitempty" toml:"max_score,omitempty"`
+	NextID   string `json:"next_id" yaml:"next_id" toml:"next_id"`
+}
+
+// Matches checks if score is within the branch range; missing bounds are open
+func (b *ScoreBranch) Matches(score int) bool {
+	return (b.MinScore == nil || score >= *b.MinScore) && (b.MaxScore == nil || score <= *b.MaxScore)
 }
 
 // Question represents a survey question
 type Question struct {
-	ID                 string   `json:"id"`
-	Text               string   `json:"text"`
-	Messages           []string `json:"messages"`
-	Images             []string `json:"images"`
-	Options            []Option `json:"options"`
-	ExternalLink       string   `json:"external_link"`
-	ExternalText       string   `json:"external_text"`
-	Input              string   `json:"input"`
-	InputType          string   `json:"input_type"`
-	InputPlaceholder   string   `json:"input_placeholder"`
-	DelayMs            *int     `json:"delay_ms"`
-	AutoAdvance        bool     `json:"auto_advance"`
-	AutoAdvanceDelayMs int      `json:"auto_advance_delay_ms"`
+	ID                 string   `json:"id" yaml:"id" toml:"id"`
+	Text               string   `json:"text,omitempty" yaml:"text,omitempty" toml:"text,omitempty"`
+	Messages           []string `json:"messages,omitempty" yaml:"messages,omitempty" toml:"messages,omitempty"`
+	Images             []string `json:"images,omitempty" yaml:"images,omitempty" toml:"images,omitempty"`
+	Options            []Option `json:"options,omitempty" yaml:"options,omitempty" toml:"options,omitempty"`
+	ExternalLink       string   `json:"external_link,omitempty" yaml:"external_link,omitempty" toml:"external_link,omitempty"`
+	ExternalText       string   `json:"external_text,omitempty" yaml:"external_text,omitempty" toml:"external_text,omitempty"`
+	Input              string   `json:"input,omitempty" yaml:"input,omitempty" toml:"input,omitempty"`
+	InputType          string   `json:"input_type,omitempty" yaml:"input_type,omitempty" toml:"input_type,omitempty"`
+	InputPlaceholder   string   `json:"input_placeholder,omitempty" yaml:"input_placeholder,omitempty" toml:"input_placeholder,omitempty"`
+	DelayMs            *int     `json:"delay_ms,omitempty" yaml:"delay_ms,omitempty" toml:"delay_ms,omitempty"`
+	AutoAdvance        bool     `json:"auto_advance,omitempty" yaml:"auto_advance,omitempty" toml:"auto_advance,omitempty"`
+	AutoAdvanceDelayMs int      `json:"auto_advance_delay_ms,omitempty" yaml:"auto_advance_delay_ms,omitempty" toml:"auto_advance_delay_ms,omitempty"`
+	Notify             bool     `json:"notify,omitempty" yaml:"notify,omitempty" toml:"notify,omitempty"`
+	Terminal           bool     `json:"terminal,omitempty" yaml:"terminal,omitempty" toml:"terminal,omitempty"`
+	ShowSummary        *bool    `json:"show_summary,omitempty" yaml:"show_summary,omitempty" toml:"show_summary,omitempty"`
+	// CorrectAnswer makes the question graded: the answer matching it, ignoring case, earns Points
+	CorrectAnswer   string        `json:"correct_answer,omitempty" yaml:"correct_answer,omitempty" toml:"correct_answer,omitempty"`
+	Points          *int          `json:"points,omitempty" yaml:"points,omitempty" toml:"points,omitempty"`
+	CorrectFeedback string        `json:"correct_feedback,omitempty" yaml:"correct_feedback,omitempty" toml:"correct_feedback,omitempty"`
+	WrongFeedback   string        `json:"wrong_feedback,omitempty" yaml:"wrong_feedback,omitempty" toml:"wrong_feedback,omitempty"`
+	ScoreBranches   []ScoreBranch `json:"score_branches,omitempty" yaml:"score_branches,omitempty" toml:"score_branches,omitempty"`
+	// ReminderAfter, ReminderText and Timeout override the configured inactivity settings for this question
+	ReminderAfter *Duration `json:"reminder_after,omitempty" yaml:"reminder_after,omitempty" toml:"reminder_after,omitempty"`
+	ReminderText  string    `json:"reminder_text,omitempty" yaml:"reminder_text,omitempty" toml:"reminder_text,omitempty"`
+	Timeout       *Duration `json:"timeout,omitempty" yaml:"timeout,omitempty" toml:"timeout,omitempty"`
+}
+
+// DefaultCorrectPoints are points for a correct answer when the question does not set them
+const DefaultCorrectPoints = 1
+
+// IsGraded checks if the question has a correct answer
+func (q *Question) IsGraded() bool {
+	return q.CorrectAnswer != ""
+}
+
+// IsCorrect checks if answer matches the correct answer, ignoring case and surrounding spaces
+func (q *Question) IsCorrect(answer string) bool {
+	return q.IsGraded() && strings.EqualFold(strings.TrimSpace(answer), strings.TrimSpace(q.CorrectAnswer))
+}
+
+// GetPoints returns points for a correct answer
+func (q *Question) GetPoints() int {
+	if q.Points != nil {
+		return *q.Points
+	}
+	return DefaultCorrectPoints
+}
+
+// ScoreBranch returns ID of the question the score leads to.
+// Branches are checked in order; false is returned when none matches.
+func (q *Question) ScoreBranch(score int) (string, bool) {
+	for i := range q.ScoreBranches {
+		if q.ScoreBranches[i].Matches(score) {
+			return q.ScoreBranches[i].NextID, true
+		}
+	}
+	return "", false
 }
 
 // GetDelayMs returns delay for question or default value
@@ -63,11 +226,41 @@ func (q *Question) GetDelayMs(defaultDelay int) int {
 	return defaultDelay
 }
 
+// GetReminderAfter returns inactivity before a reminder, or the default value
+func (q *Question) GetReminderAfter(defaultAfter time.Duration) time.Duration {
+	if q.ReminderAfter != nil {
+		return time.Duration(*q.ReminderAfter)
+	}
+	return defaultAfter
+}
+
+// GetTimeout returns inactivity after which the session expires, or the default value
+func (q *Question) GetTimeout(defaultTimeout time.Duration) time.Duration {
+	if q.Timeout != nil {
+		return time.Duration(*q.Timeout)
+	}
+	return defaultTimeout
+}
+
 // HasKeyboard checks if keyboard is needed for this question
 func (q *Question) HasKeyboard() bool {
 	return !q.AutoAdvance && (len(q.Options) > 0 || (q.ExternalLink != "" && q.ExternalText != ""))
 }
 
+// IsTerminal checks if reaching this question completes the survey
+func (q *Question) IsTerminal() bool {
+	return q.Terminal || q.ID == EndQuestionID
+}
+
+// ShouldShowSummary checks if answers summary should be shown with this question.
+// Terminal questions show summary unless disabled explicitly.
+func (q *Question) ShouldShowSummary() bool {
+	if q.ShowSummary != nil {
+		return *q.ShowSummary
+	}
+	return q.IsTerminal()
+}
+
 // GetDisplayText returns text for display
 func (q *Question) GetDisplayText() string {
 	if q.Text != "" {
@@ -79,11 +272,72 @@ func (q *Question) GetDisplayText() string {
 	return ""
 }
 
+// DefaultSurveyID identifies the survey loaded from the single questions file
+const DefaultSurveyID = ""
+
+// Survey represents a survey definition with its own start question
+type Survey struct {
+	ID              string     `json:"id,omitempty" yaml:"id,omitempty" toml:"id,omitempty"`
+	Title           string     `json:"title,omitempty" yaml:"title,omitempty" toml:"title,omitempty"`
+	StartQuestionID string     `json:"start_question_id,omitempty" yaml:"start_question_id,omitempty" toml:"start_question_id,omitempty"`
+	Questions       []Question `json:"questions,omitempty" yaml:"questions,omitempty" toml:"questions,omitempty"`
+}
+
+// GetTitle returns survey title for display
+func (s *Survey) GetTitle() string {
+	if s.Title != "" {
+		return s.Title
+	}
+	return s.ID
+}
+
 // UserState represents user state
 type UserState struct {
-	CurrentQuestionID string
-	Answers           map[string]string
-	Name              string
+	SurveyID          string            `json:"survey_id,omitempty"` // Survey the user is currently taking
+	CurrentQuestionID string            `json:"current_question_id,omitempty"`
+	Answers           map[string]string `json:"answers"`
+	Name              string            `json:"name"`
+	UserName          string            `json:"username,omitempty"` // Telegram @username, may be empty
+	StartedAt         time.Time         `json:"started_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	CompletedAt       time.Time         `json:"completed_at"`
+	ExpiredAt         time.Time         `json:"expired_at"`            // Set when the attempt expired after inactivity
+	Outcome           string            `json:"outcome,omitempty"`     // ID of the terminal question reached
+	Campaign          string            `json:"campaign,omitempty"`    // Campaign tag from the deep link the user came with
+	Source            string            `json:"source,omitempty"`      // Referral source from the deep link the user came with
+	Submissions       []Submission      `json:"submissions,omitempty"` // Completed attempts, oldest first
+	// DropOffs lists unfinished attempts abandoned by starting over, cancelling or switching surveys, oldest first
+	DropOffs []DropOff `json:"drop_offs,omitempty"`
+	// Scores holds results of scored answers by question ID, so answering again replaces the result
+	Scores map[string]QuestionScore `json:"scores,omitempty"`
+	// Visits lists questions reached during the attempt in order, for funnel analytics
+	Visits []Visit `json:"visits,omitempty"`
+	// Responses holds answer values by question ID, so exports have a stable column per question
+	Responses map[string][]string `json:"responses,omitempty"`
+}
+
+// QuestionScore is the result of a scored answer
+type QuestionScore struct {
+	Points  int  `json:"points"`
+	Graded  bool `json:"graded"` // Question has a correct answer
+	Correct bool `json:"correct"`
+}
+
+// Visit records a user reaching a question
+type Visit struct {
+	QuestionID string    `json:"question_id"`
+	At         time.Time `json:"at"`
+}
+
+// DropOff records an unfinished attempt the user abandoned, for statistics and funnel analytics
+type DropOff struct {
+	SurveyID    string    `json:"survey_id,omitempty"`
+	Campaign    string    `json:"campaign,omitempty"`
+	Source      string    `json:"source,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	AbandonedAt time.Time `json:"abandoned_at"`
+	Expired     bool      `json:"expired,omitempty"` // The attempt had expired after inactivity before it was abandoned
+	Visits      []Visit   `json:"visits,omitempty"`
 }
 
 // NewUserState creates new user state
@@ -94,9 +348,261 @@ func NewUserState(name string) *UserState {
 	}
 }
 
+// Clone returns a deep copy of the state sharing nothing the original can change
+func (us *UserState) Clone() *UserState {
+	clone := *us
+	clone.Answers = cloneMap(us.Answers)
+	clone.Scores = cloneMap(us.Scores)
+	clone.Responses = cloneResponses(us.Responses)
+	clone.Visits = append([]Visit(nil), us.Visits...)
+	if us.DropOffs != nil {
+		clone.DropOffs = make([]DropOff, len(us.DropOffs))
+		for i, dropOff := range us.DropOffs {
+			dropOff.Visits = append([]Visit(nil), dropOff.Visits...)
+			clone.DropOffs[i] = dropOff
+		}
+	}
+	if us.Submissions != nil {
+		clone.Submissions = make([]Submission, len(us.Submissions))
+		for i, submission := range us.Submissions {
+			clone.Submissions[i] = submission.clone()
+		}
+	}
+	return &clone
+}
+
+// clone returns a deep copy of the submission
+func (s Submission) clone() Submission {
+	s.Visits = append([]Visit(nil), s.Visits...)
+	s.Answers = cloneMap(s.Answers)
+	s.Responses = cloneResponses(s.Responses)
+	if s.Score != nil {
+		score := *s.Score
+		s.Score = &score
+	}
+	return s
+}
+
+// cloneMap returns a copy of the map, nil for nil
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[K]V, len(m))
+	for key, value := range m {
+		clone[key] = value
+	}
+	return clone
+}
+
+// cloneResponses returns a deep copy of answer values by question ID
+func cloneResponses(responses map[string][]string) map[string][]string {
+	if responses == nil {
+		return nil
+	}
+	clone := make(map[string][]string, len(responses))
+	for questionID, values := range responses {
+		clone[questionID] = append([]string(nil), values...)
+	}
+	return clone
+}
+
 // AddAnswer adds user answer
 func (us *UserState) AddAnswer(question, answer string) {
 	us.Answers[question] = answer
+	us.UpdatedAt = time.Now()
+}
+
+// RecordAnswer adds answer to the question, keeping it both by question text and by question ID
+func (us *UserState) RecordAnswer(questionID, question string, values ...string) {
+	us.AddAnswer(question, strings.Join(values, ", "))
+	if us.Responses == nil {
+		us.Responses = make(map[string][]string)
+	}
+	us.Responses[questionID] = append([]string(nil), values...)
+}
+
+// SetAttribution records deep link campaign and source, keeping previous values for empty ones
+func (us *UserState) SetAttribution(campaign, source string) {
+	if campaign != "" {
+		us.Campaign = campaign
+	}
+	if source != "" {
+		us.Source = source
+	}
+}
+
+// Reset clears survey progress and answers, keeping previous submissions and attribution
+func (us *UserState) Reset() {
+	us.CurrentQuestionID = ""
+	us.Answers = make(map[string]string)
+	us.StartedAt = time.Time{}
+	us.UpdatedAt = time.Time{}
+	us.CompletedAt = time.Time{}
+	us.ExpiredAt = time.Time{}
+	us.Outcome = ""
+	us.Scores = nil
+	us.Visits = nil
+	us.Responses = nil
+}
+
+// Abandon records the unfinished attempt as a drop-off before it is reset; other attempts are ignored
+func (us *UserState) Abandon(abandonedAt time.Time) {
+	if us.CurrentQuestionID == "" || us.IsCompleted() {
+		return
+	}
+	visits := append([]Visit(nil), us.Visits...)
+	if len(visits) == 0 {
+		// States saved before visits were recorded only know the current question
+		visits = []Visit{{QuestionID: us.CurrentQuestionID, At: us.StartedAt}}
+	}
+	us.DropOffs = append(us.DropOffs, DropOff{
+		SurveyID:    us.SurveyID,
+		Campaign:    us.Campaign,
+		Source:      us.Source,
+		StartedAt:   us.StartedAt,
+		AbandonedAt: abandonedAt,
+		Expired:     us.IsExpired(),
+		Visits:      visits,
+	})
+}
+
+// Reach moves the user to the question and records the visit
+func (us *UserState) Reach(questionID string, at time.Time) {
+	us.CurrentQuestionID = questionID
+	us.Visits = append(us.Visits, Visit{QuestionID: questionID, At: at})
+}
+
+// SetScore records result of a scored answer to the question
+func (us *UserState) SetScore(questionID string, score QuestionScore) {
+	if us.Scores == nil {
+		us.Scores = make(map[string]QuestionScore)
+	}
+	us.Scores[questionID] = score
+}
+
+// IsScored checks if any answer of the attempt was scored
+func (us *UserState) IsScored() bool {
+	return len(us.Scores) > 0
+}
+
+// Score returns total points of the attempt
+func (us *UserState) Score() int {
+	total := 0
+	for _, score := range us.Scores {
+		total += score.Points
+	}
+	return total
+}
+
+// CorrectCount returns numbers of correct and of all answered graded questions
+func (us *UserState) CorrectCount() (int, int) {
+	correct, graded := 0, 0
+	for _, score := range us.Scores {
+		if score.Graded {
+			graded++
+			if score.Correct {
+				correct++
+			}
+		}
+	}
+	return correct, graded
+}
+
+// IsCompleted checks if user has reached a terminal question
+func (us *UserState) IsCompleted() bool {
+	return !us.CompletedAt.IsZero()
+}
+
+// IsExpired checks if the attempt was abandoned and expired after inactivity
+func (us *UserState) IsExpired() bool {
+	return !us.ExpiredAt.IsZero()
+}
+
+// Expire marks the unfinished attempt abandoned, keeping the question it was abandoned at
+func (us *UserState) Expire(expiredAt time.Time) {
+	us.ExpiredAt = expiredAt
+}
+
+// IsInProgress checks if user has started but neither completed the survey nor let it expire
+func (us *UserState) IsInProgress() bool {
+	return us.CurrentQuestionID != "" && !us.IsCompleted() && !us.IsExpired()
+}
+
+// Complete marks survey as completed and returns the resulting submission
+func (us *UserState) Complete(userID int64, terminalQuestionID string, completedAt time.Time) *Submission {
+	us.CompletedAt = completedAt
+	us.Outcome = terminalQuestionID
+
+	submission := Submission{
+		ID:                 SubmissionID(userID, completedAt),
+		SurveyID:           us.SurveyID,
+		UserID:             userID,
+		Name:               us.Name,
+		UserName:           us.UserName,
+		TerminalQuestionID: terminalQuestionID,
+		Campaign:           us.Campaign,
+		Source:             us.Source,
+		StartedAt:          us.StartedAt,
+		CompletedAt:        completedAt,
+		Answers:            cloneMap(us.Answers),
+		Visits:             append([]Visit(nil), us.Visits...),
+	}
+	if len(us.Responses) > 0 {
+		submission.Responses = cloneResponses(us.Responses)
+	}
+	if us.IsScored() {
+		score := us.Score()
+		submission.Score = &score
+	}
+	us.Submissions = append(us.Submissions, submission)
+
+	return &submission
+}
+
+// Submission represents a completed survey attempt
+type Submission struct {
+	ID                 string              `json:"id,omitempty"` // Stable across deliveries, so sinks can drop duplicates
+	SurveyID           string              `json:"survey_id,omitempty"`
+	UserID             int64               `json:"user_id"`
+	Name               string              `json:"name"`
+	UserName           string              `json:"username,omitempty"`
+	TerminalQuestionID string              `json:"terminal_question_id"`
+	Campaign           string              `json:"campaign,omitempty"`
+	Source             string              `json:"source,omitempty"`
+	StartedAt          time.Time           `json:"started_at"`
+	CompletedAt        time.Time           `json:"completed_at"`
+	Answers            map[string]string   `json:"answers"`
+	Responses          map[string][]string `json:"responses,omitempty"` // Answer values by question ID
+	Score              *int                `json:"score,omitempty"`     // Set for attempts with scored answers
+	Visits             []Visit             `json:"visits,omitempty"`    // Questions reached during the attempt in order
+}
+
+// SubmissionID returns ID of the submission of the user completed at the given time
+func SubmissionID(userID int64, completedAt time.Time) string {
+	return fmt.Sprintf("%d-%d", userID, completedAt.UnixNano())
+}
+
+// Answer is a single answer delivered to sinks as soon as it is given
+type Answer struct {
+	SurveyID   string    `json:"survey_id,omitempty"`
+	UserID     int64     `json:"user_id"`
+	Name       string    `json:"name"`
+	UserName   string    `json:"username,omitempty"`
+	QuestionID string    `json:"question_id"`
+	Question   string    `json:"question"`
+	Answer     string    `json:"answer"`
+	AnsweredAt time.Time `json:"answered_at"`
+}
+
+// AnswerSink receives single answers before the survey completes; result sinks may implement it
+type AnswerSink interface {
+	DeliverAnswer(answer *Answer) error
+}
+
+// ResultSink receives completed survey submissions
+type ResultSink interface {
+	Deliver(submission *Submission) error
 }
 
 // GetAnswer returns user answer to question
@@ -107,20 +613,37 @@ func (us *UserState) GetAnswer(question string) (string, bool) {
 
 // BotService interface for working with bot
 type BotService interface {
-	SendImages(userID int64, images []string, delay int) error
-	SendMessage(userID int64, text string, keyboard interface{}) error
-	SendMessages(userID int64, messages []string, userName string, keyboard interface{}) error
-	ProcessQuestion(userID int64, question *Question) error
-	ProcessAnswer(userID int64, answer string) error
-	ProcessOptionAnswer(userID int64, optionText string) error
-	HandleAutoAdvance(userID int64, question *Question) error
-	GetAPI() *tgbotapi.BotAPI // Returns Telegram Bot API instance
+	SendImages(ctx context.Context, userID int64, images []string, delay int) error
+	SendMessage(ctx context.Context, userID int64, text string, keyboard interface{}) error
+	SendMessages(ctx context.Context, userID int64, messages []string, userName string, keyboard interface{}) error
+	ProcessQuestion(ctx context.Context, userID int64, question *Question) error
+	ProcessAnswer(ctx context.Context, userID int64, answer string) error
+	ProcessOptionAnswer(ctx context.Context, userID int64, optionText string) error
+	HandleAutoAdvance(ctx context.Context, userID int64, question *Question) error
+	SendDocument(ctx context.Context, userID int64, fileName string, data []byte) error
+	AnswerCallback(ctx context.Context, callbackID string) error
 }
 
-// QuestionService interface for working with questions
+// Messenger sends requests to Telegram chats.
+// Keeping it narrow lets the bot run against a fake in tests.
+type Messenger interface {
+	SendText(chatID int64, text string, markup interface{}) (int, error) // Returns ID of the sent message
+	SendPhoto(chatID int64, path string) error
+	SendMediaGroup(chatID int64, paths []string) error
+	SendDocument(chatID int64, fileName string, data []byte) error
+	AnswerCallback(callbackID, text string) error
+	EditMessageText(chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) error
+	SendChatAction(chatID int64, action string) error
+}
+
+// QuestionService interface for working with questions.
+// Methods without survey ID refer to the default survey.
 type QuestionService interface {
 	GetQuestion(id string) (*Question, error)
 	GetAllQuestions() map[string]Question
+	GetSurveyQuestion(surveyID, id string) (*Question, error)
+	GetSurvey(surveyID string) (*Survey, error)
+	ListSurveys() []Survey
 }
 
 // UserStateService interface for working with user states
@@ -129,4 +652,20 @@ type UserStateService interface {
 	SetUserState(userID int64, state *UserState)
 	UpdateCurrentQuestion(userID int64, questionID string)
 	GetOrCreateUserState(userID int64, userName string) *UserState
+	// GetAllUserStates returns copies of all user states, safe to read while updates are handled
+	GetAllUserStates() map[int64]*UserState
+	DeleteUserState(userID int64) bool
+	// LockUser serializes changes to the user's state; goroutines handling an update, a reminder or
+	// an expiry hold the lock until they are done and call the returned function
+	LockUser(userID int64) (unlock func())
+	// SaveUserState persists the user state after it changed; it does nothing without a store.
+	// It must be called with the user locked.
+	SaveUserState(userID int64) error
+}
+
+// StateStore persists user states across restarts
+type StateStore interface {
+	Save(userID int64, state *UserState) error
+	Delete(userID int64) error
+	LoadAll() (map[int64]*UserState, error)
 }
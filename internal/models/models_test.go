@@ -1,7 +1,10 @@
 package models
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestConfigValidate(t *testing.T) {
@@ -44,6 +47,53 @@ func TestConfigValidate(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "negative session timeout",
+			config: Config{
+				TelegramToken:   "valid_token",
+				StartQuestionID: "start",
+				SessionTimeout:  Duration(-time.Hour),
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative reminder limit",
+			config: Config{
+				TelegramToken:   "valid_token",
+				StartQuestionID: "start",
+				ReminderLimit:   -1,
+			},
+			expectErr: true,
+		},
+		{
+			name: "webhook without attempts",
+			config: Config{
+				TelegramToken:   "valid_token",
+				StartQuestionID: "start",
+				WebhookURL:      "https://example.com/hook",
+			},
+			expectErr: true,
+		},
+		{
+			name: "webhook with unsupported scheme",
+			config: Config{
+				TelegramToken:      "valid_token",
+				StartQuestionID:    "start",
+				WebhookURL:         "ftp://example.com/hook",
+				WebhookMaxAttempts: 3,
+			},
+			expectErr: true,
+		},
+		{
+			name: "webhook",
+			config: Config{
+				TelegramToken:      "valid_token",
+				StartQuestionID:    "start",
+				WebhookURL:         "https://example.com/hook",
+				WebhookMaxAttempts: 3,
+			},
+			expectErr: false,
+		},
 		{
 			name: "zero delay is valid",
 			config: Config{
@@ -283,3 +333,376 @@ func TestUserStateUpdateAnswer(t *testing.T) {
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestConfigIsAdmin(t *testing.T) {
+	config := Config{AdminUserIDs: []int64{1, 2}}
+
+	if !config.IsAdmin(2) {
+		t.Error("Expected user 2 to be admin")
+	}
+	if config.IsAdmin(3) {
+		t.Error("Expected user 3 not to be admin")
+	}
+	if (&Config{}).IsAdmin(1) {
+		t.Error("Expected no admins without configured IDs")
+	}
+}
+
+func TestUserStateReset(t *testing.T) {
+	state := NewUserState("John")
+	state.CurrentQuestionID = "question_1"
+	state.AddAnswer("question", "answer")
+
+	state.Reset()
+
+	if state.CurrentQuestionID != "" {
+		t.Errorf("Expected empty current question, got %s", state.CurrentQuestionID)
+	}
+	if len(state.Answers) != 0 {
+		t.Errorf("Expected no answers, got %v", state.Answers)
+	}
+	if !state.UpdatedAt.IsZero() {
+		t.Error("Expected UpdatedAt to be cleared")
+	}
+	if state.Name != "John" {
+		t.Errorf("Expected name to be kept, got %s", state.Name)
+	}
+}
+
+func TestUserStateExpire(t *testing.T) {
+	state := NewUserState("John")
+	state.CurrentQuestionID = "question_1"
+	if !state.IsInProgress() {
+		t.Fatal("Expected survey in progress")
+	}
+
+	state.Expire(time.Now())
+	if !state.IsExpired() || state.IsInProgress() {
+		t.Error("Expected expired survey not to be in progress")
+	}
+	if state.CurrentQuestionID != "question_1" {
+		t.Errorf("Expected question to be kept, got %s", state.CurrentQuestionID)
+	}
+
+	state.Reset()
+	if state.IsExpired() {
+		t.Error("Expected reset to clear expiry")
+	}
+}
+
+func TestUserStateReach(t *testing.T) {
+	state := NewUserState("John")
+	startedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	state.Reach("start", startedAt)
+	state.Reach("question_1", startedAt.Add(time.Minute))
+
+	if state.CurrentQuestionID != "question_1" {
+		t.Errorf("Expected current question question_1, got %s", state.CurrentQuestionID)
+	}
+	expected := []Visit{{QuestionID: "start", At: startedAt}, {QuestionID: "question_1", At: startedAt.Add(time.Minute)}}
+	if !reflect.DeepEqual(state.Visits, expected) {
+		t.Errorf("Expected visits %v, got %v", expected, state.Visits)
+	}
+
+	state.Reset()
+	if state.Visits != nil {
+		t.Errorf("Expected reset to clear visits, got %v", state.Visits)
+	}
+}
+
+func TestUserStateAbandon(t *testing.T) {
+	startedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	abandonedAt := startedAt.Add(time.Hour)
+
+	state := NewUserState("John")
+	state.Abandon(abandonedAt)
+	if len(state.DropOffs) != 0 {
+		t.Fatalf("Expected no drop-off without an attempt, got %+v", state.DropOffs)
+	}
+
+	state.SurveyID = "quiz"
+	state.StartedAt = startedAt
+	state.Reach("start", startedAt)
+	state.Reach("question_1", startedAt.Add(time.Minute))
+	state.Abandon(abandonedAt)
+	state.Reset()
+
+	expected := []DropOff{{
+		SurveyID:    "quiz",
+		StartedAt:   startedAt,
+		AbandonedAt: abandonedAt,
+		Visits:      []Visit{{QuestionID: "start", At: startedAt}, {QuestionID: "question_1", At: startedAt.Add(time.Minute)}},
+	}}
+	if !reflect.DeepEqual(state.DropOffs, expected) {
+		t.Errorf("Expected drop-offs %+v, got %+v", expected, state.DropOffs)
+	}
+
+	state.Reach("end", startedAt)
+	state.Complete(123, "end", startedAt)
+	state.Abandon(abandonedAt)
+	if len(state.DropOffs) != 1 {
+		t.Errorf("Expected completed attempt not to be a drop-off, got %+v", state.DropOffs)
+	}
+}
+
+func TestDurationText(t *testing.T) {
+	tests := []struct {
+		text      string
+		expected  Duration
+		formatted string
+		expectErr bool
+	}{
+		{text: "30m", expected: Duration(30 * time.Minute), formatted: "30m"},
+		{text: "2h", expected: Duration(2 * time.Hour), formatted: "2h"},
+		{text: " 1h30m ", expected: Duration(90 * time.Minute), formatted: "1h30m"},
+		{text: "90s", expected: Duration(90 * time.Second), formatted: "1m30s"},
+		{text: "0s", expected: 0, formatted: "0s"},
+		{text: "", expected: 0, formatted: "0s"},
+		{text: "30", expectErr: true},
+		{text: "soon", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var d Duration
+			err := d.UnmarshalText([]byte(tt.text))
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("UnmarshalText() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if d != tt.expected {
+				t.Errorf("Expected %v, got %v", time.Duration(tt.expected), time.Duration(d))
+			}
+			if text, _ := d.MarshalText(); string(text) != tt.formatted {
+				t.Errorf("Expected %q, got %q", tt.formatted, text)
+			}
+		})
+	}
+}
+
+func TestQuestionIsTerminal(t *testing.T) {
+	showSummary := false
+
+	tests := []struct {
+		name            string
+		question        Question
+		wantTerminal    bool
+		wantShowSummary bool
+	}{
+		{"regular question", Question{ID: "q1"}, false, false},
+		{"terminal flag", Question{ID: "qualified", Terminal: true}, true, true},
+		{"legacy end question", Question{ID: EndQuestionID}, true, true},
+		{"terminal without summary", Question{ID: "disqualified", Terminal: true, ShowSummary: &showSummary}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.question.IsTerminal(); got != tt.wantTerminal {
+				t.Errorf("IsTerminal() = %v, want %v", got, tt.wantTerminal)
+			}
+			if got := tt.question.ShouldShowSummary(); got != tt.wantShowSummary {
+				t.Errorf("ShouldShowSummary() = %v, want %v", got, tt.wantShowSummary)
+			}
+		})
+	}
+}
+
+func TestUserStateComplete(t *testing.T) {
+	state := NewUserState("John")
+	state.UserName = "johndoe"
+	state.AddAnswer("question", "answer")
+	completedAt := time.Now()
+
+	submission := state.Complete(123, "qualified", completedAt)
+
+	if !state.IsCompleted() || state.Outcome != "qualified" {
+		t.Errorf("Expected completed state, got %+v", state)
+	}
+	if submission.UserID != 123 || submission.UserName != "johndoe" || !submission.CompletedAt.Equal(completedAt) {
+		t.Errorf("Unexpected submission: %+v", submission)
+	}
+	if expected := fmt.Sprintf("123-%d", completedAt.UnixNano()); submission.ID != expected {
+		t.Errorf("Expected submission ID %s, got %q", expected, submission.ID)
+	}
+
+	if len(state.Submissions) != 1 || state.Submissions[0].TerminalQuestionID != "qualified" {
+		t.Errorf("Expected submission to be kept in state, got %+v", state.Submissions)
+	}
+
+	// Reset starts a new attempt but keeps submissions
+	state.Reset()
+	if len(state.Submissions) != 1 {
+		t.Errorf("Expected submissions to survive reset, got %d", len(state.Submissions))
+	}
+
+	// Submission must not share answers with state
+	state.AddAnswer("question", "changed")
+	if submission.Answers["question"] != "answer" {
+		t.Errorf("Expected submission answers to be a copy, got %v", submission.Answers)
+	}
+}
+
+func TestUserStateClone(t *testing.T) {
+	state := NewUserState("John")
+	state.RecordAnswer("color", "Color?", "Red")
+	state.SetScore("color", QuestionScore{Points: 1})
+	state.Reach("color", time.Now())
+	state.Complete(123, "end", time.Now())
+	state.Submissions[0].Score = new(int)
+	state.DropOffs = []DropOff{{SurveyID: "quiz", Visits: []Visit{{QuestionID: "start"}}}}
+
+	clone := state.Clone()
+	if !reflect.DeepEqual(clone, state) {
+		t.Fatalf("Expected equal clone, got %+v", clone)
+	}
+
+	// Changing the original must not show in the clone
+	state.RecordAnswer("color", "Color?", "Blue")
+	state.SetScore("color", QuestionScore{Points: 5})
+	state.Reach("end", time.Now())
+	state.Submissions[0].Answers["Color?"] = "Green"
+	state.Submissions[0].Responses["color"][0] = "Green"
+	*state.Submissions[0].Score = 7
+	state.DropOffs[0].Visits[0].QuestionID = "color"
+	if clone.DropOffs[0].Visits[0].QuestionID != "start" {
+		t.Errorf("Expected clone to keep its drop-offs, got %+v", clone.DropOffs)
+	}
+	if clone.Answers["Color?"] != "Red" || clone.Responses["color"][0] != "Red" || clone.Scores["color"].Points != 1 ||
+		len(clone.Visits) != 1 || clone.Submissions[0].Answers["Color?"] != "Red" ||
+		clone.Submissions[0].Responses["color"][0] != "Red" || *clone.Submissions[0].Score != 0 {
+		t.Errorf("Expected clone to share nothing with the original, got %+v", clone)
+	}
+}
+
+func TestUserStateRecordAnswer(t *testing.T) {
+	state := NewUserState("John")
+	state.RecordAnswer("colors", "Colors?", "Red", "Blue")
+	state.RecordAnswer("size", "Size?", "L")
+
+	if answer, _ := state.GetAnswer("Colors?"); answer != "Red, Blue" {
+		t.Errorf("Expected answer by question text, got %q", answer)
+	}
+	expected := map[string][]string{"colors": {"Red", "Blue"}, "size": {"L"}}
+	if !reflect.DeepEqual(state.Responses, expected) {
+		t.Errorf("Expected responses %v, got %v", expected, state.Responses)
+	}
+
+	// Submission must not share responses with state
+	submission := state.Complete(123, "end", time.Now())
+	state.RecordAnswer("size", "Size?", "M")
+	if !reflect.DeepEqual(submission.Responses, expected) {
+		t.Errorf("Expected submission responses to be a copy, got %v", submission.Responses)
+	}
+
+	state.Reset()
+	if state.Responses != nil {
+		t.Errorf("Expected reset to clear responses, got %v", state.Responses)
+	}
+}
+
+func TestUserStateSetAttribution(t *testing.T) {
+	state := NewUserState("John")
+
+	state.SetAttribution("spring", "instagram")
+	state.SetAttribution("", "newsletter")
+
+	if state.Campaign != "spring" || state.Source != "newsletter" {
+		t.Errorf("Expected spring/newsletter, got %s/%s", state.Campaign, state.Source)
+	}
+
+	state.Reset()
+	if state.Campaign != "spring" {
+		t.Error("Expected attribution to survive reset")
+	}
+}
+
+func TestQuestionIsCorrect(t *testing.T) {
+	question := Question{ID: "q", CorrectAnswer: "Paris"}
+
+	tests := []struct {
+		answer   string
+		expected bool
+	}{
+		{"Paris", true},
+		{"  paris ", true},
+		{"London", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if result := question.IsCorrect(tt.answer); result != tt.expected {
+			t.Errorf("IsCorrect(%q) = %v, expected %v", tt.answer, result, tt.expected)
+		}
+	}
+
+	ungraded := Question{ID: "u"}
+	if ungraded.IsCorrect("") || ungraded.IsGraded() {
+		t.Error("Expected question without correct answer not to be graded")
+	}
+}
+
+func TestQuestionScoreBranch(t *testing.T) {
+	low, high := 3, 7
+	question := Question{
+		ID: "result",
+		ScoreBranches: []ScoreBranch{
+			{MinScore: &high, NextID: "excellent"},
+			{MinScore: &low, MaxScore: &high, NextID: "good"},
+		},
+	}
+
+	tests := []struct {
+		score      int
+		expectedID string
+		expectedOK bool
+	}{
+		{10, "excellent", true},
+		{7, "excellent", true},
+		{5, "good", true},
+		{3, "good", true},
+		{2, "", false},
+	}
+
+	for _, tt := range tests {
+		nextID, ok := question.ScoreBranch(tt.score)
+		if nextID != tt.expectedID || ok != tt.expectedOK {
+			t.Errorf("ScoreBranch(%d) = %q, %v, expected %q, %v", tt.score, nextID, ok, tt.expectedID, tt.expectedOK)
+		}
+	}
+}
+
+func TestUserStateScore(t *testing.T) {
+	us := NewUserState("John")
+	if us.IsScored() || us.Score() != 0 {
+		t.Error("Expected new state without score")
+	}
+
+	us.SetScore("q1", QuestionScore{Points: 2, Graded: true, Correct: true})
+	us.SetScore("q2", QuestionScore{Graded: true})
+	us.SetScore("bonus", QuestionScore{Points: 1})
+	// Answering again replaces the result
+	us.SetScore("q2", QuestionScore{Points: 1, Graded: true, Correct: true})
+
+	if us.Score() != 4 {
+		t.Errorf("Expected score 4, got %d", us.Score())
+	}
+	if correct, graded := us.CorrectCount(); correct != 2 || graded != 2 {
+		t.Errorf("Expected 2 of 2 correct, got %d of %d", correct, graded)
+	}
+
+	submission := us.Complete(1, "end", time.Now())
+	if submission.Score == nil || *submission.Score != 4 {
+		t.Errorf("Expected submission score 4, got %v", submission.Score)
+	}
+
+	us.Reset()
+	if us.IsScored() {
+		t.Error("Expected reset to clear score")
+	}
+	if submission := us.Complete(1, "end", time.Now()); submission.Score != nil {
+		t.Errorf("Expected no score for unscored attempt, got %d", *submission.Score)
+	}
+}
@@ -0,0 +1,320 @@
+// Package outbox keeps completed submissions on disk until every result sink has accepted them.
+// Submissions are written before any delivery is attempted and removed only after it succeeds, so a sink
+// that is down or a restart never loses a result; a sink may receive the same submission more than once.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tlgbot/internal/logging"
+	"tlgbot/internal/metrics"
+	"tlgbot/internal/models"
+	"tlgbot/internal/storage"
+)
+
+// Retry settings
+const (
+	initialBackoff = 10 * time.Second
+	maxBackoff     = time.Hour
+	// StuckAttempts is the number of failed attempts after which an item is reported as stuck
+	StuckAttempts = 3
+)
+
+// fileExtension is the extension of item files
+const fileExtension = ".json"
+
+// Item is a submission waiting for delivery to a single sink
+type Item struct {
+	// Key identifies the item: submission ID and sink name. Sinks can use the submission ID to drop duplicates.
+	Key           string             `json:"key"`
+	Sink          string             `json:"sink"`
+	Submission    *models.Submission `json:"submission"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Attempts      int                `json:"attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at"`
+	LastError     string             `json:"last_error,omitempty"`
+}
+
+// Stuck checks if the item failed too many times to be a passing outage
+func (i Item) Stuck() bool {
+	return i.Attempts >= StuckAttempts
+}
+
+// Outbox stores items as <key>.json files in a directory and delivers them from a background worker
+type Outbox struct {
+	path    string
+	metrics *metrics.Metrics
+
+	mu    sync.Mutex
+	sinks map[string]models.ResultSink
+	items map[string]*Item
+	// wake tells the worker new items arrived
+	wake chan struct{}
+
+	backoff time.Duration
+	now     func() time.Time
+}
+
+// Open opens the outbox directory, creating it when missing, and loads items left from previous runs
+func Open(path string) (*Outbox, error) {
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory %s: %w", path, err)
+	}
+
+	o := &Outbox{
+		path:    path,
+		sinks:   make(map[string]models.ResultSink),
+		items:   make(map[string]*Item),
+		wake:    make(chan struct{}, 1),
+		backoff: initialBackoff,
+		now:     time.Now,
+	}
+	if err := o.load(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// SetMetrics enables counting of delivery attempts
+func (o *Outbox) SetMetrics(m *metrics.Metrics) {
+	o.metrics = m
+}
+
+// Register adds a sink under a name stored in its items; names must stay the same across restarts
+func (o *Outbox) Register(name string, sink models.ResultSink) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sinks[name] = sink
+}
+
+// Deliver stores the submission as an item per registered sink and wakes the worker
+func (o *Outbox) Deliver(submission *models.Submission) error {
+	if submission.ID == "" {
+		withID := *submission
+		withID.ID = models.SubmissionID(submission.UserID, submission.CompletedAt)
+		submission = &withID
+	}
+	id := submission.ID
+
+	o.mu.Lock()
+	names := make([]string, 0, len(o.sinks))
+	for name := range o.sinks {
+		names = append(names, name)
+	}
+	o.mu.Unlock()
+	sort.Strings(names)
+
+	now := o.now()
+	for _, name := range names {
+		item := &Item{
+			Key:           id + "." + name,
+			Sink:          name,
+			Submission:    submission,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		}
+		if err := o.save(item); err != nil {
+			return err
+		}
+		o.mu.Lock()
+		o.items[item.Key] = item
+		o.mu.Unlock()
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pending returns copies of items not delivered yet, oldest first
+func (o *Outbox) Pending() []Item {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	items := make([]Item, 0, len(o.items))
+	for _, item := range o.items {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].CreatedAt.Before(items[j].CreatedAt)
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+// Len returns number of items not delivered yet
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.items)
+}
+
+// Run delivers items as they become due until ctx is done
+func (o *Outbox) Run(ctx context.Context) {
+	for {
+		next := o.RunDue(ctx)
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(max(next.Sub(o.now()), 0))
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-o.wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// RunDue makes an attempt for every due item and returns when the next remaining item is due, zero when none are left
+func (o *Outbox) RunDue(ctx context.Context) time.Time {
+	now := o.now()
+	var due []Item
+	for _, item := range o.Pending() {
+		if !item.NextAttemptAt.After(now) {
+			due = append(due, item)
+		}
+	}
+
+	for _, item := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		o.attempt(ctx, item)
+	}
+
+	var next time.Time
+	for _, item := range o.Pending() {
+		if next.IsZero() || item.NextAttemptAt.Before(next) {
+			next = item.NextAttemptAt
+		}
+	}
+	return next
+}
+
+// attempt delivers the item to its sink, removing it on success and scheduling a retry on failure
+func (o *Outbox) attempt(ctx context.Context, item Item) {
+	o.mu.Lock()
+	sink := o.sinks[item.Sink]
+	o.mu.Unlock()
+
+	err := fmt.Errorf("sink %s is not configured", item.Sink)
+	if sink != nil {
+		err = sink.Deliver(item.Submission)
+	}
+	o.metrics.OutboxAttempt(item.Sink, err == nil)
+	logger := slog.With("key", item.Key, "sink", item.Sink, logging.KeyUserID, item.Submission.UserID)
+
+	if err == nil {
+		o.mu.Lock()
+		delete(o.items, item.Key)
+		o.mu.Unlock()
+		// A file left behind only means the submission is delivered again after a restart
+		if err := os.Remove(o.file(item.Key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.ErrorContext(ctx, "Failed to remove delivered outbox item", "error", err)
+		}
+		return
+	}
+
+	item.Attempts++
+	item.LastError = err.Error()
+	item.NextAttemptAt = o.now().Add(o.retryDelay(item.Attempts))
+	logger.WarnContext(ctx, "Outbox delivery failed", "attempt", item.Attempts, "next_attempt_at", item.NextAttemptAt, "error", err)
+
+	if err := o.save(&item); err != nil {
+		logger.ErrorContext(ctx, "Failed to save outbox item", "error", err)
+	}
+	o.mu.Lock()
+	o.items[item.Key] = &item
+	o.mu.Unlock()
+}
+
+// retryDelay returns the pause after the given number of failed attempts, doubled for each and capped
+func (o *Outbox) retryDelay(attempts int) time.Duration {
+	delay := o.backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// Check verifies the directory accepts writes, for readiness probes
+func (o *Outbox) Check(_ context.Context) error {
+	tmp, err := os.CreateTemp(o.path, ".check-*")
+	if err != nil {
+		return fmt.Errorf("outbox directory is not writable: %w", err)
+	}
+	_ = tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+// save writes the item file atomically
+func (o *Outbox) save(item *Item) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox item %s: %w", item.Key, err)
+	}
+	if err := storage.WriteFileAtomic(o.file(item.Key), data); err != nil {
+		return fmt.Errorf("failed to save outbox item %s: %w", item.Key, err)
+	}
+	return nil
+}
+
+// load reads items stored in the directory
+func (o *Outbox) load() error {
+	entries, err := os.ReadDir(o.path)
+	if err != nil {
+		return fmt.Errorf("failed to read outbox directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileExtension) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(o.path, name)) //nolint:gosec // G304: Files are listed from the outbox directory
+		if err != nil {
+			return fmt.Errorf("failed to read outbox item %s: %w", name, err)
+		}
+		var item Item
+		if err := json.Unmarshal(data, &item); err != nil {
+			return fmt.Errorf("failed to decode outbox item %s: %w", name, err)
+		}
+		if item.Submission == nil || item.Key+fileExtension != name {
+			return fmt.Errorf("invalid outbox item %s", name)
+		}
+		o.items[item.Key] = &item
+	}
+	return nil
+}
+
+// file returns path of the item file
+func (o *Outbox) file(key string) string {
+	return filepath.Join(o.path, key+fileExtension)
+}
@@ -0,0 +1,193 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+// fakeSink records submissions and fails while err is set
+type fakeSink struct {
+	err         error
+	submissions []*models.Submission
+}
+
+func (s *fakeSink) Deliver(submission *models.Submission) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.submissions = append(s.submissions, submission)
+	return nil
+}
+
+func createSubmission() *models.Submission {
+	completedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return &models.Submission{
+		ID:          models.SubmissionID(123, completedAt),
+		UserID:      123,
+		Name:        "Ann",
+		CompletedAt: completedAt,
+		Answers:     map[string]string{"Color?": "Red"},
+	}
+}
+
+// openAt opens an outbox whose clock returns the value pointed to by now
+func openAt(t *testing.T, path string, now *time.Time) *Outbox {
+	t.Helper()
+	box, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	box.now = func() time.Time { return *now }
+	return box
+}
+
+func TestDeliverSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	box := openAt(t, dir, &now)
+	box.Register("webhook", &fakeSink{err: errors.New("down")})
+	box.Register("admin_chat", &fakeSink{})
+
+	if err := box.Deliver(createSubmission()); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+	for _, key := range []string{"123-1714564800000000000.admin_chat", "123-1714564800000000000.webhook"} {
+		if _, err := os.Stat(filepath.Join(dir, key+fileExtension)); err != nil {
+			t.Errorf("Expected item file %s before delivery: %v", key, err)
+		}
+	}
+
+	// Items stay on disk until delivered, so a restarted outbox picks them up
+	reopened := openAt(t, dir, &now)
+	webhook := &fakeSink{}
+	reopened.Register("webhook", webhook)
+	reopened.Register("admin_chat", &fakeSink{})
+	if reopened.Len() != 2 {
+		t.Fatalf("Expected 2 items after restart, got %d", reopened.Len())
+	}
+
+	if next := reopened.RunDue(context.Background()); !next.IsZero() {
+		t.Errorf("Expected nothing left to deliver, next attempt at %v", next)
+	}
+	if len(webhook.submissions) != 1 || webhook.submissions[0].ID != "123-1714564800000000000" {
+		t.Errorf("Expected the submission with its ID, got %+v", webhook.submissions)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected delivered items to be removed, got %d files", len(entries))
+	}
+}
+
+func TestRunDueRetries(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	box := openAt(t, t.TempDir(), &now)
+	sink := &fakeSink{err: errors.New("connection refused")}
+	box.Register("webhook", sink)
+	if err := box.Deliver(createSubmission()); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+
+	for attempt := 1; attempt <= StuckAttempts; attempt++ {
+		next := box.RunDue(context.Background())
+		if expected := now.Add(box.retryDelay(attempt)); !next.Equal(expected) {
+			t.Fatalf("Attempt %d: expected next attempt at %v, got %v", attempt, expected, next)
+		}
+		// Items are not retried before they are due
+		box.RunDue(context.Background())
+		now = next
+	}
+
+	item := box.Pending()[0]
+	if item.Attempts != StuckAttempts || !item.Stuck() || item.LastError != "connection refused" {
+		t.Errorf("Expected stuck item with last error, got %+v", item)
+	}
+
+	sink.err = nil
+	box.RunDue(context.Background())
+	if len(sink.submissions) != 1 || box.Len() != 0 {
+		t.Errorf("Expected delivery once the sink recovers, got %d delivered and %d pending", len(sink.submissions), box.Len())
+	}
+}
+
+func TestRunDueUnknownSink(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	box := openAt(t, dir, &now)
+	box.Register("sheets", &fakeSink{err: errors.New("down")})
+	if err := box.Deliver(createSubmission()); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+
+	// The sink is no longer configured after restart, the item waits instead of being dropped
+	reopened := openAt(t, dir, &now)
+	reopened.RunDue(context.Background())
+	pending := reopened.Pending()
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError != "sink sheets is not configured" {
+		t.Errorf("Expected item kept with error, got %+v", pending)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	box := &Outbox{backoff: initialBackoff}
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: 10 * time.Second},
+		{attempts: 2, expected: 20 * time.Second},
+		{attempts: 4, expected: 80 * time.Second},
+		{attempts: 100, expected: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := box.retryDelay(tt.attempts); got != tt.expected {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.expected)
+		}
+	}
+}
+
+func TestOpenInvalidItem(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatalf("Failed to write item: %v", err)
+	}
+
+	if _, err := Open(dir); err == nil {
+		t.Error("Expected error for an undecodable item")
+	}
+}
+
+func TestRun(t *testing.T) {
+	box, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open outbox: %v", err)
+	}
+	sink := &fakeSink{}
+	box.Register("webhook", sink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		box.Run(ctx)
+		close(done)
+	}()
+
+	if err := box.Deliver(createSubmission()); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for box.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if box.Len() != 0 {
+		t.Errorf("Expected the worker to deliver the item, %d pending", box.Len())
+	}
+}
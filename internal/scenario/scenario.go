@@ -0,0 +1,338 @@
+// Package scenario runs scripted conversations against survey questions and reports mismatches.
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tlgbot/internal/config"
+	"tlgbot/internal/messenger"
+	"tlgbot/internal/models"
+	"tlgbot/internal/simulator"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a scripted conversation with expectations
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Questions is path to the questions file, relative to the scenario file
+	Questions string `yaml:"questions"`
+	Start     string `yaml:"start"`
+	UserName  string `yaml:"user_name"`
+	Steps     []Step `yaml:"steps"`
+	// Expect is checked after all steps
+	Expect *Expectation `yaml:"expect"`
+
+	path string
+}
+
+// Step is a single user action, a pause or an intermediate check
+type Step struct {
+	Send  *string `yaml:"send"`
+	Press string  `yaml:"press"`
+	// Wait lets simulated time pass without user activity, e.g. 30m
+	Wait   *models.Duration `yaml:"wait"`
+	Expect *Expectation     `yaml:"expect"`
+}
+
+// Expectation describes expected conversation state; empty fields are not checked
+type Expectation struct {
+	// Question is ID of the current question
+	Question string `yaml:"question"`
+	// Message is text contained in a bot message sent in reply to the previous action
+	Message   string `yaml:"message"`
+	Completed *bool  `yaml:"completed"`
+	Expired   *bool  `yaml:"expired"`
+	Outcome   string `yaml:"outcome"`
+	Score     *int   `yaml:"score"`
+	// Answers maps question IDs or texts to expected answers; other answers are not checked
+	Answers map[string]string `yaml:"answers"`
+}
+
+// Result is outcome of a scenario run
+type Result struct {
+	Name     string
+	Path     string
+	Failures []string
+}
+
+// Passed reports whether the scenario met all expectations
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Load reads a scenario file. Unknown fields are rejected to catch typos.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: Scenario path is given by the user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario %s: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	scenario := &Scenario{path: path}
+	if err := decoder.Decode(scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if scenario.Start == "" {
+		scenario.Start = config.DefaultStartQuestionID
+	}
+	if err := scenario.validate(); err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %w", path, err)
+	}
+
+	return scenario, nil
+}
+
+// RunFile loads and runs a scenario, reporting a file that cannot be loaded as failure
+func RunFile(path string) *Result {
+	scenario, err := Load(path)
+	if err != nil {
+		return &Result{Name: path, Path: path, Failures: []string{err.Error()}}
+	}
+	return scenario.Run()
+}
+
+// validate checks that every step has exactly one action or check
+func (s *Scenario) validate() error {
+	if s.Questions == "" {
+		return errors.New("questions file is required")
+	}
+
+	for i, step := range s.Steps {
+		kinds := 0
+		if step.Send != nil {
+			kinds++
+		}
+		if step.Press != "" {
+			kinds++
+		}
+		if step.Wait != nil {
+			kinds++
+		}
+		if step.Expect != nil {
+			kinds++
+		}
+		if kinds != 1 {
+			return fmt.Errorf("step %d must have exactly one of send, press, wait or expect", i+1)
+		}
+	}
+	return nil
+}
+
+// QuestionsPath returns path to the questions file resolved against the scenario location
+func (s *Scenario) QuestionsPath() string {
+	if filepath.IsAbs(s.Questions) {
+		return s.Questions
+	}
+	return filepath.Join(filepath.Dir(s.path), s.Questions)
+}
+
+// Run plays the scenario against its questions file
+func (s *Scenario) Run() *Result {
+	result := &Result{Name: s.Name, Path: s.path}
+
+	questions, err := config.LoadQuestions(s.QuestionsPath())
+	if err != nil {
+		result.Failures = append(result.Failures, err.Error())
+		return result
+	}
+
+	r := &run{
+		questions: questions,
+		recorder:  messenger.NewRecorder(),
+	}
+	r.session = simulator.NewSession(questions, simulator.Options{StartQuestionID: s.Start, UserName: s.UserName}, r.recorder)
+
+	for i, step := range s.Steps {
+		label := fmt.Sprintf("step %d (%s)", i+1, step)
+		if step.Expect != nil {
+			result.Failures = append(result.Failures, prefixed(label, r.check(step.Expect))...)
+			continue
+		}
+
+		if err := r.act(step); err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("%s: %v", label, err))
+			return result
+		}
+	}
+
+	if s.Expect != nil {
+		result.Failures = append(result.Failures, prefixed("final state", r.check(s.Expect))...)
+	}
+	return result
+}
+
+// String describes the step for failure reports
+func (step Step) String() string {
+	switch {
+	case step.Send != nil:
+		return fmt.Sprintf("send %q", *step.Send)
+	case step.Press != "":
+		return fmt.Sprintf("press %q", step.Press)
+	case step.Wait != nil:
+		return fmt.Sprintf("wait %s", step.Wait)
+	default:
+		return "expect"
+	}
+}
+
+// run holds state of a scenario being played
+type run struct {
+	questions map[string]models.Question
+	recorder  *messenger.Recorder
+	session   *simulator.Session
+
+	// replyStart is index of the first recorded call made in reply to the latest action
+	replyStart int
+}
+
+// act performs send, press or wait step
+func (r *run) act(step Step) error {
+	if step.Send != nil {
+		r.replyStart = len(r.recorder.Calls())
+		r.session.Send(*step.Send)
+		return nil
+	}
+	if step.Wait != nil {
+		r.replyStart = len(r.recorder.Calls())
+		r.session.Wait(time.Duration(*step.Wait))
+		return nil
+	}
+
+	data, err := r.findButton(step.Press)
+	if err != nil {
+		return err
+	}
+	r.replyStart = len(r.recorder.Calls())
+	r.session.Press(data)
+	return nil
+}
+
+// findButton returns callback data of the button on the latest inline keyboard sent to the user
+func (r *run) findButton(text string) (string, error) {
+	calls := r.recorder.CallsTo(messenger.MethodSendMessage)
+	for i := len(calls) - 1; i >= 0; i-- {
+		keyboard, ok := calls[i].Markup.(*tgbotapi.InlineKeyboardMarkup)
+		if !ok {
+			continue
+		}
+
+		var available []string
+		for _, row := range keyboard.InlineKeyboard {
+			for _, button := range row {
+				if button.CallbackData == nil {
+					continue
+				}
+				if button.Text == text {
+					return *button.CallbackData, nil
+				}
+				available = append(available, fmt.Sprintf("%q", button.Text))
+			}
+		}
+		return "", fmt.Errorf("button %q not found on the latest keyboard, available: %s", text, strings.Join(available, ", "))
+	}
+	return "", fmt.Errorf("button %q not found, no keyboard was sent", text)
+}
+
+// check compares conversation state with the expectation and returns mismatches
+func (r *run) check(expect *Expectation) []string {
+	var failures []string
+	userState := r.session.State()
+	if userState == nil {
+		userState = models.NewUserState("")
+	}
+
+	if expect.Question != "" && userState.CurrentQuestionID != expect.Question {
+		failures = append(failures, fmt.Sprintf("question: expected %q, got %q", expect.Question, userState.CurrentQuestionID))
+	}
+
+	if expect.Completed != nil && userState.IsCompleted() != *expect.Completed {
+		failures = append(failures, fmt.Sprintf("completed: expected %v, got %v", *expect.Completed, userState.IsCompleted()))
+	}
+
+	if expect.Expired != nil && userState.IsExpired() != *expect.Expired {
+		failures = append(failures, fmt.Sprintf("expired: expected %v, got %v", *expect.Expired, userState.IsExpired()))
+	}
+
+	if expect.Outcome != "" && userState.Outcome != expect.Outcome {
+		failures = append(failures, fmt.Sprintf("outcome: expected %q, got %q", expect.Outcome, userState.Outcome))
+	}
+
+	if expect.Score != nil && userState.Score() != *expect.Score {
+		failures = append(failures, fmt.Sprintf("score: expected %d, got %d", *expect.Score, userState.Score()))
+	}
+
+	if expect.Message != "" && !r.replied(expect.Message) {
+		failures = append(failures, fmt.Sprintf("message: no reply contains %q, got %q", expect.Message, r.replies()))
+	}
+
+	keys := make([]string, 0, len(expect.Answers))
+	for key := range expect.Answers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		expected := expect.Answers[key]
+		answer, exists := userState.GetAnswer(r.answerKey(key))
+		switch {
+		case !exists:
+			failures = append(failures, fmt.Sprintf("answer %q: expected %q, got none", key, expected))
+		case answer != expected:
+			failures = append(failures, fmt.Sprintf("answer %q: expected %q, got %q", key, expected, answer))
+		}
+	}
+
+	return failures
+}
+
+// answerKey converts question ID to the question text answers are stored under
+func (r *run) answerKey(key string) string {
+	if question, exists := r.questions[key]; exists {
+		return question.GetDisplayText()
+	}
+	return key
+}
+
+// replies returns texts of messages sent in reply to the latest action
+func (r *run) replies() []string {
+	var texts []string
+	for _, call := range r.recorder.Calls()[r.replyStart:] {
+		if call.Method == messenger.MethodSendMessage || call.Method == messenger.MethodEditMessageText {
+			texts = append(texts, call.Text)
+		}
+	}
+	return texts
+}
+
+// replied checks if a reply to the latest action contains text
+func (r *run) replied(text string) bool {
+	for _, reply := range r.replies() {
+		if strings.Contains(reply, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixed prepends label to each failure
+func prefixed(label string, failures []string) []string {
+	for i, failure := range failures {
+		failures[i] = label + ": " + failure
+	}
+	return failures
+}
@@ -0,0 +1,213 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testQuestions = `[
+	{"id": "start", "text": "Ready, {name}?", "options": [
+		{"text": "Option A", "next_id": "feedback"},
+		{"text": "Option B", "next_id": "end"}
+	]},
+	{"id": "feedback", "text": "Any feedback?", "input_type": "text", "reminder_after": "10m", "timeout": "1h", "options": [{"next_id": "end"}]},
+	{"id": "end", "text": "Thanks!"}
+]`
+
+// writeScenario writes questions and scenario files to a temp directory and returns scenario path
+func writeScenario(t *testing.T, scenario string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "questions.json"), []byte(testQuestions), 0o600); err != nil {
+		t.Fatalf("Failed to write questions: %v", err)
+	}
+
+	path := filepath.Join(dir, "flow.yaml")
+	if err := os.WriteFile(path, []byte(scenario), 0o600); err != nil {
+		t.Fatalf("Failed to write scenario: %v", err)
+	}
+	return path
+}
+
+func TestRunFilePasses(t *testing.T) {
+	path := writeScenario(t, `
+name: feedback flow
+questions: questions.json
+user_name: Ann
+steps:
+  - send: /start
+  - expect:
+      question: start
+      message: Ready, Ann?
+  - press: Option A
+  - send: hello
+expect:
+  question: end
+  completed: true
+  outcome: end
+  answers:
+    start: Option A
+    Any feedback?: hello
+`)
+
+	result := RunFile(path)
+
+	if !result.Passed() {
+		t.Errorf("Expected scenario to pass, got failures: %v", result.Failures)
+	}
+	if result.Name != "feedback flow" {
+		t.Errorf("Expected scenario name, got %q", result.Name)
+	}
+}
+
+func TestRunFileWait(t *testing.T) {
+	path := writeScenario(t, `
+questions: questions.json
+steps:
+  - send: /start
+  - press: Option A
+  - wait: 9m
+  - wait: 1m
+  - expect:
+      message: your survey is waiting
+      expired: false
+  - wait: 1h
+  - expect:
+      expired: true
+  - send: hello
+  - expect:
+      message: This survey has expired
+expect:
+  question: feedback
+  completed: false
+  expired: true
+`)
+
+	result := RunFile(path)
+
+	if !result.Passed() {
+		t.Errorf("Expected scenario to pass, got failures: %v", result.Failures)
+	}
+}
+
+func TestRunFileReportsMismatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario string
+		expected []string
+	}{
+		{
+			name: "wrong final state",
+			scenario: `
+questions: questions.json
+steps:
+  - send: /start
+  - press: Option B
+expect:
+  question: feedback
+  completed: false
+  score: 2
+  answers:
+    start: Option A
+    feedback: hello
+`,
+			expected: []string{
+				`final state: question: expected "feedback", got "end"`,
+				`final state: completed: expected false, got true`,
+				`final state: score: expected 2, got 0`,
+				`final state: answer "feedback": expected "hello", got none`,
+				`final state: answer "start": expected "Option A", got "Option B"`,
+			},
+		},
+		{
+			name: "unknown button",
+			scenario: `
+questions: questions.json
+steps:
+  - send: /start
+  - press: Option C
+  - send: never sent
+`,
+			expected: []string{`step 2 (press "Option C"): button "Option C" not found on the latest keyboard, available: "Option A", "Option B"`},
+		},
+		{
+			name: "missing message",
+			scenario: `
+questions: questions.json
+steps:
+  - send: /start
+  - expect:
+      message: Welcome
+`,
+			expected: []string{`step 2 (expect): message: no reply contains "Welcome", got ["Ready, Tester?"]`},
+		},
+		{
+			name: "not expired",
+			scenario: `
+questions: questions.json
+steps:
+  - send: /start
+  - press: Option A
+  - wait: 59m
+expect:
+  expired: true
+`,
+			expected: []string{`final state: expired: expected true, got false`},
+		},
+		{
+			name:     "unknown field",
+			scenario: "questions: questions.json\nstep: []\n",
+			expected: []string{"field step not found"},
+		},
+		{
+			name:     "ambiguous step",
+			scenario: "questions: questions.json\nsteps:\n  - send: /start\n    press: Option A\n",
+			expected: []string{"step 1 must have exactly one of send, press, wait or expect"},
+		},
+		{
+			name:     "missing questions file",
+			scenario: "questions: missing.json\n",
+			expected: []string{"failed to read questions file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := RunFile(writeScenario(t, tt.scenario))
+
+			if result.Passed() {
+				t.Fatal("Expected scenario to fail")
+			}
+			if len(result.Failures) != len(tt.expected) {
+				t.Fatalf("Expected %d failures, got %v", len(tt.expected), result.Failures)
+			}
+			for i, expected := range tt.expected {
+				if !strings.Contains(result.Failures[i], expected) {
+					t.Errorf("Expected failure %d to contain %q, got %q", i, expected, result.Failures[i])
+				}
+			}
+		})
+	}
+}
+
+func TestQuestionsPath(t *testing.T) {
+	tests := []struct {
+		scenarioPath string
+		questions    string
+		expected     string
+	}{
+		{"scenarios/flow.yaml", "../configs/questions.json", "configs/questions.json"},
+		{"flow.yaml", "questions.json", "questions.json"},
+		{"scenarios/flow.yaml", "/abs/questions.json", "/abs/questions.json"},
+	}
+
+	for _, tt := range tests {
+		s := &Scenario{Questions: tt.questions, path: tt.scenarioPath}
+		if got := s.QuestionsPath(); got != tt.expected {
+			t.Errorf("QuestionsPath() = %q, expected %q", got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// Manual is a scheduler whose clock only moves on Advance, for simulations and tests
+type Manual struct {
+	*Scheduler
+
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManual creates a manual scheduler with the clock set to start
+func NewManual(start time.Time) *Manual {
+	m := &Manual{now: start}
+	m.Scheduler = newScheduler(m.clock)
+	return m
+}
+
+// clock returns the manual time
+func (m *Manual) clock() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// Advance moves the clock forward, running jobs in due order with the clock set to each job's time,
+// and returns the number of jobs run
+func (m *Manual) Advance(d time.Duration) int {
+	target := m.clock().Add(d)
+	count := 0
+	for {
+		next, ok := m.next()
+		if !ok || next.After(target) {
+			break
+		}
+		if next.After(m.clock()) {
+			m.set(next)
+		}
+		count += m.RunDue(next)
+	}
+	m.set(target)
+	return count
+}
+
+// set moves the clock to the given time
+func (m *Manual) set(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
@@ -0,0 +1,207 @@
+// Package scheduler plans delayed jobs on a single timer instead of a sleeping goroutine per job.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Scheduler runs jobs at planned times. Jobs are named by key, so planning a job
+// replaces the pending job with the same key. Run starts due jobs in their own goroutines,
+// so a slow job does not hold up the others; RunDue runs them one at a time, in due order.
+type Scheduler struct {
+	mu    sync.Mutex
+	queue jobQueue
+	jobs  map[string]*job
+	seq   uint64
+	now   func() time.Time
+	// wake interrupts Run waiting for a job when an earlier one is planned
+	wake chan struct{}
+}
+
+// job is a planned function call
+type job struct {
+	key   string
+	at    time.Time
+	seq   uint64 // Keeps planning order of jobs due at the same time
+	fn    func()
+	index int
+}
+
+// New creates a scheduler driven by wall time
+func New() *Scheduler {
+	return newScheduler(time.Now)
+}
+
+// newScheduler creates a scheduler reading time from the clock
+func newScheduler(now func() time.Time) *Scheduler {
+	return &Scheduler{
+		jobs: make(map[string]*job),
+		now:  now,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Now returns current time of the scheduler clock
+func (s *Scheduler) Now() time.Time {
+	return s.now()
+}
+
+// After plans fn to run after the delay, replacing the pending job with the same key
+func (s *Scheduler) After(key string, delay time.Duration, fn func()) {
+	s.At(key, s.now().Add(delay), fn)
+}
+
+// At plans fn to run at the given time, replacing the pending job with the same key
+func (s *Scheduler) At(key string, at time.Time, fn func()) {
+	s.mu.Lock()
+	if existing, exists := s.jobs[key]; exists {
+		heap.Remove(&s.queue, existing.index)
+	}
+	s.seq++
+	j := &job{key: key, at: at, seq: s.seq, fn: fn}
+	s.jobs[key] = j
+	heap.Push(&s.queue, j)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel removes the pending job with the key and reports whether there was one
+func (s *Scheduler) Cancel(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, exists := s.jobs[key]
+	if !exists {
+		return false
+	}
+	heap.Remove(&s.queue, existing.index)
+	delete(s.jobs, key)
+	return true
+}
+
+// Pending returns the number of planned jobs
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// Run starts jobs as they become due until the context is cancelled,
+// then waits for the started jobs to finish
+func (s *Scheduler) Run(ctx context.Context) {
+	var running sync.WaitGroup
+	defer running.Wait()
+	start := func(fn func()) {
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			fn()
+		}()
+	}
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.runDue(s.now(), start)
+
+		wait := time.Hour
+		if next, ok := s.next(); ok {
+			wait = next.Sub(s.now())
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// RunDue runs jobs due at the given time, including jobs they plan that are already due,
+// and returns the number of jobs run
+func (s *Scheduler) RunDue(now time.Time) int {
+	return s.runDue(now, func(fn func()) { fn() })
+}
+
+// runDue passes jobs due at the given time to run and returns their number
+func (s *Scheduler) runDue(now time.Time, run func(fn func())) int {
+	count := 0
+	for {
+		j := s.popDue(now)
+		if j == nil {
+			return count
+		}
+		run(j.fn)
+		count++
+	}
+}
+
+// next returns due time of the earliest job
+func (s *Scheduler) next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return time.Time{}, false
+	}
+	return s.queue[0].at, true
+}
+
+// popDue removes and returns the earliest job due at the given time, or nil
+func (s *Scheduler) popDue(now time.Time) *job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 || s.queue[0].at.After(now) {
+		return nil
+	}
+	j := heap.Pop(&s.queue).(*job)
+	delete(s.jobs, j.key)
+	return j
+}
+
+// jobQueue is a min-heap of jobs ordered by due time
+type jobQueue []*job
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *jobQueue) Push(x interface{}) {
+	j := x.(*job)
+	j.index = len(*q)
+	*q = append(*q, j)
+}
+
+func (q *jobQueue) Pop() interface{} {
+	old := *q
+	j := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return j
+}
@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestManualAdvance(t *testing.T) {
+	s := NewManual(start)
+	var ran []string
+	record := func(name string) func() {
+		return func() { ran = append(ran, name+"@"+s.Now().Sub(start).String()) }
+	}
+
+	s.After("late", 2*time.Hour, record("late"))
+	s.After("early", 30*time.Minute, record("early"))
+	s.After("same", 30*time.Minute, record("same"))
+
+	if got := s.Advance(29 * time.Minute); got != 0 {
+		t.Errorf("Expected no jobs before they are due, ran %d", got)
+	}
+	if got := s.Advance(time.Hour); got != 2 {
+		t.Errorf("Expected 2 jobs run, got %d", got)
+	}
+	if got := s.Now(); !got.Equal(start.Add(89 * time.Minute)) {
+		t.Errorf("Expected clock at 1h29m, got %v", got.Sub(start))
+	}
+	if got := s.Advance(time.Hour); got != 1 {
+		t.Errorf("Expected 1 job run, got %d", got)
+	}
+
+	want := []string{"early@30m0s", "same@30m0s", "late@2h0m0s"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("Expected jobs %v, got %v", want, ran)
+	}
+}
+
+func TestReplaceAndCancel(t *testing.T) {
+	s := NewManual(start)
+	var ran []string
+
+	s.After("reminder", time.Minute, func() { ran = append(ran, "first") })
+	s.After("reminder", 2*time.Minute, func() { ran = append(ran, "second") })
+	s.After("expiry", time.Minute, func() { ran = append(ran, "expiry") })
+
+	if got := s.Pending(); got != 2 {
+		t.Errorf("Expected 2 pending jobs, got %d", got)
+	}
+	if !s.Cancel("expiry") {
+		t.Error("Expected pending job to be cancelled")
+	}
+	if s.Cancel("expiry") {
+		t.Error("Expected cancelling a missing job to report false")
+	}
+
+	s.Advance(time.Hour)
+	if want := []string{"second"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Expected jobs %v, got %v", want, ran)
+	}
+	if got := s.Pending(); got != 0 {
+		t.Errorf("Expected no pending jobs, got %d", got)
+	}
+}
+
+func TestRepeatingJob(t *testing.T) {
+	s := NewManual(start)
+	count := 0
+	var repeat func()
+	repeat = func() {
+		count++
+		if count < 3 {
+			s.After("repeat", 10*time.Minute, repeat)
+		}
+	}
+	s.After("repeat", 10*time.Minute, repeat)
+
+	if got := s.Advance(time.Hour); got != 3 {
+		t.Errorf("Expected 3 runs, got %d", got)
+	}
+	if count != 3 {
+		t.Errorf("Expected job to repeat 3 times, got %d", count)
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Run(ctx)
+	}()
+
+	done := make(chan string, 2)
+	s.After("later", time.Hour, func() { done <- "later" })
+	s.After("soon", 10*time.Millisecond, func() { done <- "soon" })
+
+	select {
+	case got := <-done:
+		if got != "soon" {
+			t.Errorf("Expected soon job first, got %s", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Job was not run")
+	}
+
+	cancel()
+	wg.Wait()
+	if got := s.Pending(); got != 1 {
+		t.Errorf("Expected later job still pending, got %d", got)
+	}
+}
+
+func TestRunDoesNotWaitForSlowJobs(t *testing.T) {
+	s := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Run(ctx)
+	}()
+
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	s.After("slow", 0, func() {
+		<-release
+		close(finished)
+	})
+	done := make(chan struct{})
+	s.After("fast", 10*time.Millisecond, func() { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Job was held up by a slow job")
+	}
+
+	cancel()
+	close(release)
+	wg.Wait()
+	select {
+	case <-finished:
+	default:
+		t.Error("Expected Run to wait for started jobs before returning")
+	}
+}
@@ -0,0 +1,97 @@
+package secrets
+
+import (
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces secrets in output
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the shortest value redacted; shorter values would mangle unrelated output
+const minSecretLength = 6
+
+// Redactor replaces secrets in strings, errors and written output
+type Redactor struct {
+	replacer *strings.Replacer
+}
+
+// NewRedactor creates redactor of the secrets, including their URL-escaped forms.
+// Empty and very short values are ignored.
+func NewRedactor(secrets ...string) *Redactor {
+	var pairs []string
+	seen := make(map[string]bool)
+	for _, secret := range secrets {
+		if len(secret) < minSecretLength {
+			continue
+		}
+		for _, form := range []string{secret, url.PathEscape(secret), url.QueryEscape(secret)} {
+			if !seen[form] {
+				seen[form] = true
+				pairs = append(pairs, form, Redacted)
+			}
+		}
+	}
+
+	if len(pairs) == 0 {
+		return &Redactor{}
+	}
+	return &Redactor{replacer: strings.NewReplacer(pairs...)}
+}
+
+// Redact returns s with secrets replaced
+func (r *Redactor) Redact(s string) string {
+	if r == nil || r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// Error returns err with secrets removed from its message, keeping the error chain for errors.Is and errors.As
+func (r *Redactor) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := r.Redact(err.Error())
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{message: message, err: err}
+}
+
+// Writer returns writer redacting secrets in every write.
+// Each write must hold complete secrets, as log handlers do by writing whole records.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	return redactingWriter{w: w, redactor: r}
+}
+
+// redactedError is an error with secrets removed from its message
+type redactedError struct {
+	message string
+	err     error
+}
+
+// Error returns the redacted message
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the original error
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactingWriter redacts secrets before writing to the underlying writer
+type redactingWriter struct {
+	w        io.Writer
+	redactor *Redactor
+}
+
+// Write writes p with secrets replaced, reporting the whole of p as written
+func (w redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.redactor.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
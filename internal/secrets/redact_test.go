@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testToken = "123456:ABC-def_ghi"
+
+func TestRedact(t *testing.T) {
+	redactor := NewRedactor(testToken, "", "short")
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain", input: "token " + testToken, expected: "token " + Redacted},
+		{name: "API URL", input: "https://api.telegram.org/bot" + testToken + "/getMe", expected: "https://api.telegram.org/bot" + Redacted + "/getMe"},
+		{name: "query escaped", input: "token=" + url.QueryEscape(testToken), expected: "token=" + Redacted},
+		{name: "short values kept", input: "short", expected: "short"},
+		{name: "no secrets", input: "hello", expected: "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := redactor.Redact(tt.input); result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	var nilRedactor *Redactor
+	if result := nilRedactor.Redact(testToken); result != testToken {
+		t.Errorf("Expected nil redactor to keep input, got %q", result)
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	redactor := NewRedactor(testToken)
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("Post \"https://api.telegram.org/bot%s/getMe\": %w", testToken, cause)
+
+	redacted := redactor.Error(err)
+	if strings.Contains(redacted.Error(), testToken) {
+		t.Errorf("Expected token removed, got %q", redacted)
+	}
+	if !errors.Is(redacted, cause) {
+		t.Error("Expected error chain to be kept")
+	}
+
+	plain := errors.New("no secret")
+	if redactor.Error(plain) != plain {
+		t.Error("Expected error without secrets returned unchanged")
+	}
+	if redactor.Error(nil) != nil {
+		t.Error("Expected nil for nil error")
+	}
+}
+
+func TestRedactingWriter(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewRedactor(testToken).Writer(&buf)
+
+			var handler slog.Handler = slog.NewTextHandler(w, nil)
+			if format == "json" {
+				handler = slog.NewJSONHandler(w, nil)
+			}
+
+			// Telegram API client logs through the standard logger
+			slog.NewLogLogger(handler, slog.LevelWarn).Printf("Endpoint: https://api.telegram.org/bot%s/getUpdates", testToken)
+			slog.New(handler).Error("Failed to get updates", "error", errors.New("bot"+testToken), "token", testToken)
+
+			if strings.Contains(buf.String(), testToken) {
+				t.Errorf("Expected token removed from logs, got:\n%s", buf.String())
+			}
+			if strings.Count(buf.String(), Redacted) != 3 {
+				t.Errorf("Expected 3 redactions, got:\n%s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Package secrets resolves secret references in configuration values and redacts secrets from output.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Built-in reference schemes
+const (
+	SchemeFile = "file"
+	SchemeEnv  = "env"
+)
+
+// Resolver returns the secret a reference points to. The reference is the value without the scheme prefix.
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to Resolver
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve calls the function
+func (f ResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	mu        sync.RWMutex
+	resolvers = map[string]Resolver{
+		SchemeFile: ResolverFunc(ReadFile),
+		SchemeEnv:  ResolverFunc(lookupEnv),
+	}
+)
+
+// Register adds resolver of references with the scheme, e.g. "vault" for vault://path, replacing existing one
+func Register(scheme string, resolver Resolver) {
+	mu.Lock()
+	defer mu.Unlock()
+	resolvers[scheme] = resolver
+}
+
+// IsReference checks if value is a reference with a registered scheme
+func IsReference(value string) bool {
+	_, _, ok := split(value)
+	return ok
+}
+
+// Resolve returns the secret for a reference such as file:///run/secrets/token or env://TOKEN.
+// Values without a registered scheme are returned unchanged.
+func Resolve(value string) (string, error) {
+	resolver, ref, ok := split(value)
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := resolver.Resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// split returns resolver and reference of a value with a registered scheme
+func split(value string) (Resolver, string, bool) {
+	scheme, ref, found := strings.Cut(value, "://")
+	if !found {
+		return nil, "", false
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	resolver, exists := resolvers[scheme]
+	return resolver, ref, exists
+}
+
+// ReadFile reads a secret file, dropping the trailing newline that editors and echo add
+func ReadFile(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("secret file path cannot be empty")
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // G304: Secret file path is given by the operator
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// lookupEnv returns value of a set environment variable
+func lookupEnv(name string) (string, error) {
+	value, exists := os.LookupEnv(name)
+	if !exists {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	t.Setenv("SECRETS_TEST_TOKEN", "env-secret")
+
+	tests := []struct {
+		name        string
+		value       string
+		expected    string
+		expectError bool
+	}{
+		{name: "plain value", value: "123:abc", expected: "123:abc"},
+		{name: "unregistered scheme", value: "https://example.com", expected: "https://example.com"},
+		{name: "file", value: "file://" + path, expected: "file-secret"},
+		{name: "env", value: "env://SECRETS_TEST_TOKEN", expected: "env-secret"},
+		{name: "missing file", value: "file://" + filepath.Join(t.TempDir(), "missing"), expectError: true},
+		{name: "empty file path", value: "file://", expectError: true},
+		{name: "unset variable", value: "env://SECRETS_TEST_UNSET", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Resolve(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("test", ResolverFunc(func(ref string) (string, error) {
+		return strings.ToUpper(ref), nil
+	}))
+
+	if !IsReference("test://name") {
+		t.Error("Expected registered scheme to be a reference")
+	}
+	result, err := Resolve("test://name")
+	if err != nil || result != "NAME" {
+		t.Errorf("Expected NAME, got %q, %v", result, err)
+	}
+}
@@ -0,0 +1,249 @@
+// Package services provides business logic services for survey funnel analytics.
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+// Funnel holds drop-off analytics of a survey
+type Funnel struct {
+	SurveyID   string       `json:"survey_id"`
+	Started    int          `json:"started"`
+	Completed  int          `json:"completed"`
+	InProgress int          `json:"in_progress"`        // Unfinished attempts the user may still go on with
+	Abandoned  int          `json:"abandoned"`          // Attempts that expired, were cancelled or replaced by another one
+	TopExit    string       `json:"top_exit,omitempty"` // Most common last question of abandoned attempts
+	Steps      []FunnelStep `json:"steps"`
+}
+
+// FunnelStep holds analytics of a single question of the funnel
+type FunnelStep struct {
+	QuestionID string          `json:"question_id"`
+	Reached    int             `json:"reached"`     // Attempts that reached the question
+	InProgress int             `json:"in_progress"` // Attempts in progress waiting at the question
+	Exits      int             `json:"exits"`       // Abandoned attempts that stopped at the question
+	Conversion float64         `json:"conversion"`  // Share of attempts that went on to the next question or finished
+	MedianTime models.Duration `json:"median_time"` // Median time spent before the next question, in whole seconds
+}
+
+// funnelColumns are CSV columns of the funnel report
+var funnelColumns = []string{"survey_id", "question_id", "reached", "in_progress", "exits", "conversion", "median_seconds"}
+
+// funnelStepData collects raw data of a funnel step
+type funnelStepData struct {
+	reached    int
+	inProgress int
+	exits      int
+	spent      []time.Duration
+}
+
+// attemptStatus is the outcome of a survey attempt in the funnel
+type attemptStatus int
+
+const (
+	attemptCompleted attemptStatus = iota
+	attemptInProgress
+	attemptAbandoned
+)
+
+// funnelAttempt is a single survey attempt counted in the funnel
+type funnelAttempt struct {
+	surveyID string
+	visits   []models.Visit
+	status   attemptStatus
+}
+
+// collectAttempts returns attempts of all users, optionally of a single survey: completed ones from
+// submissions, abandoned ones from drop-offs and the current unfinished attempt
+func collectAttempts(states map[int64]*models.UserState, surveyID string) []funnelAttempt {
+	var attempts []funnelAttempt
+	add := func(attemptSurveyID string, visits []models.Visit, status attemptStatus) {
+		if surveyID != "" && attemptSurveyID != surveyID {
+			return
+		}
+		attempts = append(attempts, funnelAttempt{surveyID: attemptSurveyID, visits: visits, status: status})
+	}
+
+	for _, state := range states {
+		for _, submission := range state.Submissions {
+			visits := submission.Visits
+			if len(visits) == 0 {
+				// Submissions saved before visits were recorded only know the terminal question
+				visits = []models.Visit{{QuestionID: submission.TerminalQuestionID, At: submission.CompletedAt}}
+			}
+			add(submission.SurveyID, visits, attemptCompleted)
+		}
+		for _, dropOff := range state.DropOffs {
+			add(dropOff.SurveyID, dropOff.Visits, attemptAbandoned)
+		}
+
+		// Completed current attempts are already counted by their submissions
+		if state.CurrentQuestionID == "" || state.IsCompleted() {
+			continue
+		}
+		visits := state.Visits
+		if len(visits) == 0 {
+			// States saved before visits were recorded only know the current question
+			visits = []models.Visit{{QuestionID: state.CurrentQuestionID, At: state.StartedAt}}
+		}
+		status := attemptInProgress
+		if state.IsExpired() {
+			status = attemptAbandoned
+		}
+		add(state.SurveyID, visits, status)
+	}
+	return attempts
+}
+
+// BuildFunnels computes funnels of every survey, optionally of a single one, sorted by survey ID.
+// Attempts are counted like in CollectStats, so users who started over or switched surveys are included.
+// Steps are sorted by number of attempts that reached them, so they follow the survey from start to finish.
+func BuildFunnels(states map[int64]*models.UserState, surveyID string) []Funnel {
+	funnels := make(map[string]*Funnel)
+	steps := make(map[string]map[string]*funnelStepData)
+
+	for _, attempt := range collectAttempts(states, surveyID) {
+		funnel := funnels[attempt.surveyID]
+		if funnel == nil {
+			funnel = &Funnel{SurveyID: attempt.surveyID}
+			funnels[attempt.surveyID] = funnel
+			steps[attempt.surveyID] = make(map[string]*funnelStepData)
+		}
+		surveySteps := steps[attempt.surveyID]
+		step := func(questionID string) *funnelStepData {
+			data := surveySteps[questionID]
+			if data == nil {
+				data = &funnelStepData{}
+				surveySteps[questionID] = data
+			}
+			return data
+		}
+
+		visits := attempt.visits
+		reached := make(map[string]bool, len(visits))
+		for i, visit := range visits {
+			if !reached[visit.QuestionID] {
+				reached[visit.QuestionID] = true
+				step(visit.QuestionID).reached++
+			}
+			if i+1 < len(visits) {
+				step(visit.QuestionID).spent = append(step(visit.QuestionID).spent, visits[i+1].At.Sub(visit.At))
+			}
+		}
+
+		funnel.Started++
+		last := step(visits[len(visits)-1].QuestionID)
+		switch attempt.status {
+		case attemptCompleted:
+			funnel.Completed++
+		case attemptAbandoned:
+			funnel.Abandoned++
+			last.exits++
+		default:
+			funnel.InProgress++
+			last.inProgress++
+		}
+	}
+
+	result := make([]Funnel, 0, len(funnels))
+	for surveyID, funnel := range funnels {
+		topExits := 0
+		for questionID, data := range steps[surveyID] {
+			conversion := 0.0
+			if data.reached > 0 {
+				conversion = float64(data.reached-data.exits-data.inProgress) / float64(data.reached)
+			}
+			funnel.Steps = append(funnel.Steps, FunnelStep{
+				QuestionID: questionID,
+				Reached:    data.reached,
+				InProgress: data.inProgress,
+				Exits:      data.exits,
+				Conversion: conversion,
+				MedianTime: models.Duration(median(data.spent).Round(time.Second)),
+			})
+
+			if data.exits > topExits || (data.exits == topExits && data.exits > 0 && questionID < funnel.TopExit) {
+				topExits = data.exits
+				funnel.TopExit = questionID
+			}
+		}
+
+		sort.Slice(funnel.Steps, func(i, j int) bool {
+			if funnel.Steps[i].Reached != funnel.Steps[j].Reached {
+				return funnel.Steps[i].Reached > funnel.Steps[j].Reached
+			}
+			return funnel.Steps[i].QuestionID < funnel.Steps[j].QuestionID
+		})
+		result = append(result, *funnel)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].SurveyID < result[j].SurveyID })
+	return result
+}
+
+// ExportFunnels encodes funnels in the given format; CSV has one row per step
+func ExportFunnels(funnels []Funnel, format string) ([]byte, error) {
+	switch format {
+	case ExportFormatCSV:
+		return exportFunnelsCSV(funnels)
+	case ExportFormatJSON:
+		return json.MarshalIndent(funnels, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// exportFunnelsCSV writes funnel steps as CSV rows
+func exportFunnelsCSV(funnels []Funnel) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(funnelColumns); err != nil {
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, funnel := range funnels {
+		for _, step := range funnel.Steps {
+			row := []string{
+				funnel.SurveyID,
+				step.QuestionID,
+				strconv.Itoa(step.Reached),
+				strconv.Itoa(step.InProgress),
+				strconv.Itoa(step.Exits),
+				strconv.FormatFloat(step.Conversion, 'f', 3, 64),
+				strconv.FormatFloat(time.Duration(step.MedianTime).Seconds(), 'f', -1, 64),
+			}
+			if err := writer.Write(row); err != nil {
+				return nil, fmt.Errorf("failed to write CSV row: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to flush CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// median returns the median duration, zero for no durations
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return (sorted[middle-1] + sorted[middle]) / 2
+}
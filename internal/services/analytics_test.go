@@ -0,0 +1,170 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+// newFunnelState creates an attempt reaching the questions at the given minutes after start
+func newFunnelState(surveyID string, questionIDs []string, minutes []int) *models.UserState {
+	startedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	state := models.NewUserState("User")
+	state.SurveyID = surveyID
+	state.StartedAt = startedAt
+	for i, questionID := range questionIDs {
+		state.Reach(questionID, startedAt.Add(time.Duration(minutes[i])*time.Minute))
+	}
+	return state
+}
+
+func createFunnelStates() map[int64]*models.UserState {
+	completed := newFunnelState("quiz", []string{"start", "q1", "q2", "end"}, []int{0, 1, 3, 4})
+	completed.Complete(0, "end", completed.StartedAt.Add(4*time.Minute))
+
+	expired := newFunnelState("quiz", []string{"start", "q1", "q2"}, []int{0, 2, 5})
+	expired.Expire(expired.StartedAt.Add(time.Hour))
+
+	// State saved before visits were recorded
+	legacy := models.NewUserState("Legacy")
+	legacy.SurveyID = "feedback"
+	legacy.CurrentQuestionID = "q1"
+
+	// Abandoned the quiz at q2 by switching to the feedback survey
+	switched := newFunnelState("quiz", []string{"start", "q1", "q2"}, []int{0, 2, 3})
+	switched.Abandon(switched.StartedAt.Add(10 * time.Minute))
+	switched.Reset()
+	switched.SurveyID = "feedback"
+	switched.StartedAt = time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	switched.Reach("q1", switched.StartedAt)
+
+	// Completed the quiz and started it over
+	restarted := newFunnelState("quiz", []string{"start", "q1", "q2", "end"}, []int{0, 1, 3, 4})
+	restarted.Complete(8, "end", restarted.StartedAt.Add(4*time.Minute))
+	restarted.Reset()
+	restarted.SurveyID = "quiz"
+	restarted.StartedAt = time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	restarted.Reach("start", restarted.StartedAt)
+
+	return map[int64]*models.UserState{
+		1: completed,
+		2: expired,
+		3: newFunnelState("quiz", []string{"start", "q1", "start", "q1"}, []int{0, 3, 4, 5}), // Went back, still in progress
+		4: newFunnelState("quiz", []string{"start"}, []int{0}),
+		5: legacy,
+		6: models.NewUserState("Never started"),
+		7: switched,
+		8: restarted,
+	}
+}
+
+func TestBuildFunnels(t *testing.T) {
+	expected := []Funnel{
+		{
+			SurveyID:   "feedback",
+			Started:    2,
+			InProgress: 2,
+			Steps:      []FunnelStep{{QuestionID: "q1", Reached: 2, InProgress: 2}},
+		},
+		{
+			SurveyID:   "quiz",
+			Started:    7,
+			Completed:  2,
+			InProgress: 3,
+			Abandoned:  2,
+			TopExit:    "q2",
+			Steps: []FunnelStep{
+				{QuestionID: "start", Reached: 7, InProgress: 2, Conversion: 5.0 / 7, MedianTime: models.Duration(90 * time.Second)},
+				{QuestionID: "q1", Reached: 5, InProgress: 1, Conversion: 0.8, MedianTime: models.Duration(2 * time.Minute)},
+				{QuestionID: "q2", Reached: 4, Exits: 2, Conversion: 0.5, MedianTime: models.Duration(time.Minute)},
+				{QuestionID: "end", Reached: 2, Conversion: 1},
+			},
+		},
+	}
+
+	got := BuildFunnels(createFunnelStates(), "")
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected funnels\n%+v\ngot\n%+v", expected, got)
+	}
+
+	got = BuildFunnels(createFunnelStates(), "quiz")
+	if !reflect.DeepEqual(got, expected[1:]) {
+		t.Errorf("Expected quiz funnel\n%+v\ngot\n%+v", expected[1:], got)
+	}
+}
+
+func TestBuildFunnelsMatchesStats(t *testing.T) {
+	states := createFunnelStates()
+	for _, surveyID := range []string{"feedback", "quiz"} {
+		funnels := BuildFunnels(states, surveyID)
+		stats := CollectStats(states, surveyID)
+		if len(funnels) != 1 {
+			t.Fatalf("Expected one funnel of %s, got %+v", surveyID, funnels)
+		}
+		if funnels[0].Started != stats.Started || funnels[0].Completed != stats.Completed {
+			t.Errorf("Expected %s funnel to count %d started and %d completed like stats, got %d and %d",
+				surveyID, stats.Started, stats.Completed, funnels[0].Started, funnels[0].Completed)
+		}
+		if funnels[0].Abandoned != stats.Abandoned+stats.Expired {
+			t.Errorf("Expected %s funnel to count %d abandoned, got %d", surveyID, stats.Abandoned+stats.Expired, funnels[0].Abandoned)
+		}
+	}
+}
+
+func TestBuildFunnelsEmpty(t *testing.T) {
+	if got := BuildFunnels(map[int64]*models.UserState{1: models.NewUserState("User")}, ""); len(got) != 0 {
+		t.Errorf("Expected no funnels without attempts, got %+v", got)
+	}
+}
+
+func TestExportFunnels(t *testing.T) {
+	funnels := BuildFunnels(createFunnelStates(), "")
+
+	t.Run("csv", func(t *testing.T) {
+		data, err := ExportFunnels(funnels, ExportFormatCSV)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 6 {
+			t.Fatalf("Expected header and 5 rows, got %d lines:\n%s", len(lines), data)
+		}
+		for i, want := range map[int]string{
+			0: "survey_id,question_id,reached,in_progress,exits,conversion,median_seconds",
+			1: "feedback,q1,2,2,0,0.000,0",
+			3: "quiz,q1,5,1,0,0.800,120",
+			4: "quiz,q2,4,0,2,0.500,60",
+		} {
+			if lines[i] != want {
+				t.Errorf("Expected line %d %q, got %q", i, want, lines[i])
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		data, err := ExportFunnels(funnels, ExportFormatJSON)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var decoded []Funnel
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if !reflect.DeepEqual(decoded, funnels) {
+			t.Errorf("Expected JSON to round-trip, got %+v", decoded)
+		}
+		if !strings.Contains(string(data), `"median_time": "1m30s"`) {
+			t.Errorf("Expected median time as duration text, got %s", data)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		if _, err := ExportFunnels(funnels, "xml"); err == nil {
+			t.Error("Expected error for unsupported format")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+// Package services provides business logic services for deep link payload parsing.
+package services
+
+import (
+	"strings"
+)
+
+// Deep link payload format: parts separated by "__", each part is "<key>-<value>",
+// e.g. "s-spring__c-instagram__src-story__q-intro".
+// Telegram allows only A-Z, a-z, 0-9, "_" and "-" in payloads, up to 64 characters.
+const (
+	payloadPartSeparator = "__"
+	payloadKeySeparator  = "-"
+
+	payloadKeySurvey   = "s"
+	payloadKeyQuestion = "q"
+	payloadKeyCampaign = "c"
+	payloadKeySource   = "src"
+)
+
+// StartPayload holds data decoded from /start deep link payload
+type StartPayload struct {
+	SurveyID   string
+	QuestionID string
+	Campaign   string
+	Source     string
+	Tag        string // Payload without recognized keys, e.g. "/start spring"
+}
+
+// ParseStartPayload decodes /start command payload
+func ParseStartPayload(payload string) StartPayload {
+	var result StartPayload
+
+	payload = strings.TrimSpace(payload)
+	if payload == "" {
+		return result
+	}
+
+	for _, part := range strings.Split(payload, payloadPartSeparator) {
+		key, value, found := strings.Cut(part, payloadKeySeparator)
+		if !found || value == "" {
+			continue
+		}
+
+		switch key {
+		case payloadKeySurvey:
+			result.SurveyID = value
+		case payloadKeyQuestion:
+			result.QuestionID = value
+		case payloadKeyCampaign:
+			result.Campaign = value
+		case payloadKeySource:
+			result.Source = value
+		}
+	}
+
+	if result == (StartPayload{}) {
+		result.Tag = payload
+	}
+
+	return result
+}
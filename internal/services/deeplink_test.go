@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestParseStartPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected StartPayload
+	}{
+		{"empty payload", "", StartPayload{}},
+		{"bare tag", "spring", StartPayload{Tag: "spring"}},
+		{"bare tag with dashes", "spring-2024", StartPayload{Tag: "spring-2024"}},
+		{
+			"all keys",
+			"s-feedback__q-intro__c-spring_sale__src-insta-story",
+			StartPayload{SurveyID: "feedback", QuestionID: "intro", Campaign: "spring_sale", Source: "insta-story"},
+		},
+		{"campaign only", "c-newsletter", StartPayload{Campaign: "newsletter"}},
+		{"unknown keys are ignored", "c-ads__x-1", StartPayload{Campaign: "ads"}},
+		{"empty value", "c-", StartPayload{Tag: "c-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseStartPayload(tt.payload)
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
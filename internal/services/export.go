@@ -0,0 +1,20 @@
+// Package services provides business logic services for exporting survey responses.
+package services
+
+import (
+	"time"
+)
+
+// Supported export formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+)
+
+// formatExportTime formats timestamp for export, leaving zero time empty
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -3,43 +3,127 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 
 	"tlgbot/internal/models"
 )
 
-// QuestionManager manages questions
+// QuestionManager manages questions of one or more surveys
 type QuestionManager struct {
+	surveys         map[string]*surveyQuestions
+	defaultSurveyID string
+}
+
+// surveyQuestions holds survey metadata with its questions indexed by ID
+type surveyQuestions struct {
+	survey    models.Survey
 	questions map[string]models.Question
 }
 
-// NewQuestionManager creates a new question manager
+// NewQuestionManager creates a new question manager for a single survey
 func NewQuestionManager(questions map[string]models.Question) *QuestionManager {
 	return &QuestionManager{
-		questions: questions,
+		surveys: map[string]*surveyQuestions{
+			models.DefaultSurveyID: {
+				survey:    models.Survey{ID: models.DefaultSurveyID},
+				questions: questions,
+			},
+		},
+		defaultSurveyID: models.DefaultSurveyID,
 	}
 }
 
-// GetQuestion returns a question by ID
+// NewSurveyQuestionManager creates a question manager hosting several surveys.
+// The first survey in ID order serves as the default one.
+func NewSurveyQuestionManager(surveys []models.Survey) (*QuestionManager, error) {
+	if len(surveys) == 0 {
+		return nil, errors.New("no surveys provided")
+	}
+
+	m := &QuestionManager{
+		surveys: make(map[string]*surveyQuestions, len(surveys)),
+	}
+
+	for _, survey := range surveys {
+		if survey.ID == "" {
+			return nil, errors.New("survey ID cannot be empty")
+		}
+		if _, exists := m.surveys[survey.ID]; exists {
+			return nil, fmt.Errorf("duplicate survey ID: %s", survey.ID)
+		}
+
+		questions := make(map[string]models.Question, len(survey.Questions))
+		for _, q := range survey.Questions {
+			questions[q.ID] = q
+		}
+		m.surveys[survey.ID] = &surveyQuestions{survey: survey, questions: questions}
+
+		if m.defaultSurveyID == "" || survey.ID < m.defaultSurveyID {
+			m.defaultSurveyID = survey.ID
+		}
+	}
+
+	return m, nil
+}
+
+// GetQuestion returns a question of the default survey by ID
 func (m *QuestionManager) GetQuestion(id string) (*models.Question, error) {
-	question, exists := m.questions[id]
+	return m.GetSurveyQuestion(m.defaultSurveyID, id)
+}
+
+// GetSurveyQuestion returns a question of the given survey by ID.
+// Empty survey ID refers to the default survey.
+func (m *QuestionManager) GetSurveyQuestion(surveyID, id string) (*models.Question, error) {
+	sq, err := m.getSurvey(surveyID)
+	if err != nil {
+		return nil, err
+	}
+
+	question, exists := sq.questions[id]
 	if !exists {
 		return nil, errors.New("question not found")
 	}
 	return &question, nil
 }
 
-// GetAllQuestions returns all questions
+// GetAllQuestions returns all questions of the default survey
 func (m *QuestionManager) GetAllQuestions() map[string]models.Question {
-	return m.questions
+	return m.surveys[m.defaultSurveyID].questions
+}
+
+// GetSurvey returns survey metadata by ID.
+// Empty survey ID refers to the default survey.
+func (m *QuestionManager) GetSurvey(surveyID string) (*models.Survey, error) {
+	sq, err := m.getSurvey(surveyID)
+	if err != nil {
+		return nil, err
+	}
+
+	survey := sq.survey
+	return &survey, nil
 }
 
-// QuestionExists checks if a question exists
+// ListSurveys returns all surveys sorted by ID
+func (m *QuestionManager) ListSurveys() []models.Survey {
+	surveys := make([]models.Survey, 0, len(m.surveys))
+	for _, sq := range m.surveys {
+		surveys = append(surveys, sq.survey)
+	}
+
+	sort.Slice(surveys, func(i, j int) bool {
+		return surveys[i].ID < surveys[j].ID
+	})
+	return surveys
+}
+
+// QuestionExists checks if a question exists in the default survey
 func (m *QuestionManager) QuestionExists(id string) bool {
-	_, exists := m.questions[id]
+	_, exists := m.GetAllQuestions()[id]
 	return exists
 }
 
-// GetNextQuestion returns the next question based on option
+// GetNextQuestion returns the next question of the default survey based on option
 func (m *QuestionManager) GetNextQuestion(currentQuestionID, optionText string) (*models.Question, error) {
 	currentQ, err := m.GetQuestion(currentQuestionID)
 	if err != nil {
@@ -54,3 +138,16 @@ func (m *QuestionManager) GetNextQuestion(currentQuestionID, optionText string)
 
 	return nil, errors.New("option not found")
 }
+
+// getSurvey resolves survey by ID, falling back to the default survey for empty ID
+func (m *QuestionManager) getSurvey(surveyID string) (*surveyQuestions, error) {
+	if surveyID == "" {
+		surveyID = m.defaultSurveyID
+	}
+
+	sq, exists := m.surveys[surveyID]
+	if !exists {
+		return nil, fmt.Errorf("survey not found: %s", surveyID)
+	}
+	return sq, nil
+}
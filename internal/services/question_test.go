@@ -151,3 +151,77 @@ func TestQuestionManagerEmptyQuestions(t *testing.T) {
 		t.Errorf("Expected 0 questions, got %d", len(allQuestions))
 	}
 }
+
+func createTestSurveys() []models.Survey {
+	return []models.Survey{
+		{
+			ID:              "survey_b",
+			StartQuestionID: "intro",
+			Questions:       []models.Question{{ID: "intro", Text: "Survey B intro"}},
+		},
+		{
+			ID:              "survey_a",
+			Title:           "Survey A",
+			StartQuestionID: "start",
+			Questions:       []models.Question{{ID: "start", Text: "Survey A start"}},
+		},
+	}
+}
+
+func TestSurveyQuestionManager(t *testing.T) {
+	manager, err := NewSurveyQuestionManager(createTestSurveys())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	question, err := manager.GetSurveyQuestion("survey_b", "intro")
+	if err != nil || question.Text != "Survey B intro" {
+		t.Errorf("Expected survey B question, got %+v (err: %v)", question, err)
+	}
+
+	// Questions are namespaced per survey
+	if _, err := manager.GetSurveyQuestion("survey_a", "intro"); err == nil {
+		t.Error("Expected error for question of another survey")
+	}
+	if _, err := manager.GetSurveyQuestion("unknown", "start"); err == nil {
+		t.Error("Expected error for unknown survey")
+	}
+
+	// Default survey is the first one in ID order
+	question, err = manager.GetQuestion("start")
+	if err != nil || question.Text != "Survey A start" {
+		t.Errorf("Expected default survey question, got %+v (err: %v)", question, err)
+	}
+
+	surveys := manager.ListSurveys()
+	if len(surveys) != 2 || surveys[0].ID != "survey_a" || surveys[1].ID != "survey_b" {
+		t.Errorf("Unexpected surveys list: %+v", surveys)
+	}
+
+	survey, err := manager.GetSurvey("survey_b")
+	if err != nil || survey.StartQuestionID != "intro" {
+		t.Errorf("Unexpected survey: %+v (err: %v)", survey, err)
+	}
+}
+
+func TestSurveyQuestionManagerInvalid(t *testing.T) {
+	if _, err := NewSurveyQuestionManager(nil); err == nil {
+		t.Error("Expected error for empty surveys")
+	}
+
+	duplicate := append(createTestSurveys(), models.Survey{ID: "survey_a"})
+	if _, err := NewSurveyQuestionManager(duplicate); err == nil {
+		t.Error("Expected error for duplicate survey ID")
+	}
+}
+
+func TestQuestionManagerDefaultSurvey(t *testing.T) {
+	manager := NewQuestionManager(map[string]models.Question{"start": {ID: "start"}})
+
+	if surveys := manager.ListSurveys(); len(surveys) != 1 || surveys[0].ID != models.DefaultSurveyID {
+		t.Errorf("Expected single default survey, got %+v", surveys)
+	}
+	if _, err := manager.GetSurveyQuestion(models.DefaultSurveyID, "start"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+// Package services provides business logic services for survey statistics.
+package services
+
+import (
+	"tlgbot/internal/models"
+)
+
+// SurveyStats holds aggregated survey progress
+type SurveyStats struct {
+	Started   int
+	Completed int
+	Expired   int            // Number of unfinished attempts expired after inactivity
+	Abandoned int            // Number of unfinished attempts abandoned by starting over, cancelling or switching surveys
+	Outcomes  map[string]int // Number of completed users per terminal question ID
+	DropOff   map[string]int // Number of unfinished users per current question ID, expired ones included
+	Campaigns map[string]*CampaignStats
+}
+
+// CampaignStats holds survey progress of users attributed to a deep link campaign
+type CampaignStats struct {
+	Started   int
+	Completed int
+}
+
+// CollectStats aggregates survey progress of all attempts, optionally of a single survey.
+// Completed attempts come from submissions and abandoned ones from drop-offs, so users who started over
+// or switched surveys are counted; other unfinished attempts come from the users' current state.
+func CollectStats(states map[int64]*models.UserState, surveyID string) SurveyStats {
+	stats := SurveyStats{
+		Outcomes:  make(map[string]int),
+		DropOff:   make(map[string]int),
+		Campaigns: make(map[string]*CampaignStats),
+	}
+	campaignStats := func(campaign string) *CampaignStats {
+		if campaign == "" {
+			return &CampaignStats{}
+		}
+		c := stats.Campaigns[campaign]
+		if c == nil {
+			c = &CampaignStats{}
+			stats.Campaigns[campaign] = c
+		}
+		return c
+	}
+
+	for _, submission := range CollectSubmissions(states, surveyID) {
+		stats.Started++
+		stats.Completed++
+		stats.Outcomes[submission.Outcome]++
+		campaign := campaignStats(submission.Campaign)
+		campaign.Started++
+		campaign.Completed++
+	}
+
+	for _, state := range states {
+		for _, dropOff := range state.DropOffs {
+			if surveyID != "" && dropOff.SurveyID != surveyID {
+				continue
+			}
+			stats.Started++
+			if dropOff.Expired {
+				stats.Expired++
+			} else {
+				stats.Abandoned++
+			}
+			campaignStats(dropOff.Campaign).Started++
+		}
+
+		// Completed current attempts are already counted by their submissions
+		if state.CurrentQuestionID == "" || state.IsCompleted() || (surveyID != "" && state.SurveyID != surveyID) {
+			continue
+		}
+
+		stats.Started++
+		campaignStats(state.Campaign).Started++
+		if state.IsExpired() {
+			stats.Expired++
+		}
+		stats.DropOff[state.CurrentQuestionID]++
+	}
+
+	return stats
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+func TestCollectStats(t *testing.T) {
+	completed := &models.UserState{CurrentQuestionID: "end", Campaign: "spring", Answers: map[string]string{}}
+	completed.Complete(1, "end", time.Now())
+	// Finished once, then started over
+	restarted := &models.UserState{CurrentQuestionID: "end", Answers: map[string]string{}}
+	restarted.Complete(7, "end", time.Now())
+	restarted.Reset()
+	// Finished another survey, then switched to the default one
+	switched := &models.UserState{SurveyID: "other", CurrentQuestionID: "end", Answers: map[string]string{}}
+	switched.Complete(8, "other_end", time.Now())
+	switched.Reset()
+	switched.SurveyID = ""
+
+	states := map[int64]*models.UserState{
+		1: completed,
+		2: {CurrentQuestionID: "question_1", Campaign: "spring"},
+		3: {CurrentQuestionID: "question_1"},
+		4: {CurrentQuestionID: "question_2"},
+		5: {CurrentQuestionID: ""}, // Never started
+		6: {CurrentQuestionID: "question_2", ExpiredAt: time.Now()},
+		7: restarted,
+		8: switched,
+	}
+
+	stats := CollectStats(states, "")
+
+	if stats.Started != 7 {
+		t.Errorf("Expected 7 started, got %d", stats.Started)
+	}
+	if stats.Completed != 3 {
+		t.Errorf("Expected 3 completed, got %d", stats.Completed)
+	}
+	if stats.Outcomes["end"] != 2 || stats.Outcomes["other_end"] != 1 {
+		t.Errorf("Expected completions of both surveys, got %v", stats.Outcomes)
+	}
+	if stats.DropOff["question_1"] != 2 {
+		t.Errorf("Expected 2 users at question_1, got %d", stats.DropOff["question_1"])
+	}
+	if stats.DropOff["question_2"] != 2 {
+		t.Errorf("Expected 2 users at question_2, got %d", stats.DropOff["question_2"])
+	}
+	if stats.Expired != 1 {
+		t.Errorf("Expected 1 expired, got %d", stats.Expired)
+	}
+	if c := stats.Campaigns["spring"]; c == nil || c.Started != 2 || c.Completed != 1 {
+		t.Errorf("Expected spring campaign with 2 started and 1 completed, got %+v", c)
+	}
+	if _, exists := stats.DropOff["end"]; exists {
+		t.Error("Expected completed users not to be counted as drop-off")
+	}
+}
+
+func TestCollectStatsOfSurvey(t *testing.T) {
+	// Completed the survey, then switched to another one
+	switched := &models.UserState{SurveyID: "quiz", CurrentQuestionID: "end", Answers: map[string]string{}}
+	switched.Complete(1, "end", time.Now())
+	switched.Reset()
+	switched.SurveyID = "feedback"
+	switched.CurrentQuestionID = "start"
+
+	states := map[int64]*models.UserState{
+		1: switched,
+		2: {SurveyID: "quiz", CurrentQuestionID: "q1"},
+	}
+
+	quiz := CollectStats(states, "quiz")
+	if quiz.Started != 2 || quiz.Completed != 1 || quiz.DropOff["q1"] != 1 {
+		t.Errorf("Expected quiz completion kept after switching surveys, got %+v", quiz)
+	}
+	feedback := CollectStats(states, "feedback")
+	if feedback.Started != 1 || feedback.Completed != 0 || feedback.DropOff["start"] != 1 {
+		t.Errorf("Expected only the current feedback attempt, got %+v", feedback)
+	}
+}
+
+func TestCollectStatsDropOffs(t *testing.T) {
+	// Cancelled one attempt, let another expire and started over
+	state := &models.UserState{SurveyID: "quiz", Campaign: "spring", Answers: map[string]string{}}
+	state.Reach("q1", time.Now())
+	state.Abandon(time.Now())
+	state.Reset()
+	state.Reach("q2", time.Now())
+	state.Expire(time.Now())
+	state.Abandon(time.Now())
+	state.Reset()
+	state.Reach("start", time.Now())
+
+	stats := CollectStats(map[int64]*models.UserState{1: state}, "quiz")
+	if stats.Started != 3 || stats.Abandoned != 1 || stats.Expired != 1 || stats.DropOff["start"] != 1 {
+		t.Errorf("Expected cancelled and expired attempts to be counted, got %+v", stats)
+	}
+	if c := stats.Campaigns["spring"]; c == nil || c.Started != 3 {
+		t.Errorf("Expected spring campaign with 3 started, got %+v", c)
+	}
+}
@@ -0,0 +1,228 @@
+// Package services provides business logic services for exporting survey submissions.
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+// Additional formats of submission exports
+const (
+	ExportFormatJSONL = "jsonl"
+	ExportFormatXLSX  = "xlsx"
+)
+
+// SubmissionExportFormats lists formats supported by ExportSubmissions
+var SubmissionExportFormats = []string{ExportFormatCSV, ExportFormatJSON, ExportFormatJSONL, ExportFormatXLSX}
+
+// SubmissionRecord represents an exported submission
+type SubmissionRecord struct {
+	SurveyID    string              `json:"survey_id,omitempty"`
+	UserID      int64               `json:"user_id"`
+	UserName    string              `json:"username,omitempty"`
+	Name        string              `json:"name"`
+	StartedAt   time.Time           `json:"started_at"`
+	CompletedAt time.Time           `json:"completed_at"`
+	Outcome     string              `json:"outcome"`
+	Campaign    string              `json:"campaign,omitempty"`
+	Source      string              `json:"source,omitempty"`
+	Score       *int                `json:"score,omitempty"`
+	Answers     map[string][]string `json:"answers"` // Answer values by question ID
+}
+
+// submissionFixedColumns are table columns preceding the answers
+var submissionFixedColumns = []string{
+	"survey_id", "user_id", "username", "name", "started_at", "completed_at", "outcome", "campaign", "source", "score",
+}
+
+// CollectSubmissions returns submissions of all users, optionally of a single survey,
+// sorted by completion time and user ID
+func CollectSubmissions(states map[int64]*models.UserState, surveyID string) []SubmissionRecord {
+	var records []SubmissionRecord
+	for _, state := range states {
+		for _, submission := range state.Submissions {
+			if surveyID != "" && submission.SurveyID != surveyID {
+				continue
+			}
+			records = append(records, SubmissionRecord{
+				SurveyID:    submission.SurveyID,
+				UserID:      submission.UserID,
+				UserName:    submission.UserName,
+				Name:        submission.Name,
+				StartedAt:   submission.StartedAt,
+				CompletedAt: submission.CompletedAt,
+				Outcome:     submission.TerminalQuestionID,
+				Campaign:    submission.Campaign,
+				Source:      submission.Source,
+				Score:       submission.Score,
+				Answers:     submission.Responses,
+			})
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].CompletedAt.Equal(records[j].CompletedAt) {
+			return records[i].CompletedAt.Before(records[j].CompletedAt)
+		}
+		return records[i].UserID < records[j].UserID
+	})
+	return records
+}
+
+// SubmissionColumns returns IDs of questions answered in any of the submissions in alphabetical order.
+// Taking them before filtering by time keeps the columns of a survey the same in every export.
+func SubmissionColumns(records []SubmissionRecord) []string {
+	seen := make(map[string]struct{})
+	for _, record := range records {
+		for questionID := range record.Answers {
+			seen[questionID] = struct{}{}
+		}
+	}
+
+	columns := make([]string, 0, len(seen))
+	for questionID := range seen {
+		columns = append(columns, questionID)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// SubmissionsSince returns submissions completed at or after the given time; zero time keeps all
+func SubmissionsSince(records []SubmissionRecord, since time.Time) []SubmissionRecord {
+	if since.IsZero() {
+		return records
+	}
+
+	filtered := make([]SubmissionRecord, 0, len(records))
+	for _, record := range records {
+		if !record.CompletedAt.Before(since) {
+			filtered = append(filtered, record)
+		}
+	}
+	return filtered
+}
+
+// ExportSubmissions encodes submissions in the given format, one row or line per submission.
+// Table formats have a column per question ID; questions answered with several values hold them as a JSON array.
+func ExportSubmissions(records []SubmissionRecord, questionIDs []string, format string) ([]byte, error) {
+	switch format {
+	case ExportFormatCSV:
+		return exportSubmissionsCSV(submissionRows(records, questionIDs))
+	case ExportFormatXLSX:
+		return writeXLSX("Responses", submissionRows(records, questionIDs))
+	case ExportFormatJSONL:
+		return exportSubmissionsJSONL(records)
+	case ExportFormatJSON:
+		return exportSubmissionsJSON(records)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// submissionRows converts submissions to table rows with header
+func submissionRows(records []SubmissionRecord, questionIDs []string) [][]string {
+	rows := make([][]string, 0, len(records)+1)
+	rows = append(rows, append(append([]string{}, submissionFixedColumns...), questionIDs...))
+
+	for _, record := range records {
+		score := ""
+		if record.Score != nil {
+			score = strconv.Itoa(*record.Score)
+		}
+		row := []string{
+			record.SurveyID,
+			strconv.FormatInt(record.UserID, 10),
+			record.UserName,
+			record.Name,
+			formatExportTime(record.StartedAt),
+			formatExportTime(record.CompletedAt),
+			record.Outcome,
+			record.Campaign,
+			record.Source,
+			score,
+		}
+		for _, questionID := range questionIDs {
+			row = append(row, encodeAnswerValues(record.Answers[questionID]))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// encodeAnswerValues encodes answer values as a single cell: a single value as is, several as a JSON array
+func encodeAnswerValues(values []string) string {
+	switch len(values) {
+	case 0:
+		return ""
+	case 1:
+		return values[0]
+	default:
+		data, _ := json.Marshal(values) // Strings always encode
+		return string(data)
+	}
+}
+
+// exportSubmissionsCSV writes table rows as CSV with cells that spreadsheets would run as formulas escaped
+func exportSubmissionsCSV(rows [][]string) ([]byte, error) {
+	escaped := make([][]string, len(rows))
+	for i, row := range rows {
+		escaped[i] = make([]string, len(row))
+		for j, value := range row {
+			escaped[i][j] = escapeFormula(value)
+		}
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(escaped); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeFormula prefixes a cell starting like a formula with an apostrophe, so spreadsheets show it as text.
+// Numbers such as negative scores are kept as they are.
+func escapeFormula(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+// exportSubmissionsJSON writes submissions as an indented JSON array
+func exportSubmissionsJSON(records []SubmissionRecord) ([]byte, error) {
+	normalized := make([]SubmissionRecord, len(records))
+	for i, record := range records {
+		if record.Answers == nil {
+			record.Answers = map[string][]string{}
+		}
+		normalized[i] = record
+	}
+	return json.MarshalIndent(normalized, "", "  ")
+}
+
+// exportSubmissionsJSONL writes one JSON object per line
+func exportSubmissionsJSONL(records []SubmissionRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if record.Answers == nil {
+			record.Answers = map[string][]string{}
+		}
+		if err := encoder.Encode(record); err != nil {
+			return nil, fmt.Errorf("failed to encode submission of user %d: %w", record.UserID, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
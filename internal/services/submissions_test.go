@@ -0,0 +1,223 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+func createSubmissionStates() map[int64]*models.UserState {
+	completedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	score := 2
+
+	ann := models.NewUserState("Ann")
+	ann.UserName = "ann"
+	ann.Source = "instagram"
+	ann.SurveyID = "quiz"
+	ann.StartedAt = completedAt.Add(-time.Minute)
+	ann.RecordAnswer("colors", "Colors?", "Red", "Blue")
+	ann.RecordAnswer("size", "Size?", `Large, "XL"`)
+	ann.Complete(1, "end", completedAt)
+	ann.Submissions[0].Score = &score
+	ann.Reset()
+	ann.StartedAt = completedAt.Add(47 * time.Hour)
+	ann.RecordAnswer("colors", "Colors?", "Green")
+	ann.Complete(1, "end", completedAt.Add(48*time.Hour))
+
+	bob := models.NewUserState("Bob")
+	bob.SurveyID = "feedback"
+	bob.RecordAnswer("mood", "Mood?", "Good")
+	bob.Complete(2, "thanks", completedAt.Add(time.Hour))
+
+	// Unfinished attempts have no submissions
+	carol := models.NewUserState("Carol")
+	carol.SurveyID = "quiz"
+	carol.RecordAnswer("colors", "Colors?", "Red")
+
+	return map[int64]*models.UserState{1: ann, 2: bob, 3: carol}
+}
+
+func TestCollectSubmissions(t *testing.T) {
+	records := CollectSubmissions(createSubmissionStates(), "")
+	var users []int64
+	for _, record := range records {
+		users = append(users, record.UserID)
+	}
+	if !reflect.DeepEqual(users, []int64{1, 2, 1}) {
+		t.Errorf("Expected submissions ordered by completion, got users %v", users)
+	}
+
+	quiz := CollectSubmissions(createSubmissionStates(), "quiz")
+	if len(quiz) != 2 {
+		t.Fatalf("Expected 2 quiz submissions, got %d", len(quiz))
+	}
+	if got := SubmissionColumns(quiz); !reflect.DeepEqual(got, []string{"colors", "size"}) {
+		t.Errorf("Expected question columns, got %v", got)
+	}
+
+	recent := SubmissionsSince(quiz, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC))
+	if len(recent) != 1 || recent[0].Answers["colors"][0] != "Green" {
+		t.Errorf("Expected only the second attempt, got %+v", recent)
+	}
+	if got := SubmissionsSince(quiz, time.Time{}); len(got) != 2 {
+		t.Errorf("Expected zero time to keep all submissions, got %d", len(got))
+	}
+}
+
+func TestExportSubmissionsCSV(t *testing.T) {
+	records := CollectSubmissions(createSubmissionStates(), "quiz")
+	columns := SubmissionColumns(records)
+
+	// Columns stay the same when the period leaves no answers to a question
+	data, err := ExportSubmissions(SubmissionsSince(records, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)), columns, ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := "survey_id,user_id,username,name,started_at,completed_at,outcome,campaign,source,score,colors,size\n" +
+		"quiz,1,ann,Ann,2024-05-03T11:00:00Z,2024-05-03T12:00:00Z,end,,instagram,,Green,\n"
+	if string(data) != expected {
+		t.Errorf("Expected CSV\n%s\ngot\n%s", expected, data)
+	}
+
+	data, err = ExportSubmissions(records, columns, ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := `,2,"[""Red"",""Blue""]","Large, ""XL"""`; !strings.Contains(string(data), want) {
+		t.Errorf("Expected score and encoded answers %s, got\n%s", want, data)
+	}
+}
+
+func TestExportSubmissionsCSVEscapesFormulas(t *testing.T) {
+	score := -2
+	records := []SubmissionRecord{{
+		UserID:  1,
+		Name:    "=HYPERLINK(\"http://example.com\")",
+		Score:   &score,
+		Answers: map[string][]string{"a": {"+1+1"}, "b": {"-2+3"}, "c": {"@SUM(A1)"}, "d": {"\tcmd"}, "e": {"\r=1"}, "f": {"fine = ok"}},
+	}}
+
+	data, err := ExportSubmissions(records, SubmissionColumns(records), ExportFormatCSV)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	lines := strings.SplitN(string(data), "\n", 2)
+	expected := `,1,,"'=HYPERLINK(""http://example.com"")",,,,,,-2,'+1+1,'-2+3,'@SUM(A1),'` + "\tcmd,\"'\r=1\",fine = ok\n"
+	if lines[1] != expected {
+		t.Errorf("Expected escaped row %q, got %q", expected, lines[1])
+	}
+
+	// XLSX cells are inline strings and are never run as formulas
+	data, err = ExportSubmissions(records, SubmissionColumns(records), ExportFormatXLSX)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if sheet := readZipParts(t, data)["xl/worksheets/sheet1.xml"]; !strings.Contains(sheet, "<t xml:space=\"preserve\">+1+1</t>") {
+		t.Errorf("Expected XLSX values unchanged, got %s", sheet)
+	}
+}
+
+func TestExportSubmissionsJSONL(t *testing.T) {
+	records := CollectSubmissions(createSubmissionStates(), "")
+	data, err := ExportSubmissions(records, nil, ExportFormatJSONL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d:\n%s", len(lines), data)
+	}
+	var first SubmissionRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Expected valid JSON line, got %v", err)
+	}
+	if !reflect.DeepEqual(first, records[0]) {
+		t.Errorf("Expected %+v, got %+v", records[0], first)
+	}
+	if !strings.Contains(lines[0], `"colors":["Red","Blue"]`) {
+		t.Errorf("Expected answer values as array, got %s", lines[0])
+	}
+}
+
+func TestExportSubmissionsJSON(t *testing.T) {
+	records := CollectSubmissions(createSubmissionStates(), "")
+	data, err := ExportSubmissions(records, nil, ExportFormatJSON)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var decoded []SubmissionRecord
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected JSON array, got %v", err)
+	}
+	if !reflect.DeepEqual(decoded, records) {
+		t.Errorf("Expected %+v, got %+v", records, decoded)
+	}
+}
+
+// readZipParts returns contents of the archive files by name
+func readZipParts(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	archive, err := zip.NewReader(strings.NewReader(string(data)), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Expected ZIP archive, got %v", err)
+	}
+	parts := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(reader)
+		_ = reader.Close()
+		parts[file.Name] = string(content)
+	}
+	return parts
+}
+
+func TestExportSubmissionsXLSX(t *testing.T) {
+	records := CollectSubmissions(createSubmissionStates(), "quiz")
+	data, err := ExportSubmissions(records, SubmissionColumns(records), ExportFormatXLSX)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	parts := readZipParts(t, data)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if parts[name] == "" {
+			t.Errorf("Expected part %s", name)
+		}
+	}
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr"><is><t xml:space="preserve">survey_id</t></is></c>`,
+		`<c r="L1" t="inlineStr"><is><t xml:space="preserve">size</t></is></c>`,
+		`<t xml:space="preserve">Large, &#34;XL&#34;</t>`,
+		`<row r="3">`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("Expected sheet to contain %s, got\n%s", want, sheet)
+		}
+	}
+}
+
+func TestExportSubmissionsUnsupportedFormat(t *testing.T) {
+	if _, err := ExportSubmissions(nil, nil, "xml"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for index, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(index); got != want {
+			t.Errorf("Expected column %d to be %s, got %s", index, want, got)
+		}
+	}
+}
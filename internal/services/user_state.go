@@ -2,21 +2,71 @@
 package services
 
 import (
+	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
+	"tlgbot/internal/logging"
 	"tlgbot/internal/models"
 )
 
-// UserStateManager manages user states
+// UserStateManager manages user states, optionally persisted in a store.
+// States are changed only by goroutines holding the user's lock; readers of all states get copies.
 type UserStateManager struct {
 	mu     sync.RWMutex
 	states map[int64]*models.UserState
+	store  models.StateStore
+	// locks serialize work on each user's state
+	locks map[int64]*sync.Mutex
+	// snapshots hold copies of states taken when their users were last unlocked,
+	// returned for users whose update is being handled
+	snapshots map[int64]*models.UserState
 }
 
-// NewUserStateManager creates a new user state manager
+// NewUserStateManager creates a new user state manager keeping states in memory only
 func NewUserStateManager() *UserStateManager {
+	return newUserStateManager(make(map[int64]*models.UserState), nil)
+}
+
+// newUserStateManager creates a user state manager with the given states and optional store
+func newUserStateManager(states map[int64]*models.UserState, store models.StateStore) *UserStateManager {
 	return &UserStateManager{
-		states: make(map[int64]*models.UserState),
+		states:    states,
+		store:     store,
+		locks:     make(map[int64]*sync.Mutex),
+		snapshots: make(map[int64]*models.UserState),
+	}
+}
+
+// NewPersistentUserStateManager creates a user state manager with states loaded from the store
+func NewPersistentUserStateManager(store models.StateStore) (*UserStateManager, error) {
+	states, err := store.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user states: %w", err)
+	}
+	return newUserStateManager(states, store), nil
+}
+
+// LockUser locks the user's state until the returned function is called.
+// Unlocking keeps a copy of the state for readers of all states.
+func (m *UserStateManager) LockUser(userID int64) func() {
+	m.mu.Lock()
+	lock, exists := m.locks[userID]
+	if !exists {
+		lock = &sync.Mutex{}
+		m.locks[userID] = lock
+	}
+	m.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		m.mu.Lock()
+		if state, exists := m.states[userID]; exists {
+			m.snapshots[userID] = state.Clone()
+		}
+		m.mu.Unlock()
+		lock.Unlock()
 	}
 }
 
@@ -46,7 +96,7 @@ func (m *UserStateManager) UpdateCurrentQuestion(userID int64, questionID string
 	defer m.mu.Unlock()
 
 	if state, exists := m.states[userID]; exists {
-		state.CurrentQuestionID = questionID
+		state.Reach(questionID, time.Now())
 	}
 }
 
@@ -62,3 +112,54 @@ func (m *UserStateManager) GetOrCreateUserState(userID int64, userName string) *
 	}
 	return state
 }
+
+// GetAllUserStates returns copies of all user states. States of users whose update is being handled
+// are returned as they were when the previous one finished; users new in that update are left out.
+func (m *UserStateManager) GetAllUserStates() map[int64]*models.UserState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := make(map[int64]*models.UserState, len(m.states))
+	for userID, state := range m.states {
+		lock := m.locks[userID]
+		switch {
+		case lock == nil:
+			states[userID] = state.Clone()
+		case lock.TryLock():
+			states[userID] = state.Clone()
+			lock.Unlock()
+		case m.snapshots[userID] != nil:
+			states[userID] = m.snapshots[userID].Clone()
+		}
+	}
+	return states
+}
+
+// DeleteUserState removes user state and reports whether it existed
+func (m *UserStateManager) DeleteUserState(userID int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, exists := m.states[userID]
+	delete(m.states, userID)
+	delete(m.snapshots, userID)
+	if m.store != nil {
+		if err := m.store.Delete(userID); err != nil {
+			slog.Error("Failed to delete persisted user state", logging.KeyUserID, userID, "error", err)
+		}
+	}
+	return exists
+}
+
+// SaveUserState persists the user state; it does nothing without a store
+func (m *UserStateManager) SaveUserState(userID int64) error {
+	if m.store == nil {
+		return nil
+	}
+
+	state := m.GetUserState(userID)
+	if state == nil {
+		return nil
+	}
+	return m.store.Save(userID, state)
+}
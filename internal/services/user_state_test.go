@@ -1,9 +1,14 @@
 package services
 
 import (
+	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"tlgbot/internal/models"
+	"tlgbot/internal/storage"
 )
 
 const (
@@ -138,3 +143,154 @@ func TestUserStateManagerConcurrentAccess(_ *testing.T) {
 
 	// Test passed if there was no race condition
 }
+
+func TestUserStateManagerGetAllUserStates(t *testing.T) {
+	manager := NewUserStateManager()
+	manager.GetOrCreateUserState(1, "User1")
+	manager.GetOrCreateUserState(2, "User2")
+
+	states := manager.GetAllUserStates()
+	if len(states) != 2 {
+		t.Fatalf("Expected 2 states, got %d", len(states))
+	}
+
+	// Snapshot must not affect the manager
+	delete(states, 1)
+	if manager.GetUserState(1) == nil {
+		t.Error("Expected state to remain in manager")
+	}
+	states[2].AddAnswer("question", "answer")
+	if len(manager.GetUserState(2).Answers) != 0 {
+		t.Error("Expected returned states to be copies")
+	}
+}
+
+func TestUserStateManagerGetAllUserStatesWhileLocked(t *testing.T) {
+	manager := NewUserStateManager()
+	unlock := manager.LockUser(1)
+	manager.GetOrCreateUserState(1, testUserName).AddAnswer("question", "first")
+	unlock()
+
+	// States of users being handled are returned as of their previous update
+	unlock = manager.LockUser(1)
+	manager.GetUserState(1).AddAnswer("question", "second")
+	if answer := manager.GetAllUserStates()[1].Answers["question"]; answer != "first" {
+		t.Errorf("Expected state as of the previous update, got answer %q", answer)
+	}
+	unlock()
+
+	if answer := manager.GetAllUserStates()[1].Answers["question"]; answer != "second" {
+		t.Errorf("Expected current state after unlock, got answer %q", answer)
+	}
+
+	// A user created during the update being handled is not returned yet
+	unlock = manager.LockUser(2)
+	manager.GetOrCreateUserState(2, "Other")
+	if _, exists := manager.GetAllUserStates()[2]; exists {
+		t.Error("Expected user created in the update being handled to be left out")
+	}
+	unlock()
+}
+
+func TestUserStateManagerLockedChangesAndReads(t *testing.T) {
+	dir, err := storage.NewDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+	manager, err := NewPersistentUserStateManager(dir)
+	if err != nil {
+		t.Fatalf("NewPersistentUserStateManager failed: %v", err)
+	}
+
+	// Updates, expiry and readers of all states run concurrently, as in the bot
+	var wg sync.WaitGroup
+	for worker := 0; worker < 3; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				unlock := manager.LockUser(1)
+				state := manager.GetOrCreateUserState(1, testUserName)
+				state.AddAnswer(fmt.Sprintf("question %d-%d", worker, i), "answer")
+				state.Reach(testQuestionID, time.Now())
+				if err := manager.SaveUserState(1); err != nil {
+					t.Errorf("SaveUserState failed: %v", err)
+				}
+				unlock()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			for _, state := range manager.GetAllUserStates() {
+				_ = len(state.Answers)
+			}
+		}
+	}()
+	wg.Wait()
+
+	if answers := len(manager.GetUserState(1).Answers); answers != 150 {
+		t.Errorf("Expected 150 answers, got %d", answers)
+	}
+}
+
+func TestUserStateManagerDeleteUserState(t *testing.T) {
+	manager := NewUserStateManager()
+	manager.GetOrCreateUserState(1, testUserName)
+
+	if !manager.DeleteUserState(1) {
+		t.Error("Expected existing state to be deleted")
+	}
+	if manager.GetUserState(1) != nil {
+		t.Error("Expected state to be removed")
+	}
+	if manager.DeleteUserState(1) {
+		t.Error("Expected false for non-existent state")
+	}
+}
+
+func TestPersistentUserStateManager(t *testing.T) {
+	dir, err := storage.NewDir(filepath.Join(t.TempDir(), "state"))
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+
+	manager, err := NewPersistentUserStateManager(dir)
+	if err != nil {
+		t.Fatalf("NewPersistentUserStateManager failed: %v", err)
+	}
+	manager.GetOrCreateUserState(1, testUserName)
+	manager.UpdateCurrentQuestion(1, testQuestionID)
+	manager.GetOrCreateUserState(2, "Other")
+	for _, userID := range []int64{1, 2, 3} {
+		if err := manager.SaveUserState(userID); err != nil {
+			t.Fatalf("SaveUserState failed: %v", err)
+		}
+	}
+	manager.DeleteUserState(2)
+
+	// A restarted manager restores saved states
+	restored, err := NewPersistentUserStateManager(dir)
+	if err != nil {
+		t.Fatalf("NewPersistentUserStateManager failed: %v", err)
+	}
+	states := restored.GetAllUserStates()
+	if len(states) != 1 {
+		t.Fatalf("Expected 1 restored state, got %d", len(states))
+	}
+	state := restored.GetUserState(1)
+	if state == nil || state.Name != testUserName || state.CurrentQuestionID != testQuestionID || len(state.Visits) != 1 {
+		t.Errorf("Expected restored state at %s with 1 visit, got %+v", testQuestionID, state)
+	}
+}
+
+func TestUserStateManagerSaveWithoutStore(t *testing.T) {
+	manager := NewUserStateManager()
+	manager.GetOrCreateUserState(1, testUserName)
+
+	if err := manager.SaveUserState(1); err != nil {
+		t.Errorf("Expected saving without store to do nothing, got %v", err)
+	}
+}
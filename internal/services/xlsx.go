@@ -0,0 +1,115 @@
+// Package services provides business logic services for writing spreadsheet exports.
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// xlsxStaticParts are parts of a workbook with a single sheet that do not depend on its content
+var xlsxStaticParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// writeXLSX writes rows as an Excel workbook with a single sheet of text cells
+func writeXLSX(sheetName string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, part := range xlsxStaticParts {
+		if err := writeZipPart(archive, part.name, []byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+
+	var workbook bytes.Buffer
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`)
+	if err := xml.EscapeText(&workbook, []byte(sheetName)); err != nil {
+		return nil, fmt.Errorf("failed to encode sheet name: %w", err)
+	}
+	workbook.WriteString(`" sheetId="1" r:id="rId1"/></sheets></workbook>`)
+	if err := writeZipPart(archive, "xl/workbook.xml", workbook.Bytes()); err != nil {
+		return nil, err
+	}
+
+	sheet, err := xlsxSheet(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeZipPart(archive, "xl/worksheets/sheet1.xml", sheet); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write XLSX: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// xlsxSheet encodes rows as worksheet XML with inline strings
+func xlsxSheet(rows [][]string) ([]byte, error) {
+	var sheet bytes.Buffer
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	for i, row := range rows {
+		rowNumber := strconv.Itoa(i + 1)
+		fmt.Fprintf(&sheet, `<row r="%s">`, rowNumber)
+		for j, value := range row {
+			fmt.Fprintf(&sheet, `<c r="%s%s" t="inlineStr"><is><t xml:space="preserve">`, xlsxColumn(j), rowNumber)
+			// Characters not allowed in XML, such as most control characters, are replaced
+			if err := xml.EscapeText(&sheet, []byte(value)); err != nil {
+				return nil, fmt.Errorf("failed to encode cell: %w", err)
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+	return sheet.Bytes(), nil
+}
+
+// xlsxColumn returns spreadsheet column name of the zero-based index: A, B, ..., Z, AA, ...
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// writeZipPart adds a file to the archive
+func writeZipPart(archive *zip.Writer, name string, content []byte) error {
+	part, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write XLSX part %s: %w", name, err)
+	}
+	if _, err := part.Write(content); err != nil {
+		return fmt.Errorf("failed to write XLSX part %s: %w", name, err)
+	}
+	return nil
+}
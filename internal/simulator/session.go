@@ -0,0 +1,156 @@
+package simulator
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"tlgbot/internal/bot"
+	"tlgbot/internal/handlers"
+	"tlgbot/internal/models"
+	"tlgbot/internal/scheduler"
+	"tlgbot/internal/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5" //nolint:depguard // Required for Telegram bot functionality
+)
+
+// userID identifies the simulated user; private chat ID equals user ID
+const userID = int64(1)
+
+// defaultUserName is the simulated user's first name when none is configured
+const defaultUserName = "Tester"
+
+// Session runs the bot engine for a single simulated user.
+// Updates are handled synchronously, so bot replies are sent before Send and Press return.
+// Reminders and timeouts run on simulated time that only moves on Wait.
+type Session struct {
+	handler         *handlers.TelegramHandler
+	userStates      *services.UserStateManager
+	questionManager models.QuestionService
+	clock           *scheduler.Manual
+	userName        string
+
+	submission *models.Submission
+	nextID     int
+}
+
+// NewSession wires the bot for questions to the messenger
+func NewSession(questions map[string]models.Question, opts Options, m models.Messenger) *Session {
+	cfg := &models.Config{StartQuestionID: opts.StartQuestionID}
+	if opts.Delays {
+		cfg.DelayMs = opts.DelayMs
+	} else {
+		questions = withoutDelays(questions)
+	}
+
+	userName := opts.UserName
+	if userName == "" {
+		userName = defaultUserName
+	}
+
+	userStates := services.NewUserStateManager()
+	questionManager := services.NewQuestionManager(questions)
+	telegramBot := bot.NewTelegramBot(m, cfg, userStates, questionManager)
+	clock := scheduler.NewManual(time.Now())
+	telegramBot.SetScheduler(clock.Scheduler)
+
+	s := &Session{
+		handler:         handlers.NewTelegramHandler(telegramBot, cfg, userStates, questionManager),
+		userStates:      userStates,
+		questionManager: questionManager,
+		clock:           clock,
+		userName:        userName,
+	}
+	telegramBot.AddSink(s)
+	return s
+}
+
+// UserID returns ID of the simulated user
+func (s *Session) UserID() int64 {
+	return userID
+}
+
+// Deliver receives the submission of the completed survey
+func (s *Session) Deliver(submission *models.Submission) error {
+	s.submission = submission
+	return nil
+}
+
+// Submission returns submission of the latest completed attempt, or nil
+func (s *Session) Submission() *models.Submission {
+	return s.submission
+}
+
+// State returns state of the simulated user, or nil before the first message
+func (s *Session) State() *models.UserState {
+	return s.userStates.GetUserState(userID)
+}
+
+// ExpectsText checks if the current question accepts free text input
+func (s *Session) ExpectsText() bool {
+	userState := s.State()
+	if userState == nil {
+		return false
+	}
+
+	question, err := s.questionManager.GetSurveyQuestion(userState.SurveyID, userState.CurrentQuestionID)
+	return err == nil && question.InputType != ""
+}
+
+// Send delivers text message from the user to the bot
+func (s *Session) Send(text string) {
+	message := &tgbotapi.Message{
+		MessageID: s.newID(),
+		From:      s.user(),
+		Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+
+	s.handler.HandleUpdate(tgbotapi.Update{UpdateID: s.newID(), Message: message})
+}
+
+// Press delivers inline button press with the callback data to the bot
+func (s *Session) Press(data string) {
+	callback := &tgbotapi.CallbackQuery{
+		ID:      strconv.Itoa(s.newID()),
+		From:    s.user(),
+		Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: userID, Type: "private"}},
+		Data:    data,
+	}
+
+	s.handler.HandleUpdate(tgbotapi.Update{UpdateID: s.newID(), CallbackQuery: callback})
+}
+
+// Wait lets simulated time pass without user activity, sending reminders and expiring the survey when due
+func (s *Session) Wait(d time.Duration) {
+	s.clock.Advance(d)
+}
+
+// user returns the simulated Telegram user
+func (s *Session) user() *tgbotapi.User {
+	return &tgbotapi.User{ID: userID, FirstName: s.userName}
+}
+
+// newID returns next ID for simulated messages and updates
+func (s *Session) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+// withoutDelays returns copy of questions with message and auto advance delays removed
+func withoutDelays(questions map[string]models.Question) map[string]models.Question {
+	noDelay := 0
+	result := make(map[string]models.Question, len(questions))
+	for id, question := range questions {
+		if question.DelayMs != nil {
+			question.DelayMs = &noDelay
+		}
+		question.AutoAdvanceDelayMs = 0
+		result[id] = question
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package simulator
+
+import (
+	"testing"
+
+	"tlgbot/internal/messenger"
+	"tlgbot/internal/models"
+)
+
+func TestSession(t *testing.T) {
+	recorder := messenger.NewRecorder()
+	session := NewSession(testQuestions(), Options{StartQuestionID: "start"}, recorder)
+
+	if session.State() != nil {
+		t.Error("Expected no state before the first message")
+	}
+
+	session.Send("/start")
+	if texts := recorder.Texts(session.UserID()); len(texts) != 1 || texts[0] != "Hi Tester! Ready?" {
+		t.Fatalf("Unexpected start messages: %v", texts)
+	}
+	if session.ExpectsText() {
+		t.Error("Expected start question not to accept text")
+	}
+
+	session.Press("Yes")
+	if !session.ExpectsText() {
+		t.Error("Expected city question to accept text")
+	}
+	if session.Submission() != nil {
+		t.Error("Expected no submission before completion")
+	}
+
+	session.Send("Paris")
+	submission := session.Submission()
+	if submission == nil {
+		t.Fatal("Expected submission after completion")
+	}
+	if submission.TerminalQuestionID != "end" || submission.Answers["Which city?"] != "Paris" {
+		t.Errorf("Unexpected submission: %+v", submission)
+	}
+}
+
+func TestWithoutDelays(t *testing.T) {
+	delay := 1000
+	questions := map[string]models.Question{
+		"a": {ID: "a", DelayMs: &delay, AutoAdvanceDelayMs: 500},
+		"b": {ID: "b"},
+	}
+
+	result := withoutDelays(questions)
+
+	if *result["a"].DelayMs != 0 || result["a"].AutoAdvanceDelayMs != 0 {
+		t.Errorf("Expected delays removed, got %+v", result["a"])
+	}
+	if result["b"].DelayMs != nil {
+		t.Error("Expected unset delay to stay unset")
+	}
+	if *questions["a"].DelayMs != 1000 {
+		t.Error("Expected original questions to stay unchanged")
+	}
+}
@@ -0,0 +1,148 @@
+// Package simulator runs surveys through the real bot engine without Telegram.
+package simulator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tlgbot/internal/messenger"
+	"tlgbot/internal/models"
+)
+
+// quitCommand ends the simulation
+const quitCommand = "/quit"
+
+// waitCommand lets simulated time pass, e.g. "/wait 30m", to try reminders and timeouts
+const waitCommand = "/wait"
+
+// Options configure a simulation
+type Options struct {
+	StartQuestionID string
+	UserName        string
+	// Delays enables pauses between messages; DelayMs is the default pause when enabled
+	Delays  bool
+	DelayMs int
+}
+
+// Simulator feeds terminal input to the bot and prints its replies
+type Simulator struct {
+	out      io.Writer
+	terminal *messenger.Terminal
+	session  *Session
+}
+
+// New creates a simulator for questions writing output to out
+func New(questions map[string]models.Question, opts Options, out io.Writer) *Simulator {
+	terminal := messenger.NewTerminal(out)
+	return &Simulator{
+		out:      out,
+		terminal: terminal,
+		session:  NewSession(questions, opts, terminal),
+	}
+}
+
+// Run starts the survey and processes input lines until the survey completes, input ends or user quits
+func (s *Simulator) Run(in io.Reader) error {
+	s.printf("Type a number to choose an option, any other text to answer, %s 30m to let time pass, %s to exit.\n\n", waitCommand, quitCommand)
+	s.session.Send("/start")
+
+	scanner := bufio.NewScanner(in)
+	for s.session.Submission() == nil {
+		s.printf("> ")
+		if !scanner.Scan() {
+			s.printf("\n")
+			break
+		}
+
+		input := strings.TrimSpace(scanner.Text())
+		if input == quitCommand {
+			break
+		}
+		s.handleInput(input)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	s.printResult()
+	return nil
+}
+
+// handleInput picks a numbered option or sends input as a message
+func (s *Simulator) handleInput(input string) {
+	if input == "" {
+		return
+	}
+
+	if arg, ok := strings.CutPrefix(input, waitCommand+" "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(arg))
+		if err != nil || d <= 0 {
+			s.printf("Usage: %s <duration>, e.g. %s 30m\n", waitCommand, waitCommand)
+			return
+		}
+		s.session.Wait(d)
+		return
+	}
+
+	if number, err := strconv.Atoi(input); err == nil {
+		if data, ok := s.terminal.Choice(number); ok {
+			s.session.Press(data)
+			return
+		}
+	}
+
+	if !strings.HasPrefix(input, "/") && s.terminal.HasChoices() && !s.session.ExpectsText() {
+		s.printf("Choose one of the numbered options.\n")
+		return
+	}
+	s.session.Send(input)
+}
+
+// printResult prints outcome and answers recorded during the simulation
+func (s *Simulator) printResult() {
+	answers := map[string]string{}
+	if submission := s.session.Submission(); submission != nil {
+		s.printf("\n--- Survey completed at %q ---\n", submission.TerminalQuestionID)
+		answers = submission.Answers
+		if submission.Score != nil {
+			s.printf("Score: %d\n", *submission.Score)
+		}
+	} else if userState := s.session.State(); userState != nil && userState.IsExpired() {
+		s.printf("\n--- Survey expired at %q ---\n", userState.CurrentQuestionID)
+		answers = userState.Answers
+	} else {
+		s.printf("\n--- Survey not completed ---\n")
+		if userState := s.session.State(); userState != nil {
+			answers = userState.Answers
+			if userState.IsScored() {
+				s.printf("Score: %d\n", userState.Score())
+			}
+		}
+	}
+
+	if len(answers) == 0 {
+		s.printf("No answers recorded.\n")
+		return
+	}
+
+	questions := make([]string, 0, len(answers))
+	for question := range answers {
+		questions = append(questions, question)
+	}
+	sort.Strings(questions)
+
+	s.printf("Recorded answers:\n")
+	for _, question := range questions {
+		s.printf("  %s: %s\n", question, answers[question])
+	}
+}
+
+// printf writes to output; write errors are ignored as there is nowhere to report them
+func (s *Simulator) printf(format string, args ...interface{}) {
+	_, _ = fmt.Fprintf(s.out, format, args...)
+}
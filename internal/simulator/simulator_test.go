@@ -0,0 +1,108 @@
+package simulator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+func testQuestions() map[string]models.Question {
+	delay := 5000
+	return map[string]models.Question{
+		"start": {
+			ID:      "start",
+			Text:    "Hi {name}! Ready?",
+			DelayMs: &delay,
+			Options: []models.Option{{Text: "Yes", NextID: "city"}, {Text: "No", NextID: "end"}},
+		},
+		"city": {
+			ID:        "city",
+			Text:      "Which city?",
+			InputType: "text",
+			Options:   []models.Option{{NextID: "end"}},
+		},
+		"end": {ID: "end", Text: "Thanks!"},
+	}
+}
+
+func TestSimulatorCompletesSurvey(t *testing.T) {
+	var out bytes.Buffer
+	sim := New(testQuestions(), Options{StartQuestionID: "start", UserName: "Ann"}, &out)
+
+	if err := sim.Run(strings.NewReader("hello\n1\nParis\n")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	output := out.String()
+	expectedParts := []string{
+		"bot: Hi Ann! Ready?\n  1) Yes\n  2) No\n",
+		"Choose one of the numbered options.",
+		"bot: Which city?\n",
+		"bot: Thanks!",
+		"--- Survey completed at \"end\" ---",
+		"Recorded answers:\n  Hi {name}! Ready?: Yes\n  Which city?: Paris\n",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(output, part) {
+			t.Errorf("Expected output to contain %q, got:\n%s", part, output)
+		}
+	}
+}
+
+func TestSimulatorQuit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"quit command", "1\n/quit\nParis\n", "--- Survey not completed ---\nRecorded answers:\n  Hi {name}! Ready?: Yes\n"},
+		{"end of input", "", "--- Survey not completed ---\nNo answers recorded.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			sim := New(testQuestions(), Options{StartQuestionID: "start"}, &out)
+
+			if err := sim.Run(strings.NewReader(tt.input)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !strings.HasSuffix(out.String(), tt.expected) {
+				t.Errorf("Expected output to end with %q, got:\n%s", tt.expected, out.String())
+			}
+			if strings.Contains(out.String(), "Paris") {
+				t.Error("Expected input after /quit to be ignored")
+			}
+		})
+	}
+}
+
+func TestSimulatorWait(t *testing.T) {
+	questions := testQuestions()
+	city := questions["city"]
+	reminderAfter, timeout := models.Duration(10*time.Minute), models.Duration(time.Hour)
+	city.ReminderAfter, city.Timeout = &reminderAfter, &timeout
+	questions["city"] = city
+
+	var out bytes.Buffer
+	sim := New(questions, Options{StartQuestionID: "start", UserName: "Ann"}, &out)
+
+	if err := sim.Run(strings.NewReader("1\n/wait soon\n/wait 10m\n/wait 1h\n")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	output := out.String()
+	expectedParts := []string{
+		"Usage: /wait <duration>, e.g. /wait 30m",
+		"bot: ⏰ Ann, your survey is waiting for you.",
+		"--- Survey expired at \"city\" ---\nRecorded answers:\n  Hi {name}! Ready?: Yes\n",
+	}
+	for _, part := range expectedParts {
+		if !strings.Contains(output, part) {
+			t.Errorf("Expected output to contain %q, got:\n%s", part, output)
+		}
+	}
+}
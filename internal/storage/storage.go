@@ -0,0 +1,144 @@
+// Package storage persists user states as JSON files, one per user, so survey progress survives restarts
+// and can be read by the analytics and export subcommands.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"tlgbot/internal/models"
+)
+
+// fileExtension is the extension of user state files
+const fileExtension = ".json"
+
+// Dir stores user states in a directory as <user ID>.json.
+// Each state is saved while its user is locked by the user state manager, so a save never reads
+// a state another goroutine is changing.
+type Dir struct {
+	path string
+}
+
+// NewDir opens the state directory, creating it when missing
+func NewDir(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory %s: %w", path, err)
+	}
+	return &Dir{path: path}, nil
+}
+
+// Open opens an existing state directory for reading
+func Open(path string) (*Dir, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("state directory %s is not a directory", path)
+	}
+	return &Dir{path: path}, nil
+}
+
+// Path returns the directory path
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// Save writes the user state atomically, so a crash leaves either the old or the new state
+func (d *Dir) Save(userID int64, state *models.UserState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode state of user %d: %w", userID, err)
+	}
+	if err := WriteFileAtomic(d.file(userID), data); err != nil {
+		return fmt.Errorf("failed to save state of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// Delete removes the user state; a missing state is not an error
+func (d *Dir) Delete(userID int64) error {
+	if err := os.Remove(d.file(userID)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete state of user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// LoadAll reads states of all users. Files not named after a user ID are skipped.
+func (d *Dir) LoadAll() (map[int64]*models.UserState, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state directory: %w", err)
+	}
+
+	states := make(map[int64]*models.UserState, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileExtension) {
+			continue
+		}
+		userID, err := strconv.ParseInt(strings.TrimSuffix(name, fileExtension), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(d.path, name)) //nolint:gosec // G304: Files are listed from the state directory
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state of user %d: %w", userID, err)
+		}
+		state := models.NewUserState("")
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("failed to decode state of user %d: %w", userID, err)
+		}
+		if state.Answers == nil {
+			state.Answers = make(map[string]string)
+		}
+		states[userID] = state
+	}
+	return states, nil
+}
+
+// Check verifies the directory accepts writes, for readiness probes
+func (d *Dir) Check(_ context.Context) error {
+	tmp, err := os.CreateTemp(d.path, ".check-*")
+	if err != nil {
+		return fmt.Errorf("state directory is not writable: %w", err)
+	}
+	_ = tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+// WriteFileAtomic writes data to a temporary file next to path, syncs it and renames it over path,
+// so readers and crashes never see a partly written file. The file is readable by its owner only.
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// file returns path of the user state file
+func (d *Dir) file(userID int64) string {
+	return filepath.Join(d.path, strconv.FormatInt(userID, 10)+fileExtension)
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+func TestDirSaveLoadDelete(t *testing.T) {
+	dir, err := NewDir(filepath.Join(t.TempDir(), "state"))
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+
+	startedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	state := models.NewUserState("Ann")
+	state.SurveyID = "feedback"
+	state.StartedAt = startedAt
+	state.Reach("start", startedAt)
+	state.Reach("q1", startedAt.Add(time.Minute))
+	state.AddAnswer("How are you?", "Good")
+	state.SetScore("q1", models.QuestionScore{Points: 1, Graded: true, Correct: true})
+
+	if err := dir.Save(1, state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := dir.Save(2, models.NewUserState("Bob")); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	// Unrelated files are skipped
+	if err := os.WriteFile(filepath.Join(dir.Path(), "notes.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := dir.LoadAll()
+	if err != nil {
+		t.Fatalf("LoadAll failed: %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("Expected 2 states, got %d", len(states))
+	}
+	loaded := states[1]
+	loaded.UpdatedAt, state.UpdatedAt = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(loaded, state) {
+		t.Errorf("Expected %+v, got %+v", state, loaded)
+	}
+
+	if err := dir.Delete(1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := dir.Delete(1); err != nil {
+		t.Errorf("Expected deleting a missing state to succeed, got %v", err)
+	}
+	if states, _ := dir.LoadAll(); len(states) != 1 || states[2] == nil {
+		t.Errorf("Expected only user 2 left, got %v", states)
+	}
+}
+
+func TestDirLoadAllCorrupted(t *testing.T) {
+	dir, err := NewDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir.Path(), "5.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dir.LoadAll(); err == nil {
+		t.Error("Expected error for corrupted state file")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := t.TempDir()
+	if _, err := Open(path); err != nil {
+		t.Errorf("Expected existing directory to open, got %v", err)
+	}
+	if _, err := Open(filepath.Join(path, "missing")); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+}
+
+func TestDirCheck(t *testing.T) {
+	dir, err := NewDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDir failed: %v", err)
+	}
+	if err := dir.Check(context.Background()); err != nil {
+		t.Errorf("Expected writable directory, got %v", err)
+	}
+
+	if err := os.RemoveAll(dir.Path()); err != nil {
+		t.Fatal(err)
+	}
+	if err := dir.Check(context.Background()); err == nil {
+		t.Error("Expected error for removed directory")
+	}
+}
@@ -0,0 +1,302 @@
+// Package webhook delivers survey results as signed JSON requests to an HTTP endpoint.
+// Events are queued and sent from a background worker with retries, so the bot never waits for the endpoint.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"tlgbot/internal/logging"
+	"tlgbot/internal/metrics"
+	"tlgbot/internal/models"
+)
+
+// Event types
+const (
+	EventSubmission = "submission.completed"
+	EventAnswer     = "answer.recorded"
+)
+
+// Request headers
+const (
+	// SignatureHeader holds "sha256=" and hex HMAC-SHA256 of the request body keyed with the webhook secret
+	SignatureHeader = "X-Tlgbot-Signature"
+	EventHeader     = "X-Tlgbot-Event"
+	// DeliveryHeader holds the event ID, the same for every attempt, so receivers can drop duplicates
+	DeliveryHeader = "X-Tlgbot-Delivery"
+)
+
+// Delivery settings
+const (
+	DefaultBackoff = time.Second
+	maxBackoff     = 5 * time.Minute
+	requestTimeout = 10 * time.Second
+	queueSize      = 1000
+)
+
+// Options configures webhook delivery
+type Options struct {
+	URL            string
+	Secret         string // Signs requests when set
+	MaxAttempts    int
+	DeadLetterPath string // Events failed after all attempts are appended here when set
+	Answers        bool   // Send every answer as it is given besides completed submissions
+	// Backoff is the pause before the second attempt, doubled for each next one; DefaultBackoff when zero
+	Backoff time.Duration
+	Client  *http.Client
+}
+
+// Event is the JSON body of a webhook request
+type Event struct {
+	ID         string             `json:"id"`
+	Type       string             `json:"type"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Submission *models.Submission `json:"submission,omitempty"`
+	Answer     *models.Answer     `json:"answer,omitempty"`
+}
+
+// deadLetter is a line of the dead-letter file
+type deadLetter struct {
+	Event    Event     `json:"event"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Sink sends submissions and, optionally, single answers to the webhook
+type Sink struct {
+	options Options
+	queue   chan Event
+	metrics *metrics.Metrics
+	// mu serializes writes to the dead-letter file
+	mu sync.Mutex
+}
+
+// New creates a webhook sink; events are sent once Run is started
+func New(options Options) *Sink {
+	if options.MaxAttempts < 1 {
+		options.MaxAttempts = 1
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = DefaultBackoff
+	}
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: requestTimeout}
+	}
+	return &Sink{
+		options: options,
+		queue:   make(chan Event, queueSize),
+	}
+}
+
+// SetMetrics enables counting of delivered and failed events
+func (s *Sink) SetMetrics(m *metrics.Metrics) {
+	s.metrics = m
+}
+
+// Len returns the number of events waiting to be sent
+func (s *Sink) Len() int {
+	return len(s.queue)
+}
+
+// Deliver queues the submission for sending
+func (s *Sink) Deliver(submission *models.Submission) error {
+	return s.enqueue(submissionEvent(submission))
+}
+
+// submissionEvent returns the event of the submission; its ID follows the submission ID
+func submissionEvent(submission *models.Submission) Event {
+	id := submission.ID
+	if id == "" {
+		id = models.SubmissionID(submission.UserID, submission.CompletedAt)
+	}
+	return Event{
+		ID:         "submission-" + id,
+		Type:       EventSubmission,
+		CreatedAt:  time.Now(),
+		Submission: submission,
+	}
+}
+
+// Sender returns a sink sending each submission right away in a single attempt and returning its error,
+// for callers that retry on their own such as the outbox
+func (s *Sink) Sender() models.ResultSink {
+	return sender{sink: s}
+}
+
+// sender sends submissions synchronously
+type sender struct {
+	sink *Sink
+}
+
+// Deliver sends the submission and reports failure to the caller without dead-lettering it
+func (s sender) Deliver(submission *models.Submission) error {
+	event := submissionEvent(submission)
+	if _, err := s.sink.send(context.Background(), event); err != nil {
+		return err
+	}
+	s.sink.metrics.WebhookEvent(event.Type, true)
+	return nil
+}
+
+// DeliverAnswer queues the answer for sending when answer events are enabled
+func (s *Sink) DeliverAnswer(answer *models.Answer) error {
+	if !s.options.Answers {
+		return nil
+	}
+	return s.enqueue(Event{
+		ID:        fmt.Sprintf("answer-%d-%s-%d", answer.UserID, answer.QuestionID, answer.AnsweredAt.UnixNano()),
+		Type:      EventAnswer,
+		CreatedAt: time.Now(),
+		Answer:    answer,
+	})
+}
+
+// enqueue adds the event to the queue; when the queue is full the event goes to the dead-letter file
+func (s *Sink) enqueue(event Event) error {
+	select {
+	case s.queue <- event:
+		return nil
+	default:
+		err := errors.New("webhook queue is full")
+		s.fail(event, err)
+		return err
+	}
+}
+
+// Run sends queued events one at a time until ctx is done
+func (s *Sink) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.queue:
+			s.deliver(ctx, event)
+		}
+	}
+}
+
+// deliver sends the event, retrying failures with exponential backoff
+func (s *Sink) deliver(ctx context.Context, event Event) {
+	backoff := s.options.Backoff
+	var err error
+	for attempt := 1; attempt <= s.options.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				s.fail(event, fmt.Errorf("stopped before attempt %d: %w", attempt, err))
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, maxBackoff)
+		}
+
+		var retry bool
+		if retry, err = s.send(ctx, event); err == nil {
+			s.metrics.WebhookEvent(event.Type, true)
+			return
+		}
+		slog.WarnContext(ctx, "Webhook delivery failed", "event_id", event.ID, "attempt", attempt, "error", err)
+		if !retry {
+			break
+		}
+	}
+	s.fail(event, err)
+}
+
+// send makes a single delivery attempt and reports whether a failure is worth retrying
+func (s *Sink) send(ctx context.Context, event Event) (bool, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, event.Type)
+	request.Header.Set(DeliveryHeader, event.ID)
+	if s.options.Secret != "" {
+		request.Header.Set(SignatureHeader, Sign(s.options.Secret, body))
+	}
+
+	response, err := s.options.Client.Do(request)
+	if err != nil {
+		return true, fmt.Errorf("request failed: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		return false, nil
+	case response.StatusCode == http.StatusRequestTimeout || response.StatusCode == http.StatusTooManyRequests ||
+		response.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected status %s", response.Status)
+	default:
+		// Other client errors repeat on every attempt
+		return false, fmt.Errorf("unexpected status %s", response.Status)
+	}
+}
+
+// fail records an event that could not be delivered in the dead-letter file
+func (s *Sink) fail(event Event, cause error) {
+	s.metrics.WebhookEvent(event.Type, false)
+	logger := slog.With("event_id", event.ID, "event", event.Type)
+	if event.Submission != nil {
+		logger = logger.With(logging.KeyUserID, event.Submission.UserID)
+	} else if event.Answer != nil {
+		logger = logger.With(logging.KeyUserID, event.Answer.UserID)
+	}
+
+	if s.options.DeadLetterPath == "" {
+		logger.Error("Webhook event dropped", "error", cause)
+		return
+	}
+	if err := s.writeDeadLetter(deadLetter{Event: event, Error: cause.Error(), FailedAt: time.Now()}); err != nil {
+		logger.Error("Webhook event dropped, failed to write dead letter", "error", cause, "dead_letter_error", err)
+		return
+	}
+	logger.Error("Webhook event written to dead letter file", "error", cause, "path", s.options.DeadLetterPath)
+}
+
+// writeDeadLetter appends the entry to the dead-letter file as a JSON line
+func (s *Sink) writeDeadLetter(entry deadLetter) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.options.DeadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open dead letter file: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write dead letter file: %w", err)
+	}
+	return file.Close()
+}
+
+// Sign returns signature header value of the body: "sha256=" followed by hex HMAC-SHA256 keyed with the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
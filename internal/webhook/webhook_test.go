@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"tlgbot/internal/models"
+)
+
+// receivedRequest is a request recorded by the test endpoint
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newEndpoint starts a server answering requests with the given statuses in turn, the last one repeated
+func newEndpoint(t *testing.T, statuses ...int) (*httptest.Server, func() []receivedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []receivedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, receivedRequest{header: r.Header.Clone(), body: body})
+		status := statuses[min(len(requests), len(statuses))-1]
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]receivedRequest(nil), requests...)
+	}
+}
+
+func createSubmission() *models.Submission {
+	return &models.Submission{
+		SurveyID:           "quiz",
+		UserID:             123,
+		Name:               "Ann",
+		TerminalQuestionID: "end",
+		CompletedAt:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Answers:            map[string]string{"Color?": "Red"},
+	}
+}
+
+// readDeadLetters returns entries of the dead-letter file
+func readDeadLetters(t *testing.T, path string) []deadLetter {
+	t.Helper()
+	file, err := os.Open(path) //nolint:gosec // G304: Test file in a temporary directory
+	if err != nil {
+		t.Fatalf("Failed to open dead letter file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []deadLetter
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Invalid dead letter line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestRunSendsSignedEvent(t *testing.T) {
+	server, requests := newEndpoint(t, http.StatusOK)
+	sink := New(Options{URL: server.URL, Secret: "webhook-secret", MaxAttempts: 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sink.Run(ctx)
+
+	if err := sink.Deliver(createSubmission()); err != nil {
+		t.Fatalf("Deliver failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(requests()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	received := requests()
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 request, got %d", len(received))
+	}
+
+	request := received[0]
+	if got := request.header.Get(SignatureHeader); got != Sign("webhook-secret", request.body) {
+		t.Errorf("Expected signature of the body, got %q", got)
+	}
+	if got := request.header.Get(EventHeader); got != EventSubmission {
+		t.Errorf("Expected event header %s, got %q", EventSubmission, got)
+	}
+	if got := request.header.Get(DeliveryHeader); got != "submission-123-1714564800000000000" {
+		t.Errorf("Expected delivery ID from user and completion time, got %q", got)
+	}
+
+	var event Event
+	if err := json.Unmarshal(request.body, &event); err != nil {
+		t.Fatalf("Expected JSON body, got %v", err)
+	}
+	if event.Type != EventSubmission || event.Submission == nil || event.Submission.Answers["Color?"] != "Red" {
+		t.Errorf("Unexpected event: %+v", event)
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		statuses         []int
+		expectedRequests int
+		expectDeadLetter bool
+	}{
+		{name: "first attempt", statuses: []int{http.StatusNoContent}, expectedRequests: 1},
+		{name: "after server errors", statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, expectedRequests: 3},
+		{name: "attempts exhausted", statuses: []int{http.StatusInternalServerError}, expectedRequests: 4, expectDeadLetter: true},
+		{name: "client error not retried", statuses: []int{http.StatusBadRequest}, expectedRequests: 1, expectDeadLetter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newEndpoint(t, tt.statuses...)
+			deadLetterPath := filepath.Join(t.TempDir(), "dead.jsonl")
+			sink := New(Options{URL: server.URL, MaxAttempts: 4, Backoff: time.Millisecond, DeadLetterPath: deadLetterPath})
+
+			submission := createSubmission()
+			if err := sink.Deliver(submission); err != nil {
+				t.Fatalf("Deliver failed: %v", err)
+			}
+			sink.deliver(context.Background(), <-sink.queue)
+
+			if got := len(requests()); got != tt.expectedRequests {
+				t.Errorf("Expected %d requests, got %d", tt.expectedRequests, got)
+			}
+			_, err := os.Stat(deadLetterPath)
+			if !tt.expectDeadLetter {
+				if err == nil {
+					t.Error("Expected no dead letter file")
+				}
+				return
+			}
+
+			entries := readDeadLetters(t, deadLetterPath)
+			if len(entries) != 1 || entries[0].Event.Submission == nil || entries[0].Event.Submission.UserID != submission.UserID {
+				t.Fatalf("Expected dead letter of the submission, got %+v", entries)
+			}
+			if entries[0].Error == "" || entries[0].FailedAt.IsZero() {
+				t.Errorf("Expected error and failure time, got %+v", entries[0])
+			}
+		})
+	}
+}
+
+func TestDeliverUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	deadLetterPath := filepath.Join(t.TempDir(), "dead.jsonl")
+	sink := New(Options{URL: server.URL, MaxAttempts: 2, Backoff: time.Millisecond, DeadLetterPath: deadLetterPath})
+
+	sink.deliver(context.Background(), Event{ID: "event-1", Type: EventSubmission, Submission: createSubmission()})
+
+	if entries := readDeadLetters(t, deadLetterPath); len(entries) != 1 || entries[0].Event.ID != "event-1" {
+		t.Errorf("Expected dead letter of the unreachable event, got %+v", entries)
+	}
+}
+
+func TestDeliverAnswer(t *testing.T) {
+	answer := &models.Answer{UserID: 123, QuestionID: "color", Answer: "Red"}
+
+	disabled := New(Options{URL: "http://localhost"})
+	if err := disabled.DeliverAnswer(answer); err != nil || len(disabled.queue) != 0 {
+		t.Errorf("Expected answers not to be queued when disabled, got %d queued, error %v", len(disabled.queue), err)
+	}
+
+	enabled := New(Options{URL: "http://localhost", Answers: true})
+	if err := enabled.DeliverAnswer(answer); err != nil {
+		t.Fatalf("DeliverAnswer failed: %v", err)
+	}
+	if event := <-enabled.queue; event.Type != EventAnswer || event.Answer != answer {
+		t.Errorf("Expected answer event, got %+v", event)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	deadLetterPath := filepath.Join(t.TempDir(), "dead.jsonl")
+	sink := New(Options{URL: "http://localhost", DeadLetterPath: deadLetterPath})
+	for i := 0; i < queueSize; i++ {
+		if err := sink.Deliver(createSubmission()); err != nil {
+			t.Fatalf("Deliver %d failed: %v", i, err)
+		}
+	}
+
+	if got := sink.Len(); got != queueSize {
+		t.Errorf("Expected %d queued events, got %d", queueSize, got)
+	}
+
+	if err := sink.Deliver(createSubmission()); err == nil {
+		t.Error("Expected error when the queue is full")
+	}
+	if entries := readDeadLetters(t, deadLetterPath); len(entries) != 1 {
+		t.Errorf("Expected event over the queue size in dead letter file, got %d", len(entries))
+	}
+}
+
+func TestSender(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		expectError bool
+	}{
+		{name: "delivered", status: http.StatusOK},
+		{name: "server error returned", status: http.StatusBadGateway, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newEndpoint(t, tt.status)
+			deadLetterPath := filepath.Join(t.TempDir(), "dead.jsonl")
+			sink := New(Options{URL: server.URL, MaxAttempts: 3, DeadLetterPath: deadLetterPath})
+
+			submission := createSubmission()
+			submission.ID = "123-42"
+			err := sink.Sender().Deliver(submission)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+
+			received := requests()
+			if len(received) != 1 {
+				t.Fatalf("Expected a single attempt, got %d", len(received))
+			}
+			if got := received[0].header.Get(DeliveryHeader); got != "submission-123-42" {
+				t.Errorf("Expected delivery ID from submission ID, got %q", got)
+			}
+			if _, err := os.Stat(deadLetterPath); err == nil {
+				t.Error("Expected failures to be left to the caller, not dead-lettered")
+			}
+		})
+	}
+}